@@ -61,20 +61,59 @@ func main() {
 	walletStore := data.NewWalletStore(sqlcQueries)
 	productStore := data.NewProductStore(sqlcQueries)
 	orderStore := data.NewOrderStore(sqlcQueries)
+	categoryStore := data.NewCategoryStore(sqlcQueries)
+	wishlistStore := data.NewWishlistStore(sqlcQueries)
+	alertStore := data.NewAlertStore(sqlcQueries)
+	couponStore := data.NewCouponStore(sqlcQueries)
+	commissionStore := data.NewCommissionStore(sqlcQueries)
+	handoverStore := data.NewHandoverStore(sqlcQueries)
+	messageStore := data.NewMessageStore(sqlcQueries)
+	reviewStore := data.NewReviewStore(sqlcQueries)
+	moderationStore := data.NewModerationStore(sqlcQueries)
+	signupRuleStore := data.NewSignupRuleStore(sqlcQueries)
 
 	tokenService := service.NewTokenService(tokenStore, logger)
-	walletPaymentService := service.NewWalletPaymentService(dbPool, walletStore, logger)
-	cloudService, err := service.NewCloudinaryService(&cfg, logger)
+	eventService := service.NewEventService(cacheClient, walletStore, logger)
+	walletPaymentService := service.NewWalletPaymentService(dbPool, walletStore, eventService, logger)
+	cloudService, err := service.NewCloudService(&cfg, logger)
 	if err != nil {
-		logger.Error("Cloudinary init error", "error", err)
+		logger.Error("Image storage init error", "error", err)
 		return
 	}
 
-	walletService := service.NewWalletService(walletStore, dbPool, walletPaymentService, logger)
-	userService := service.NewUserService(logger, userStore, walletStore, cacheClient, dbPool, tokenService)
-	productService := service.NewProductService(productStore, cloudService, dbPool, logger)
-	cartService := service.NewCartService(productStore, cacheClient, logger)
-	orderService := service.NewOrderService(orderStore, productStore, walletService, cartService, dbPool, logger)
+	orphanSweeper := service.NewOrphanSweeper(productStore, cloudService, cfg.OrphanSweepInterval, cfg.OrphanGracePeriod, logger)
+	orphanSweeper.Start()
+	defer orphanSweeper.Stop()
+
+	walletService := service.NewWalletService(walletStore, dbPool, walletPaymentService, eventService, logger)
+	signupPolicyService := service.NewSignupPolicyService(signupRuleStore, cfg.AllowedEmailDomains, cfg.RollNumberPattern, logger)
+	userService := service.NewUserService(logger, userStore, walletStore, cacheClient, dbPool, tokenService, signupPolicyService)
+	sellerService := service.NewSellerService(userStore, orderStore, logger)
+	profileService := service.NewProfileService(userStore, sellerService, cloudService, logger)
+	productService := service.NewProductService(productStore, sellerService, cloudService, dbPool, logger)
+	var cartStore data.CartStore
+	if cfg.CartPersistence {
+		cartStore = data.NewCartStore(sqlcQueries)
+	}
+	cartService, err := service.NewCartService(productStore, cartStore, cacheClient, &cfg, logger)
+	if err != nil {
+		logger.Error("Cart service init error", "error", err)
+		return
+	}
+	couponService := service.NewCouponService(couponStore, cartService, walletService, logger)
+	commissionService := service.NewCommissionService(commissionStore, cfg.CommissionDefaultBps, cfg.CommissionMinFee, logger)
+	orderService := service.NewOrderService(orderStore, productStore, walletService, cartService, couponService, commissionService, dbPool, logger)
+	handoverService := service.NewHandoverService(handoverStore, orderService, logger)
+	messageService := service.NewMessageService(messageStore, productStore, orderStore, eventService, dbPool, logger)
+	reviewService := service.NewReviewService(reviewStore, dbPool, logger)
+	moderationService := service.NewModerationService(moderationStore, productService, userStore, eventService, cacheClient, dbPool, cfg.ListingReportThreshold, logger)
+	productService.SetFilter(moderationService)
+	categoryService := service.NewCategoryService(categoryStore, logger)
+	wishlistService := service.NewWishlistService(wishlistStore, productStore, cartService, logger)
+	alertService := service.NewAlertService(alertStore, productStore, cacheClient, cfg.AlertRateLimit, cfg.AlertDigestTime, logger)
+	productService.AddListener(alertService)
+	alertService.Start()
+	defer alertService.Stop()
 
 	api.SetupServer(
 		&cfg,
@@ -86,6 +125,19 @@ func main() {
 		productService,
 		cartService,
 		orderService,
+		categoryService,
+		wishlistService,
+		alertService,
+		couponService,
+		commissionService,
+		handoverService,
+		messageService,
+		reviewService,
+		moderationService,
+		signupPolicyService,
+		profileService,
+		eventService,
+		cloudService,
 		dbPool,
 	)
 }
@@ -14,4 +14,7 @@ type User struct {
 	UpdatedAt     time.Time `json:"updated_at"`
 	Verified      bool      `json:"verified,omitempty"`
 	User_Type     string    `json:"user_type,omitempty"`
+	RollNumber    string    `json:"roll_number,omitempty"`
+	Batch         string    `json:"batch,omitempty"`
+	Branch        string    `json:"branch,omitempty"`
 }
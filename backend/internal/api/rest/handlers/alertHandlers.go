@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/data"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/service"
+	"ecommerce/internal/validator"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AlertHandler struct {
+	Svc    *service.AlertService
+	Logger *slog.Logger
+}
+
+func AlertRoutes(
+	rh *rest.RestHandler,
+	alertSvc *service.AlertService,
+	logger *slog.Logger,
+	protected fiber.Router,
+) {
+	h := &AlertHandler{
+		Svc:    alertSvc,
+		Logger: logger,
+	}
+
+	alertGroup := protected.Group("/alerts")
+
+	alertGroup.Get("/", h.GetAlertsHandler)
+	alertGroup.Put("/mode", h.SetAlertModeHandler)
+	alertGroup.Post("/:product_id", h.SubscribeHandler)
+	alertGroup.Delete("/:product_id", h.UnsubscribeHandler)
+}
+
+func (h *AlertHandler) GetAlertsHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	subs, err := h.Svc.GetSubscriptions(c.Context(), int64(userID))
+	if err != nil {
+		h.Logger.Error("Failed to get alert subscriptions", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve alerts"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(subs)
+}
+
+func (h *AlertHandler) SetAlertModeHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req struct {
+		Mode string `json:"mode"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	v := validator.New()
+	v.Check(validator.PermittedValue(req.Mode, service.AlertModes...), "mode", "must be one of: "+strings.Join(service.AlertModes, ", "))
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	if err := h.Svc.SetMode(c.Context(), int64(userID), req.Mode); err != nil {
+		h.Logger.Error("Failed to set alert mode", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update alert mode"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"mode": req.Mode})
+}
+
+func (h *AlertHandler) SubscribeHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	productID, err := c.ParamsInt("product_id")
+	if err != nil || productID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	var req struct {
+		Kind string `json:"kind"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if resp := validateAlertKind(c, req.Kind); resp != nil {
+		return resp
+	}
+
+	err = h.Svc.Subscribe(c.Context(), int64(userID), int64(productID), req.Kind)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "product not found"})
+		}
+		if errors.Is(err, service.ErrCannotBuyOwnItem) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you cannot set alerts on your own item"})
+		}
+		h.Logger.Error("Failed to subscribe to alert", "user_id", userID, "product_id", productID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not create alert"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "alert created"})
+}
+
+func (h *AlertHandler) UnsubscribeHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	productID, err := c.ParamsInt("product_id")
+	if err != nil || productID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	kind := c.Query("kind")
+	if resp := validateAlertKind(c, kind); resp != nil {
+		return resp
+	}
+
+	err = h.Svc.Unsubscribe(c.Context(), int64(userID), int64(productID), kind)
+	if err != nil {
+		if errors.Is(err, service.ErrAlertNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		h.Logger.Error("Failed to remove alert", "user_id", userID, "product_id", productID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not remove alert"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "alert removed"})
+}
+
+func validateAlertKind(c *fiber.Ctx, kind string) error {
+	v := validator.New()
+	v.Check(validator.PermittedValue(kind, service.AlertKinds...), "kind", "must be one of: "+strings.Join(service.AlertKinds, ", "))
+	if v.Valid() {
+		return nil
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+		Code:    "validation_error",
+		Message: "invalid details",
+		Fields:  v.Errors,
+	})
+}
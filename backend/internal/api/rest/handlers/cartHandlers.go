@@ -3,14 +3,20 @@ package handlers
 import (
 	"ecommerce/internal/api/rest"
 	"ecommerce/internal/data"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/middleware"
 	"ecommerce/internal/service"
 	"errors"
+	"fmt"
 	"log/slog"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+const GuestCartCookie = "guest_cart"
+
 type CartHandler struct {
 	Svc    *service.CartService
 	Logger *slog.Logger
@@ -20,14 +26,13 @@ func CartRoutes(
 	app *rest.RestHandler,
 	cartSvc *service.CartService,
 	logger *slog.Logger,
-	protected fiber.Router,
 ) {
 	h := &CartHandler{
 		Svc:    cartSvc,
 		Logger: logger,
 	}
 
-	cartGroup := protected.Group("/cart")
+	cartGroup := app.App.Group("/cart", middleware.OptionalAuthMiddleware())
 
 	cartGroup.Get("/", h.GetCartHandler)
 	cartGroup.Post("/add", h.AddToCartHandler)
@@ -36,12 +41,79 @@ func CartRoutes(
 	cartGroup.Delete("/clear", h.ClearCartHandler)
 }
 
-func (h *CartHandler) AddToCartHandler(c *fiber.Ctx) error {
-	userID, err := getCurrentUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+// cartOwner resolves the cart a request acts on: the signed-in user's, or the
+// guest cart named by the signed cookie. With create set, a guest without a
+// cookie is given a new session.
+func (h *CartHandler) cartOwner(c *fiber.Ctx, create bool) (service.CartOwner, bool) {
+	if userID, err := getCurrentUserID(c); err == nil {
+		return service.UserCart(int64(userID)), true
+	}
+
+	sessionID, ok := h.Svc.VerifyGuestSession(c.Cookies(GuestCartCookie))
+	if !ok {
+		if !create {
+			return service.CartOwner{}, false
+		}
+		sessionID = h.Svc.NewGuestSession()
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     GuestCartCookie,
+		Value:    h.Svc.SignGuestSession(sessionID),
+		Path:     "/",
+		Expires:  time.Now().Add(h.Svc.GuestTTL),
+		Secure:   h.Svc.SecureCookie,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return service.GuestCart(sessionID), true
+}
+
+// cartError maps the cart service's typed errors to HTTP responses.
+func cartError(c *fiber.Ctx, carts *service.CartService, err error, fallback string) error {
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "product_not_found", Message: "product not found"})
+	case errors.Is(err, service.ErrCartItemNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Code: "item_not_in_cart", Message: err.Error()})
+	case errors.Is(err, service.ErrInvalidQuantity):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Code: "invalid_quantity", Message: err.Error()})
+	case errors.Is(err, service.ErrCannotBuyOwnItem):
+		return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{Code: "own_item", Message: "you cannot add your own item to the cart"})
+	case errors.Is(err, service.ErrProductUnavailable):
+		return c.Status(fiber.StatusGone).JSON(dto.ErrorResponse{Code: "product_unavailable", Message: err.Error()})
+	case errors.Is(err, service.ErrInsufficientStock):
+		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Code: "insufficient_stock", Message: err.Error()})
+	case errors.Is(err, service.ErrQuantityLimit):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(dto.ErrorResponse{Code: "quantity_limit", Message: fmt.Sprintf("you can add at most %d of an item", carts.MaxItemQuantity)})
+	case errors.Is(err, service.ErrCartFull):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(dto.ErrorResponse{Code: "cart_full", Message: fmt.Sprintf("your cart can hold at most %d different items", carts.MaxCartItems)})
+	}
+
+	carts.Logger.Error("Cart operation failed", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+}
+
+// mergeGuestCart moves any guest cart on the request into the user's cart and
+// drops the cookie. Failures are logged rather than failing the login.
+func mergeGuestCart(c *fiber.Ctx, carts *service.CartService, userID int64) {
+	if carts == nil {
+		return
+	}
+
+	sessionID, ok := carts.VerifyGuestSession(c.Cookies(GuestCartCookie))
+	if !ok {
+		return
 	}
 
+	if err := carts.MergeGuestCart(c.Context(), sessionID, userID); err != nil {
+		carts.Logger.Error("Failed to merge guest cart", "user_id", userID, "error", err)
+		return
+	}
+	c.ClearCookie(GuestCartCookie)
+}
+
+func (h *CartHandler) AddToCartHandler(c *fiber.Ctx) error {
 	var req struct {
 		ProductID int64 `json:"product_id"`
 		Quantity  int   `json:"quantity"`
@@ -55,31 +127,22 @@ func (h *CartHandler) AddToCartHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product_id or quantity"})
 	}
 
-	err = h.Svc.AddToCart(c.Context(), int64(userID), req.ProductID, req.Quantity)
+	owner, _ := h.cartOwner(c, true)
+	err := h.Svc.AddToCart(c.Context(), owner, req.ProductID, req.Quantity)
 	if err != nil {
-		if errors.Is(err, data.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "product not found"})
-		}
-		if err.Error() == "insufficient stock" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "insufficient stock"})
-		}
-		if errors.Is(err, service.ErrCannotBuyOwnItem) {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you cannot add your own item to the cart"})
-		}
-		h.Logger.Error("Failed to add item to cart", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not add item to cart"})
+		return cartError(c, h.Svc, err, "could not add item to cart")
 	}
 
 	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{"message": "item added to cart"})
 }
 
 func (h *CartHandler) GetCartHandler(c *fiber.Ctx) error {
-	userID, err := getCurrentUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	owner, ok := h.cartOwner(c, false)
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(service.Cart{Items: []service.CartItem{}})
 	}
 
-	cart, err := h.Svc.GetCart(c.Context(), int64(userID))
+	cart, err := h.Svc.GetCart(c.Context(), owner)
 	if err != nil {
 		h.Logger.Error("Failed to get cart", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve cart"})
@@ -89,9 +152,9 @@ func (h *CartHandler) GetCartHandler(c *fiber.Ctx) error {
 }
 
 func (h *CartHandler) UpdateCartItemHandler(c *fiber.Ctx) error {
-	userID, err := getCurrentUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	owner, ok := h.cartOwner(c, false)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "item not in cart"})
 	}
 
 	var req struct {
@@ -103,23 +166,22 @@ func (h *CartHandler) UpdateCartItemHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
-	if req.ProductID <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product_id"})
+	if req.ProductID <= 0 || req.Quantity < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product_id or quantity"})
 	}
 
-	err = h.Svc.UpdateCartItemQuantity(c.Context(), int64(userID), req.ProductID, req.Quantity)
+	err := h.Svc.UpdateCartItemQuantity(c.Context(), owner, req.ProductID, req.Quantity)
 	if err != nil {
-		h.Logger.Error("Failed to update cart quantity", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update item quantity"})
+		return cartError(c, h.Svc, err, "could not update item quantity")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "cart item updated"})
 }
 
 func (h *CartHandler) DeleteCartItemHandler(c *fiber.Ctx) error {
-	userID, err := getCurrentUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	owner, ok := h.cartOwner(c, false)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "item not in cart"})
 	}
 
 	productID, err := strconv.ParseInt(c.Params("product_id"), 10, 64)
@@ -127,7 +189,7 @@ func (h *CartHandler) DeleteCartItemHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
 	}
 
-	err = h.Svc.DeleteCartItem(c.Context(), int64(userID), productID)
+	err = h.Svc.DeleteCartItem(c.Context(), owner, productID)
 	if err != nil {
 		h.Logger.Error("Failed to delete cart item", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete item from cart"})
@@ -137,12 +199,12 @@ func (h *CartHandler) DeleteCartItemHandler(c *fiber.Ctx) error {
 }
 
 func (h *CartHandler) ClearCartHandler(c *fiber.Ctx) error {
-	userID, err := getCurrentUserID(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	owner, ok := h.cartOwner(c, false)
+	if !ok {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "cart cleared"})
 	}
 
-	err = h.Svc.ClearCart(c.Context(), int64(userID))
+	err := h.Svc.ClearCart(c.Context(), owner)
 	if err != nil {
 		h.Logger.Error("Failed to clear cart", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not clear cart"})
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CategoryHandler struct {
+	Svc *service.CategoryService
+}
+
+func CategoryRoutes(rh *rest.RestHandler, categoryService *service.CategoryService) {
+	h := CategoryHandler{
+		Svc: categoryService,
+	}
+
+	rh.App.Get("/categories", h.ListCategoriesHandler)
+}
+
+func (h *CategoryHandler) ListCategoriesHandler(c *fiber.Ctx) error {
+	categories, err := h.Svc.ListCategoryTree(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "could not retrieve categories",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(categories)
+}
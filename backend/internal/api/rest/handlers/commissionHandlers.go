@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/data"
+	"ecommerce/internal/service"
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CommissionHandler struct {
+	Svc    *service.CommissionService
+	Logger *slog.Logger
+}
+
+func CommissionRoutes(
+	rh *rest.RestHandler,
+	commissionSvc *service.CommissionService,
+	logger *slog.Logger,
+	protected fiber.Router,
+	adminOnly fiber.Handler,
+) {
+	h := &CommissionHandler{
+		Svc:    commissionSvc,
+		Logger: logger,
+	}
+
+	protected.Get("/orders/sales", h.GetSalesHandler)
+
+	adminGroup := protected.Group("/admin", adminOnly)
+	adminGroup.Put("/categories/:id/commission", h.SetCategoryCommissionHandler)
+	adminGroup.Put("/clubs/:user_id", h.SetClubVerifiedHandler)
+}
+
+func (h *CommissionHandler) GetSalesHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	sales, err := h.Svc.GetSales(c.Context(), int64(userID))
+	if err != nil {
+		h.Logger.Error("Failed to get sales", "seller_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve sales"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(sales)
+}
+
+func (h *CommissionHandler) SetCategoryCommissionHandler(c *fiber.Ctx) error {
+	categoryID, err := c.ParamsInt("id")
+	if err != nil || categoryID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid category ID"})
+	}
+
+	var req struct {
+		Bps *int32 `json:"bps"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Bps != nil && (*req.Bps < 0 || *req.Bps > 10000) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "bps must be between 0 and 10000"})
+	}
+
+	if err := h.Svc.SetCategoryRate(c.Context(), int64(categoryID), req.Bps); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "category not found"})
+		}
+		h.Logger.Error("Failed to set category commission", "category_id", categoryID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update commission"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"category_id": categoryID, "bps": req.Bps})
+}
+
+func (h *CommissionHandler) SetClubVerifiedHandler(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("user_id")
+	if err != nil || userID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	var req struct {
+		Verified *bool `json:"verified"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Verified == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "verified is required"})
+	}
+
+	if err := h.Svc.SetClubVerified(c.Context(), int32(userID), *req.Verified); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+		}
+		h.Logger.Error("Failed to set club verification", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update club"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"user_id": userID, "verified": *req.Verified})
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/data"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/service"
+	"ecommerce/internal/validator"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CouponHandler struct {
+	Svc    *service.CouponService
+	Logger *slog.Logger
+}
+
+func CouponRoutes(
+	rh *rest.RestHandler,
+	couponSvc *service.CouponService,
+	logger *slog.Logger,
+	protected fiber.Router,
+	adminOnly fiber.Handler,
+) {
+	h := &CouponHandler{
+		Svc:    couponSvc,
+		Logger: logger,
+	}
+
+	couponGroup := protected.Group("/coupons")
+
+	couponGroup.Post("/preview", h.PreviewCouponHandler)
+	couponGroup.Get("/", adminOnly, h.ListCouponsHandler)
+	couponGroup.Post("/", adminOnly, h.CreateCouponHandler)
+	couponGroup.Get("/budget", adminOnly, h.GetPromoBudgetHandler)
+	couponGroup.Post("/budget", adminOnly, h.FundPromoBudgetHandler)
+	couponGroup.Patch("/:id", adminOnly, h.SetCouponActiveHandler)
+}
+
+// couponErrorStatus maps coupon rejections to a status and error code. It
+// returns a zero status for errors that aren't about the coupon itself.
+func couponErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrCouponNotFound):
+		return fiber.StatusNotFound, "coupon_not_found"
+	case errors.Is(err, service.ErrCouponInactive):
+		return fiber.StatusUnprocessableEntity, "coupon_inactive"
+	case errors.Is(err, service.ErrCouponExpired):
+		return fiber.StatusGone, "coupon_expired"
+	case errors.Is(err, service.ErrCouponUsageLimit):
+		return fiber.StatusGone, "coupon_usage_limit"
+	case errors.Is(err, service.ErrCouponUserLimit):
+		return fiber.StatusConflict, "coupon_user_limit"
+	case errors.Is(err, service.ErrCouponMinCartValue):
+		return fiber.StatusUnprocessableEntity, "coupon_min_cart_value"
+	case errors.Is(err, service.ErrCouponNotApplicable):
+		return fiber.StatusUnprocessableEntity, "coupon_not_applicable"
+	case errors.Is(err, service.ErrPromoBudgetExhausted):
+		return fiber.StatusServiceUnavailable, "promo_budget_exhausted"
+	}
+	return 0, ""
+}
+
+func (h *CouponHandler) PreviewCouponHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "code is required"})
+	}
+
+	quote, err := h.Svc.Preview(c.Context(), int64(userID), req.Code)
+	if err != nil {
+		if status, code := couponErrorStatus(err); status != 0 {
+			return c.Status(status).JSON(dto.ErrorResponse{Code: code, Message: err.Error()})
+		}
+		if errors.Is(err, service.ErrCartEmpty) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cart is empty"})
+		}
+		h.Logger.Error("Failed to preview coupon", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not apply coupon"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(quote)
+}
+
+func (h *CouponHandler) ListCouponsHandler(c *fiber.Ctx) error {
+	coupons, err := h.Svc.ListCoupons(c.Context())
+	if err != nil {
+		h.Logger.Error("Failed to list coupons", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve coupons"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(coupons)
+}
+
+func (h *CouponHandler) CreateCouponHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req struct {
+		Code          string     `json:"code"`
+		Description   string     `json:"description"`
+		DiscountType  string     `json:"discount_type"`
+		DiscountValue int32      `json:"discount_value"`
+		MaxDiscount   *int32     `json:"max_discount"`
+		MinCartValue  int32      `json:"min_cart_value"`
+		UsageLimit    *int32     `json:"usage_limit"`
+		PerUserLimit  *int32     `json:"per_user_limit"`
+		CategoryID    *int64     `json:"category_id"`
+		SellerID      *int64     `json:"seller_id"`
+		StartsAt      *time.Time `json:"starts_at"`
+		EndsAt        *time.Time `json:"ends_at"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	perUserLimit := int32(1)
+	if req.PerUserLimit != nil {
+		perUserLimit = *req.PerUserLimit
+	}
+
+	code := service.NormalizeCouponCode(req.Code)
+	v := validator.New()
+	v.Check(code != "", "code", "must be provided")
+	v.Check(len(code) <= 32, "code", "must not be more than 32 characters")
+	v.Check(!strings.ContainsAny(code, " \t"), "code", "must not contain spaces")
+	v.Check(validator.PermittedValue(req.DiscountType, service.CouponTypes...), "discount_type", "must be one of: "+strings.Join(service.CouponTypes, ", "))
+	v.Check(req.DiscountValue > 0, "discount_value", "must be greater than zero")
+	v.Check(req.DiscountType != service.CouponPercent || req.DiscountValue <= 100, "discount_value", "must not be more than 100 for a percent coupon")
+	v.Check(req.MaxDiscount == nil || *req.MaxDiscount > 0, "max_discount", "must be greater than zero")
+	v.Check(req.MinCartValue >= 0, "min_cart_value", "must not be negative")
+	v.Check(req.UsageLimit == nil || *req.UsageLimit > 0, "usage_limit", "must be greater than zero")
+	v.Check(perUserLimit > 0, "per_user_limit", "must be greater than zero")
+	v.Check(req.EndsAt == nil || req.EndsAt.After(time.Now()), "ends_at", "must be in the future")
+	v.Check(req.EndsAt == nil || req.StartsAt == nil || req.EndsAt.After(*req.StartsAt), "ends_at", "must be after starts_at")
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	coupon, err := h.Svc.CreateCoupon(c.Context(), int64(userID), service.CreateCouponParams{
+		Code:          code,
+		Description:   req.Description,
+		DiscountType:  req.DiscountType,
+		DiscountValue: req.DiscountValue,
+		MaxDiscount:   req.MaxDiscount,
+		MinCartValue:  req.MinCartValue,
+		UsageLimit:    req.UsageLimit,
+		PerUserLimit:  perUserLimit,
+		CategoryID:    req.CategoryID,
+		SellerID:      req.SellerID,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+	})
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateCoupon) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		h.Logger.Error("Failed to create coupon", "code", code, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not create coupon"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(coupon)
+}
+
+func (h *CouponHandler) SetCouponActiveHandler(c *fiber.Ctx) error {
+	couponID, err := c.ParamsInt("id")
+	if err != nil || couponID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid coupon ID"})
+	}
+
+	var req struct {
+		IsActive *bool `json:"is_active"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.IsActive == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "is_active is required"})
+	}
+
+	coupon, err := h.Svc.SetActive(c.Context(), int64(couponID), *req.IsActive)
+	if err != nil {
+		if errors.Is(err, service.ErrCouponNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		h.Logger.Error("Failed to update coupon", "coupon_id", couponID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update coupon"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(coupon)
+}
+
+func (h *CouponHandler) GetPromoBudgetHandler(c *fiber.Ctx) error {
+	wallet, err := h.Svc.PromoBudget(c.Context())
+	if err != nil {
+		h.Logger.Error("Failed to get promo budget", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve promo budget"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(wallet)
+}
+
+func (h *CouponHandler) FundPromoBudgetHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req struct {
+		Amount int64 `json:"amount"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Amount <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "amount must be positive"})
+	}
+
+	wallet, err := h.Svc.FundPromoBudget(c.Context(), int64(userID), req.Amount)
+	if err != nil {
+		h.Logger.Error("Failed to fund promo budget", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not fund promo budget"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(wallet)
+}
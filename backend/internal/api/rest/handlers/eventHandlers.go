@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/service"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type EventHandler struct {
+	Svc    *service.EventService
+	Logger *slog.Logger
+}
+
+func EventRoutes(
+	rh *rest.RestHandler,
+	eventSvc *service.EventService,
+	logger *slog.Logger,
+	protected fiber.Router,
+) {
+	h := &EventHandler{
+		Svc:    eventSvc,
+		Logger: logger,
+	}
+
+	protected.Post("/events/token", h.CreateStreamTokenHandler)
+}
+
+// CreateStreamTokenHandler issues a short-lived token for opening the event
+// stream with EventSource: GET /events?token=<token>.
+func (h *EventHandler) CreateStreamTokenHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	streamToken, err := h.Svc.NewStreamToken(int64(userID))
+	if err != nil {
+		h.Logger.Error("Failed to create stream token", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"token":      streamToken.Plaintext,
+		"expires_at": streamToken.Expiry,
+	})
+}
+
+// StreamEventsHandler streams the caller's events as Server-Sent Events.
+// Clients authenticate with a bearer header or a stream token from
+// POST /events/token, and resume with the Last-Event-ID header, or
+// last_event_id in the query string for clients that can't set it.
+func (h *EventHandler) StreamEventsHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	lastEventID := c.Get("Last-Event-ID", c.Query("last_event_id"))
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		sse := &sseWriter{w: w}
+		if err := sse.write("retry: 3000\n\n"); err != nil {
+			return
+		}
+
+		err := h.Svc.Stream(context.Background(), int64(userID), lastEventID, sse)
+		if errors.Is(err, service.ErrInvalidEventID) {
+			sse.write("event: error\ndata: {\"error\":\"invalid last event ID\"}\n\n")
+			return
+		}
+		if err != nil && !sse.closed {
+			h.Logger.Error("Event stream failed", "user_id", userID, "error", err)
+		}
+	})
+
+	return nil
+}
+
+// sseWriter writes events in the text/event-stream format, flushing each
+// one so it reaches the client straight away.
+type sseWriter struct {
+	w      *bufio.Writer
+	closed bool
+}
+
+func (s *sseWriter) WriteEvent(e service.Event) error {
+	return s.write(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data))
+}
+
+func (s *sseWriter) Ping() error {
+	return s.write(": ping\n\n")
+}
+
+func (s *sseWriter) write(frame string) error {
+	if _, err := s.w.WriteString(frame); err != nil {
+		s.closed = true
+		return err
+	}
+	if err := s.w.Flush(); err != nil {
+		s.closed = true
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/service"
+	"ecommerce/internal/token"
+	"ecommerce/internal/validator"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	maxPickupSlots      = 10
+	maxPickupSlotLength = 4 * time.Hour
+	maxPickupSlotAhead  = 30 * 24 * time.Hour
+)
+
+type HandoverHandler struct {
+	Svc    *service.HandoverService
+	Logger *slog.Logger
+}
+
+func HandoverRoutes(
+	rh *rest.RestHandler,
+	handoverSvc *service.HandoverService,
+	logger *slog.Logger,
+	protected fiber.Router,
+) {
+	h := &HandoverHandler{
+		Svc:    handoverSvc,
+		Logger: logger,
+	}
+
+	protected.Get("/campus-locations", h.ListLocationsHandler)
+
+	subOrderGroup := protected.Group("/orders/sub-orders/:id")
+	subOrderGroup.Get("/handover", h.GetHandoverHandler)
+	subOrderGroup.Put("/slots", h.ProposeSlotsHandler)
+	subOrderGroup.Post("/slots/:slot_id/choose", h.ChooseSlotHandler)
+	subOrderGroup.Post("/handover/code", h.RegenerateCodeHandler)
+	subOrderGroup.Post("/handover", h.CompleteHandoverHandler)
+}
+
+func (h *HandoverHandler) ListLocationsHandler(c *fiber.Ctx) error {
+	locations, err := h.Svc.ListLocations(c.Context())
+	if err != nil {
+		h.Logger.Error("Failed to list campus locations", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve locations"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(locations)
+}
+
+func (h *HandoverHandler) GetHandoverHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	subOrderID, err := c.ParamsInt("id")
+	if err != nil || subOrderID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid sub-order ID"})
+	}
+
+	details, err := h.Svc.GetHandover(c.Context(), int64(userID), int64(subOrderID))
+	if err != nil {
+		return h.handoverError(c, err, "could not retrieve handover")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(details)
+}
+
+func (h *HandoverHandler) ProposeSlotsHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	subOrderID, err := c.ParamsInt("id")
+	if err != nil || subOrderID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid sub-order ID"})
+	}
+
+	var req struct {
+		Slots []struct {
+			LocationID int64     `json:"location_id"`
+			StartsAt   time.Time `json:"starts_at"`
+			EndsAt     time.Time `json:"ends_at"`
+		} `json:"slots"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	now := time.Now()
+	v := validator.New()
+	v.Check(len(req.Slots) > 0, "slots", "must contain at least one slot")
+	v.Check(len(req.Slots) <= maxPickupSlots, "slots", fmt.Sprintf("must not contain more than %d slots", maxPickupSlots))
+
+	slots := make([]service.ProposedSlot, 0, len(req.Slots))
+	for i, s := range req.Slots {
+		key := fmt.Sprintf("slots[%d]", i)
+		v.Check(s.LocationID > 0, key+".location_id", "must be provided")
+		v.Check(s.StartsAt.After(now), key+".starts_at", "must be in the future")
+		v.Check(s.StartsAt.Before(now.Add(maxPickupSlotAhead)), key+".starts_at", "must be within the next 30 days")
+		v.Check(s.EndsAt.After(s.StartsAt), key+".ends_at", "must be after starts_at")
+		v.Check(s.EndsAt.Sub(s.StartsAt) <= maxPickupSlotLength, key+".ends_at", "slot must not be longer than 4 hours")
+		slots = append(slots, service.ProposedSlot{
+			LocationID: s.LocationID,
+			StartsAt:   s.StartsAt,
+			EndsAt:     s.EndsAt,
+		})
+	}
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	proposed, err := h.Svc.ProposeSlots(c.Context(), int64(userID), int64(subOrderID), slots)
+	if err != nil {
+		return h.handoverError(c, err, "could not save pickup slots")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(proposed)
+}
+
+func (h *HandoverHandler) ChooseSlotHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	subOrderID, err := c.ParamsInt("id")
+	if err != nil || subOrderID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid sub-order ID"})
+	}
+
+	slotID, err := c.ParamsInt("slot_id")
+	if err != nil || slotID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid slot ID"})
+	}
+
+	code, err := h.Svc.ChooseSlot(c.Context(), int64(userID), int64(subOrderID), int64(slotID))
+	if err != nil {
+		return h.handoverError(c, err, "could not choose pickup slot")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(code)
+}
+
+func (h *HandoverHandler) RegenerateCodeHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	subOrderID, err := c.ParamsInt("id")
+	if err != nil || subOrderID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid sub-order ID"})
+	}
+
+	code, err := h.Svc.RegenerateCode(c.Context(), int64(userID), int64(subOrderID))
+	if err != nil {
+		return h.handoverError(c, err, "could not generate handover code")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(code)
+}
+
+func (h *HandoverHandler) CompleteHandoverHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	subOrderID, err := c.ParamsInt("id")
+	if err != nil || subOrderID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid sub-order ID"})
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	code := strings.TrimSpace(req.Code)
+	v := validator.New()
+	if err := (&token.Token{}).ValidateVerificationToken(v, code); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	subOrder, err := h.Svc.CompleteHandover(c.Context(), int64(userID), int64(subOrderID), code)
+	if err != nil {
+		return h.handoverError(c, err, "could not complete handover")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(subOrder)
+}
+
+func (h *HandoverHandler) handoverError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound),
+		errors.Is(err, service.ErrPickupSlotNotFound),
+		errors.Is(err, service.ErrPickupLocationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrNotOrderParticipant):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrSubOrderNotPending),
+		errors.Is(err, service.ErrPickupSlotChosen),
+		errors.Is(err, service.ErrNoPickupSlotChosen):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrPickupSlotPassed),
+		errors.Is(err, service.ErrHandoverCodeExpired):
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrHandoverCodeInvalid):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrHandoverLocked):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	h.Logger.Error("Handover operation failed", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+}
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/data"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/service"
+	"ecommerce/internal/validator"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MessageHandler struct {
+	Svc    *service.MessageService
+	Logger *slog.Logger
+}
+
+func MessageRoutes(
+	rh *rest.RestHandler,
+	messageSvc *service.MessageService,
+	logger *slog.Logger,
+	protected fiber.Router,
+	adminOnly fiber.Handler,
+) {
+	h := &MessageHandler{
+		Svc:    messageSvc,
+		Logger: logger,
+	}
+
+	conversationGroup := protected.Group("/conversations")
+
+	conversationGroup.Get("/", h.ListConversationsHandler)
+	conversationGroup.Post("/", h.StartConversationHandler)
+	conversationGroup.Get("/unread", h.UnreadCountHandler)
+	conversationGroup.Get("/:id/messages", h.GetMessagesHandler)
+	conversationGroup.Post("/:id/messages", h.SendMessageHandler)
+	conversationGroup.Post("/:id/read", h.MarkReadHandler)
+
+	protected.Post("/messages/:id/report", h.ReportMessageHandler)
+
+	blockGroup := protected.Group("/blocks")
+	blockGroup.Get("/", h.ListBlockedUsersHandler)
+	blockGroup.Put("/:user_id", h.BlockUserHandler)
+	blockGroup.Delete("/:user_id", h.UnblockUserHandler)
+
+	protected.Get("/admin/message-reports", adminOnly, h.ListReportsHandler)
+}
+
+func (h *MessageHandler) ListConversationsHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	conversations, err := h.Svc.ListConversations(c.Context(), int64(userID))
+	if err != nil {
+		h.Logger.Error("Failed to list conversations", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve conversations"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(conversations)
+}
+
+func (h *MessageHandler) StartConversationHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req struct {
+		ProductID  *int64 `json:"product_id"`
+		SubOrderID *int64 `json:"sub_order_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if (req.ProductID == nil) == (req.SubOrderID == nil) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "exactly one of product_id or sub_order_id is required"})
+	}
+
+	conversation, err := h.Svc.StartConversation(c.Context(), int64(userID), req.ProductID, req.SubOrderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "product not found"})
+		case errors.Is(err, service.ErrOrderNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrNotOrderParticipant):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrCannotMessageSelf):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		h.Logger.Error("Failed to start conversation", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not start conversation"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(conversation)
+}
+
+func (h *MessageHandler) UnreadCountHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	count, err := h.Svc.UnreadCount(c.Context(), int64(userID))
+	if err != nil {
+		h.Logger.Error("Failed to count unread messages", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not count unread messages"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"unread": count})
+}
+
+func (h *MessageHandler) GetMessagesHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	conversationID, err := c.ParamsInt("id")
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid conversation ID"})
+	}
+
+	var beforeID *int64
+	if before := c.Query("before"); before != "" {
+		id, err := strconv.ParseInt(before, 10, 64)
+		if err != nil || id <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid before cursor"})
+		}
+		beforeID = &id
+	}
+
+	limit := c.QueryInt("limit", service.DefaultMessagePageSize)
+	if limit <= 0 || limit > service.MaxMessagePageSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "limit must be between 1 and " + strconv.Itoa(service.MaxMessagePageSize)})
+	}
+
+	page, err := h.Svc.GetMessages(c.Context(), int64(userID), int64(conversationID), beforeID, int32(limit))
+	if err != nil {
+		return h.messageError(c, err, "could not retrieve messages")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+func (h *MessageHandler) SendMessageHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	conversationID, err := c.ParamsInt("id")
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid conversation ID"})
+	}
+
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	body := strings.TrimSpace(req.Body)
+	v := validator.New()
+	v.Check(body != "", "body", "must be provided")
+	v.Check(utf8.RuneCountInString(body) <= 2000, "body", "must not be more than 2000 characters")
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	message, err := h.Svc.SendMessage(c.Context(), int64(userID), int64(conversationID), body)
+	if err != nil {
+		return h.messageError(c, err, "could not send message")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(message)
+}
+
+func (h *MessageHandler) MarkReadHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	conversationID, err := c.ParamsInt("id")
+	if err != nil || conversationID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid conversation ID"})
+	}
+
+	if err := h.Svc.MarkRead(c.Context(), int64(userID), int64(conversationID)); err != nil {
+		return h.messageError(c, err, "could not mark conversation read")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *MessageHandler) ReportMessageHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	messageID, err := c.ParamsInt("id")
+	if err != nil || messageID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid message ID"})
+	}
+
+	var req struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	details := strings.TrimSpace(req.Details)
+	v := validator.New()
+	v.Check(validator.PermittedValue(req.Reason, service.ReportReasons...), "reason", "must be one of: "+strings.Join(service.ReportReasons, ", "))
+	v.Check(utf8.RuneCountInString(details) <= 1000, "details", "must not be more than 1000 characters")
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	report, err := h.Svc.ReportMessage(c.Context(), int64(userID), int64(messageID), req.Reason, details)
+	if err != nil {
+		return h.messageError(c, err, "could not report message")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+func (h *MessageHandler) ListBlockedUsersHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	blocked, err := h.Svc.ListBlockedUsers(c.Context(), int64(userID))
+	if err != nil {
+		h.Logger.Error("Failed to list blocked users", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve blocked users"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(blocked)
+}
+
+func (h *MessageHandler) BlockUserHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	blockedID, err := c.ParamsInt("user_id")
+	if err != nil || blockedID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	if err := h.Svc.BlockUser(c.Context(), int64(userID), int64(blockedID)); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+		}
+		return h.messageError(c, err, "could not block user")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *MessageHandler) UnblockUserHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	blockedID, err := c.ParamsInt("user_id")
+	if err != nil || blockedID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	if err := h.Svc.UnblockUser(c.Context(), int64(userID), int64(blockedID)); err != nil {
+		return h.messageError(c, err, "could not unblock user")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *MessageHandler) ListReportsHandler(c *fiber.Ctx) error {
+	reports, err := h.Svc.ListReports(c.Context())
+	if err != nil {
+		h.Logger.Error("Failed to list message reports", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve reports"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(reports)
+}
+
+func (h *MessageHandler) messageError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrConversationNotFound),
+		errors.Is(err, service.ErrMessageNotFound),
+		errors.Is(err, service.ErrBlockNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrUserBlocked):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrCannotBlockSelf):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyReported):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	h.Logger.Error("Messaging operation failed", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+}
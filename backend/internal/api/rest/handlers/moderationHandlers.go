@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/service"
+	"ecommerce/internal/validator"
+	"errors"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ModerationHandler struct {
+	Svc    *service.ModerationService
+	Logger *slog.Logger
+}
+
+func ModerationRoutes(
+	rh *rest.RestHandler,
+	moderationSvc *service.ModerationService,
+	logger *slog.Logger,
+	protected fiber.Router,
+	adminOnly fiber.Handler,
+) {
+	h := &ModerationHandler{
+		Svc:    moderationSvc,
+		Logger: logger,
+	}
+
+	protected.Post("/products/:id/report", h.ReportListingHandler)
+
+	adminGroup := protected.Group("/admin/moderation", adminOnly)
+	adminGroup.Get("/listings", h.QueueHandler)
+	adminGroup.Get("/listings/:id/reports", h.ListReportsHandler)
+	adminGroup.Post("/listings/:id/remove", h.RemoveListingHandler)
+	adminGroup.Post("/listings/:id/restore", h.RestoreListingHandler)
+	adminGroup.Get("/banned-terms", h.ListBannedTermsHandler)
+	adminGroup.Post("/banned-terms", h.AddBannedTermHandler)
+	adminGroup.Delete("/banned-terms/:id", h.DeleteBannedTermHandler)
+}
+
+func (h *ModerationHandler) ReportListingHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	productID, err := c.ParamsInt("id")
+	if err != nil || productID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	var req struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	details := strings.TrimSpace(req.Details)
+
+	v := validator.New()
+	v.Check(validator.PermittedValue(req.Reason, service.ListingReportReasons...), "reason", "must be one of: "+strings.Join(service.ListingReportReasons, ", "))
+	v.Check(utf8.RuneCountInString(details) <= 1000, "details", "must not be more than 1000 characters")
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	report, err := h.Svc.ReportListing(c.Context(), int64(userID), int64(productID), req.Reason, details)
+	if err != nil {
+		return h.moderationError(c, err, "could not report listing")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+func (h *ModerationHandler) QueueHandler(c *fiber.Ctx) error {
+	queue, err := h.Svc.Queue(c.Context())
+	if err != nil {
+		h.Logger.Error("Failed to load moderation queue", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve moderation queue"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(queue)
+}
+
+func (h *ModerationHandler) ListReportsHandler(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil || productID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	reports, err := h.Svc.ListReports(c.Context(), int64(productID))
+	if err != nil {
+		h.Logger.Error("Failed to list listing reports", "product_id", productID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve reports"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(reports)
+}
+
+func (h *ModerationHandler) RemoveListingHandler(c *fiber.Ctx) error {
+	adminID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	productID, err := c.ParamsInt("id")
+	if err != nil || productID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+
+	v := validator.New()
+	v.Check(reason != "", "reason", "must be provided")
+	v.Check(utf8.RuneCountInString(reason) <= 500, "reason", "must not be more than 500 characters")
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	product, err := h.Svc.RemoveListing(c.Context(), int64(adminID), int64(productID), reason)
+	if err != nil {
+		return h.moderationError(c, err, "could not remove listing")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(product)
+}
+
+func (h *ModerationHandler) RestoreListingHandler(c *fiber.Ctx) error {
+	adminID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	productID, err := c.ParamsInt("id")
+	if err != nil || productID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	product, err := h.Svc.RestoreListing(c.Context(), int64(adminID), int64(productID))
+	if err != nil {
+		return h.moderationError(c, err, "could not restore listing")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(product)
+}
+
+func (h *ModerationHandler) ListBannedTermsHandler(c *fiber.Ctx) error {
+	terms, err := h.Svc.ListBannedTerms(c.Context())
+	if err != nil {
+		h.Logger.Error("Failed to list banned terms", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve banned terms"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(terms)
+}
+
+func (h *ModerationHandler) AddBannedTermHandler(c *fiber.Ctx) error {
+	adminID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req struct {
+		Pattern string `json:"pattern"`
+		IsRegex bool   `json:"is_regex"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	pattern := strings.TrimSpace(req.Pattern)
+
+	v := validator.New()
+	v.Check(pattern != "", "pattern", "must be provided")
+	v.Check(utf8.RuneCountInString(pattern) <= 200, "pattern", "must not be more than 200 characters")
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	term, err := h.Svc.AddBannedTerm(c.Context(), int64(adminID), pattern, req.IsRegex)
+	if err != nil {
+		return h.moderationError(c, err, "could not add banned term")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(term)
+}
+
+func (h *ModerationHandler) DeleteBannedTermHandler(c *fiber.Ctx) error {
+	termID, err := c.ParamsInt("id")
+	if err != nil || termID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid banned term ID"})
+	}
+
+	if err := h.Svc.DeleteBannedTerm(c.Context(), int64(termID)); err != nil {
+		return h.moderationError(c, err, "could not delete banned term")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ModerationHandler) moderationError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrListingNotFound),
+		errors.Is(err, service.ErrBannedTermNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrCannotReportOwnListing),
+		errors.Is(err, service.ErrInvalidBannedTermPattern):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyReportedListing),
+		errors.Is(err, service.ErrBannedTermExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.Logger.Error("Moderation operation failed", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+}
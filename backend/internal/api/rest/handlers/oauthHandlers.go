@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/service"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/markbates/goth"
+)
+
+const (
+	oauthStateCookie = "oauth_state"
+	oauthStateTTL    = 10 * time.Minute
+)
+
+type OAuthHandler struct {
+	Svc      *service.UserService
+	Carts    *service.CartService
+	Provider goth.Provider
+	// SuccessRedirect, when set, receives the tokens in its URL fragment
+	// instead of the callback answering with JSON.
+	SuccessRedirect string
+	Logger          *slog.Logger
+}
+
+// OAuthRoutes registers the sign-in routes. They are public, so call this
+// before the authenticated group is set up.
+func OAuthRoutes(rh *rest.RestHandler, h *OAuthHandler) {
+	group := rh.App.Group("/auth/" + h.Provider.Name())
+	group.Get("/", h.BeginAuthHandler)
+	group.Get("/callback", h.CallbackHandler)
+}
+
+func (h *OAuthHandler) BeginAuthHandler(c *fiber.Ctx) error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		h.Logger.Error("Failed to generate OAuth state", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not start sign-in"})
+	}
+	state := base64.RawURLEncoding.EncodeToString(b)
+
+	sess, err := h.Provider.BeginAuth(state)
+	if err != nil {
+		h.Logger.Error("Failed to begin OAuth", "provider", h.Provider.Name(), "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not start sign-in"})
+	}
+	authURL, err := sess.GetAuthURL()
+	if err != nil {
+		h.Logger.Error("Failed to build OAuth URL", "provider", h.Provider.Name(), "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not start sign-in"})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthStateCookie,
+		Value:    state,
+		Path:     "/auth",
+		Expires:  time.Now().Add(oauthStateTTL),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect(authURL, fiber.StatusTemporaryRedirect)
+}
+
+func (h *OAuthHandler) CallbackHandler(c *fiber.Ctx) error {
+	expected := c.Cookies(oauthStateCookie)
+	c.Cookie(&fiber.Cookie{
+		Name:     oauthStateCookie,
+		Path:     "/auth",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	if reason := c.Query("error"); reason != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sign-in was cancelled"})
+	}
+
+	state := c.Query("state")
+	if expected == "" || subtle.ConstantTimeCompare([]byte(state), []byte(expected)) != 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid or expired sign-in attempt, please try again"})
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing authorization code"})
+	}
+
+	sess, err := h.Provider.BeginAuth(state)
+	if err != nil {
+		h.Logger.Error("Failed to create OAuth session", "provider", h.Provider.Name(), "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not complete sign-in"})
+	}
+	if _, err := sess.Authorize(h.Provider, url.Values{"code": {code}}); err != nil {
+		h.Logger.Warn("OAuth code exchange failed", "provider", h.Provider.Name(), "error", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "could not complete sign-in"})
+	}
+	gu, err := h.Provider.FetchUser(sess)
+	if err != nil {
+		h.Logger.Warn("Failed to fetch OAuth user", "provider", h.Provider.Name(), "error", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "could not complete sign-in"})
+	}
+
+	u, accessToken, refreshToken, err := h.Svc.LoginWithGoogle(c.Context(), service.OAuthIdentity{
+		Subject:       gu.UserID,
+		Email:         gu.Email,
+		EmailVerified: claimTrue(gu.RawData["email_verified"]),
+		Name:          gu.Name,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOAuthEmailUnverified),
+			errors.Is(err, service.ErrEmailDomainNotAllowed):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrOAuthAccountConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		h.Logger.Error("Internal error during Google sign-in", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "An internal server error has occurred"})
+	}
+
+	mergeGuestCart(c, h.Carts, int64(u.ID))
+
+	if h.SuccessRedirect != "" {
+		fragment := url.Values{
+			"access_token":  {accessToken},
+			"refresh_token": {refreshToken},
+		}
+		return c.Redirect(h.SuccessRedirect+"#"+fragment.Encode(), fiber.StatusSeeOther)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"name":          u.Name,
+		"phone":         u.Phone,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	})
+}
+
+// claimTrue reads a boolean claim, which some providers send as a string.
+func claimTrue(v any) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+	return false
+}
@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"bytes"
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/auth"
+	db "ecommerce/internal/data/gen"
+	"ecommerce/internal/service"
+	"ecommerce/internal/token"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	testClientID     = "test-client"
+	testClientSecret = "test-secret"
+	testCallbackURL  = "http://api.test/auth/google/callback"
+)
+
+type oauthTest struct {
+	app *fiber.App
+	idp *fakeOIDC
+	db  *memDB
+}
+
+func newOAuthTest(t *testing.T) *oauthTest {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+
+	idp := newFakeOIDC(t, testClientID, testClientSecret, testCallbackURL)
+	provider, err := auth.NewAuth(testClientID, testClientSecret, testCallbackURL, idp.DiscoveryURL())
+	if err != nil {
+		t.Fatalf("NewAuth: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := newMemDB()
+	tokens := service.NewTokenService(&memTokenStore{db: store}, logger)
+	policy := service.NewSignupPolicyService(noSignupRules{}, nil, nil, logger)
+	users := service.NewUserService(logger, &memUserStore{db: store}, &memWalletStore{db: store}, nopCache{}, store, tokens, policy)
+
+	app := fiber.New()
+	OAuthRoutes(&rest.RestHandler{App: app, Logger: logger}, &OAuthHandler{
+		Svc:      users,
+		Provider: provider,
+		Logger:   logger,
+	})
+
+	return &oauthTest{app: app, idp: idp, db: store}
+}
+
+func (o *oauthTest) do(t *testing.T, req *http.Request) *http.Response {
+	t.Helper()
+	resp, err := o.app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// begin starts a sign-in and returns the state the provider was sent, after
+// checking it was also set in the state cookie.
+func (o *oauthTest) begin(t *testing.T) string {
+	t.Helper()
+
+	resp := o.do(t, httptest.NewRequest(http.MethodGet, "/auth/google", nil))
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("begin: status = %d, want %d", resp.StatusCode, http.StatusTemporaryRedirect)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("begin: bad redirect: %v", err)
+	}
+	if got, want := location.Scheme+"://"+location.Host+location.Path, o.idp.URL+"/authorize"; got != want {
+		t.Fatalf("begin: redirected to %s, want %s", got, want)
+	}
+	if got := location.Query().Get("client_id"); got != testClientID {
+		t.Fatalf("begin: client_id = %q, want %q", got, testClientID)
+	}
+
+	state := location.Query().Get("state")
+	if state == "" {
+		t.Fatal("begin: no state in authorization URL")
+	}
+	for _, c := range resp.Cookies() {
+		if c.Name == oauthStateCookie {
+			if c.Value != state {
+				t.Fatalf("begin: state cookie = %q, want %q", c.Value, state)
+			}
+			return state
+		}
+	}
+	t.Fatal("begin: state cookie not set")
+	return ""
+}
+
+func (o *oauthTest) callback(t *testing.T, cookieState, state, code string) *http.Response {
+	t.Helper()
+
+	q := url.Values{}
+	if state != "" {
+		q.Set("state", state)
+	}
+	q.Set("code", code)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/google/callback?"+q.Encode(), nil)
+	if cookieState != "" {
+		req.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: cookieState})
+	}
+	return o.do(t, req)
+}
+
+// signIn runs the whole flow for id and returns the callback response.
+func (o *oauthTest) signIn(t *testing.T, id oidcIdentity) *http.Response {
+	t.Helper()
+	state := o.begin(t)
+	return o.callback(t, state, state, o.idp.issueCode(id))
+}
+
+type loginResponse struct {
+	Name         string `json:"name"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	Error        string `json:"error"`
+}
+
+func decodeLogin(t *testing.T, resp *http.Response) loginResponse {
+	t.Helper()
+	var body loginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return body
+}
+
+func expectStatus(t *testing.T, resp *http.Response, want int) {
+	t.Helper()
+	if resp.StatusCode != want {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		t.Fatalf("status = %d, want %d; body: %s", resp.StatusCode, want, b)
+	}
+}
+
+// userIDFromAccessToken checks the access token and returns its user.
+func userIDFromAccessToken(t *testing.T, accessToken string) int64 {
+	t.Helper()
+	claims, err := token.VerifyAccessToken(accessToken)
+	if err != nil {
+		t.Fatalf("access token does not verify: %v", err)
+	}
+	return claims.UserID
+}
+
+func TestGoogleCallbackRejectsStateMismatch(t *testing.T) {
+	alice := oidcIdentity{Subject: "g-alice", Email: "alice@college.edu", EmailVerified: true, Name: "Alice"}
+
+	tests := []struct {
+		name  string
+		state func(o *oauthTest, t *testing.T) (cookie, query string)
+	}{
+		{"different state", func(o *oauthTest, t *testing.T) (string, string) {
+			return o.begin(t), "forged-state"
+		}},
+		{"state from another attempt", func(o *oauthTest, t *testing.T) (string, string) {
+			return o.begin(t), o.begin(t)
+		}},
+		{"no state cookie", func(o *oauthTest, t *testing.T) (string, string) {
+			return "", o.begin(t)
+		}},
+		{"no state parameter", func(o *oauthTest, t *testing.T) (string, string) {
+			return o.begin(t), ""
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOAuthTest(t)
+			cookie, query := tt.state(o, t)
+
+			resp := o.callback(t, cookie, query, o.idp.issueCode(alice))
+			expectStatus(t, resp, http.StatusBadRequest)
+
+			if n := o.idp.Exchanges(); n != 0 {
+				t.Errorf("code was exchanged %d times, want 0", n)
+			}
+			if n := o.db.userCount(); n != 0 {
+				t.Errorf("%d users created, want 0", n)
+			}
+		})
+	}
+}
+
+func TestGoogleSignInRejectsUnverifiedEmail(t *testing.T) {
+	o := newOAuthTest(t)
+	existing := o.db.addUser(db.User{
+		Name:         "Bob",
+		Email:        "bob@college.edu",
+		PasswordHash: pgtype.Text{String: "hash", Valid: true},
+	})
+
+	resp := o.signIn(t, oidcIdentity{Subject: "g-bob", Email: "bob@college.edu", EmailVerified: false, Name: "Bob"})
+	expectStatus(t, resp, http.StatusForbidden)
+
+	if body := decodeLogin(t, resp); body.AccessToken != "" || body.RefreshToken != "" {
+		t.Error("tokens issued for an unverified email")
+	}
+	if u, _ := o.db.user(existing.ID); u.GoogleID.Valid {
+		t.Errorf("existing account linked to %q on an unverified email", u.GoogleID.String)
+	}
+	if n := o.db.userCount(); n != 1 {
+		t.Errorf("%d users, want only the existing one", n)
+	}
+	if n := len(o.db.refreshTokens()); n != 0 {
+		t.Errorf("%d refresh tokens stored, want 0", n)
+	}
+}
+
+func TestGoogleSignInLinksExistingAccountByVerifiedEmail(t *testing.T) {
+	o := newOAuthTest(t)
+	existing := o.db.addUser(db.User{
+		Name:          "Carol",
+		Email:         "carol@college.edu",
+		PasswordHash:  pgtype.Text{String: "hash", Valid: true},
+		EmailVerified: true,
+	})
+	o.db.addRefreshToken(existing.ID, "carol-session")
+
+	resp := o.signIn(t, oidcIdentity{Subject: "g-carol", Email: "carol@college.edu", EmailVerified: true, Name: "Carol G"})
+	expectStatus(t, resp, http.StatusOK)
+	body := decodeLogin(t, resp)
+
+	if got := userIDFromAccessToken(t, body.AccessToken); got != int64(existing.ID) {
+		t.Errorf("signed in as user %d, want existing user %d", got, existing.ID)
+	}
+	if body.Name != "Carol" {
+		t.Errorf("name = %q, want the account's own name %q", body.Name, "Carol")
+	}
+
+	u, _ := o.db.user(existing.ID)
+	if !u.GoogleID.Valid || u.GoogleID.String != "g-carol" {
+		t.Errorf("google_id = %+v, want g-carol", u.GoogleID)
+	}
+	if !u.EmailVerified {
+		t.Error("email not marked verified after linking")
+	}
+	if n := o.db.userCount(); n != 1 {
+		t.Errorf("%d users, want 1", n)
+	}
+	if n := o.db.walletCount(); n != 0 {
+		t.Errorf("%d wallets created when linking, want 0", n)
+	}
+	if !u.PasswordHash.Valid {
+		t.Error("password of a verified account was cleared")
+	}
+	if !hasRefreshToken(o.db, "carol-session") {
+		t.Error("existing session of a verified account was revoked")
+	}
+}
+
+// An unverified account may have been registered by someone who doesn't own
+// the address, so linking must leave them without a way in.
+func TestGoogleSignInLinkingUnverifiedAccountDropsCredentials(t *testing.T) {
+	o := newOAuthTest(t)
+	squatted := o.db.addUser(db.User{
+		Name:         "Not Grace",
+		Email:        "grace@college.edu",
+		PasswordHash: pgtype.Text{String: "attacker-hash", Valid: true},
+	})
+	o.db.addRefreshToken(squatted.ID, "attacker-session")
+
+	resp := o.signIn(t, oidcIdentity{Subject: "g-grace", Email: "grace@college.edu", EmailVerified: true, Name: "Grace"})
+	expectStatus(t, resp, http.StatusOK)
+	body := decodeLogin(t, resp)
+
+	if got := userIDFromAccessToken(t, body.AccessToken); got != int64(squatted.ID) {
+		t.Errorf("signed in as user %d, want %d", got, squatted.ID)
+	}
+
+	u, _ := o.db.user(squatted.ID)
+	if u.PasswordHash.Valid {
+		t.Error("password set before the email was verified still works after linking")
+	}
+	if !u.GoogleID.Valid || u.GoogleID.String != "g-grace" || !u.EmailVerified {
+		t.Errorf("google_id = %+v, verified = %v; want g-grace, true", u.GoogleID, u.EmailVerified)
+	}
+	if hasRefreshToken(o.db, "attacker-session") {
+		t.Error("session from before the link was not revoked")
+	}
+	if !hasRefreshToken(o.db, body.RefreshToken) {
+		t.Error("refresh token issued by the Google sign-in was not stored")
+	}
+}
+
+func TestGoogleSignInConflictKeepsUnverifiedAccountUntouched(t *testing.T) {
+	o := newOAuthTest(t)
+	existing := o.db.addUser(db.User{
+		Name:         "Heidi",
+		Email:        "heidi@college.edu",
+		PasswordHash: pgtype.Text{String: "hash", Valid: true},
+		GoogleID:     pgtype.Text{String: "g-heidi-old", Valid: true},
+	})
+	o.db.addRefreshToken(existing.ID, "heidi-session")
+
+	resp := o.signIn(t, oidcIdentity{Subject: "g-heidi-new", Email: "heidi@college.edu", EmailVerified: true, Name: "Heidi"})
+	expectStatus(t, resp, http.StatusConflict)
+
+	// The link failed, so clearing the password and sessions rolls back too.
+	u, _ := o.db.user(existing.ID)
+	if !u.PasswordHash.Valid {
+		t.Error("password cleared although the link was refused")
+	}
+	if !hasRefreshToken(o.db, "heidi-session") {
+		t.Error("sessions revoked although the link was refused")
+	}
+}
+
+func hasRefreshToken(store *memDB, plaintext string) bool {
+	want := token.GenerateTokenHash(plaintext)
+	for _, t := range store.refreshTokens() {
+		if hex.EncodeToString(t.Hash) == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGoogleSignInConflictWhenLinkedToAnotherGoogleID(t *testing.T) {
+	o := newOAuthTest(t)
+	existing := o.db.addUser(db.User{
+		Name:     "Dan",
+		Email:    "dan@college.edu",
+		GoogleID: pgtype.Text{String: "g-dan-old", Valid: true},
+	})
+
+	resp := o.signIn(t, oidcIdentity{Subject: "g-dan-new", Email: "dan@college.edu", EmailVerified: true, Name: "Dan"})
+	expectStatus(t, resp, http.StatusConflict)
+
+	if body := decodeLogin(t, resp); body.AccessToken != "" {
+		t.Error("tokens issued on a conflicting Google account")
+	}
+	if u, _ := o.db.user(existing.ID); u.GoogleID.String != "g-dan-old" {
+		t.Errorf("google_id = %q, want it left as g-dan-old", u.GoogleID.String)
+	}
+	if n := o.db.userCount(); n != 1 {
+		t.Errorf("%d users, want 1", n)
+	}
+}
+
+func TestGoogleFirstSignInCreatesUserAndWallet(t *testing.T) {
+	erin := oidcIdentity{Subject: "g-erin", Email: "erin@college.edu", EmailVerified: true, Name: "Erin"}
+
+	t.Run("created together", func(t *testing.T) {
+		o := newOAuthTest(t)
+
+		resp := o.signIn(t, erin)
+		expectStatus(t, resp, http.StatusOK)
+		body := decodeLogin(t, resp)
+
+		userID := int32(userIDFromAccessToken(t, body.AccessToken))
+		u, ok := o.db.user(userID)
+		if !ok {
+			t.Fatalf("user %d from the access token was not stored", userID)
+		}
+		if u.Email != erin.Email || u.Name != erin.Name {
+			t.Errorf("user = %q <%s>, want %q <%s>", u.Name, u.Email, erin.Name, erin.Email)
+		}
+		if u.GoogleID.String != erin.Subject || !u.EmailVerified {
+			t.Errorf("google_id = %q, verified = %v; want %q, true", u.GoogleID.String, u.EmailVerified, erin.Subject)
+		}
+		if u.PasswordHash.Valid {
+			t.Error("Google-only user was given a password")
+		}
+		if _, ok := o.db.wallet(userID); !ok {
+			t.Error("no wallet created for the new user")
+		}
+	})
+
+	t.Run("rolled back together", func(t *testing.T) {
+		o := newOAuthTest(t)
+		o.db.failWallet = true
+
+		resp := o.signIn(t, erin)
+		expectStatus(t, resp, http.StatusInternalServerError)
+
+		if n := o.db.userCount(); n != 0 {
+			t.Errorf("%d users left behind after the wallet failed, want 0", n)
+		}
+		if n := len(o.db.refreshTokens()); n != 0 {
+			t.Errorf("%d refresh tokens stored, want 0", n)
+		}
+	})
+}
+
+func TestGoogleSignInIssuesTokenPair(t *testing.T) {
+	o := newOAuthTest(t)
+	frank := oidcIdentity{Subject: "g-frank", Email: "frank@college.edu", EmailVerified: true, Name: "Frank"}
+
+	first := decodeLogin(t, o.signIn(t, frank))
+	userID := userIDFromAccessToken(t, first.AccessToken)
+
+	stored := o.db.refreshTokens()
+	if len(stored) != 1 {
+		t.Fatalf("%d refresh tokens stored, want 1", len(stored))
+	}
+	if got, want := hex.EncodeToString(stored[0].Hash), token.GenerateTokenHash(first.RefreshToken); got != want {
+		t.Error("stored refresh token hash does not match the issued refresh token")
+	}
+	if stored[0].UserID != userID || stored[0].Scope != token.ScopeRefresh {
+		t.Errorf("refresh token stored for user %d scope %q, want user %d scope %q", stored[0].UserID, stored[0].Scope, userID, token.ScopeRefresh)
+	}
+	if !stored[0].Expiry.Valid {
+		t.Error("refresh token stored without an expiry")
+	}
+
+	// Signing in again goes straight to the linked user with a new pair.
+	resp := o.signIn(t, frank)
+	expectStatus(t, resp, http.StatusOK)
+	second := decodeLogin(t, resp)
+
+	if got := userIDFromAccessToken(t, second.AccessToken); got != userID {
+		t.Errorf("second sign-in as user %d, want %d", got, userID)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("second sign-in reused the refresh token")
+	}
+	if n := o.db.userCount(); n != 1 {
+		t.Errorf("%d users, want 1", n)
+	}
+	if n := len(o.db.refreshTokens()); n != 2 {
+		t.Errorf("%d refresh tokens stored, want 2", n)
+	}
+}
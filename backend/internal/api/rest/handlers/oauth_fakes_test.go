@@ -0,0 +1,495 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"ecommerce/internal/cache"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"ecommerce/internal/token"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// oidcIdentity is the account a fake provider signs in as.
+type oidcIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+func (id oidcIdentity) claims() map[string]any {
+	return map[string]any{
+		"sub":            id.Subject,
+		"email":          id.Email,
+		"email_verified": id.EmailVerified,
+		"name":           id.Name,
+	}
+}
+
+// fakeOIDC is a local OpenID Connect provider serving discovery, JWKS, token
+// and userinfo endpoints. Codes are handed out with issueCode instead of
+// going through a login page.
+type fakeOIDC struct {
+	*httptest.Server
+	clientID     string
+	clientSecret string
+	callbackURL  string
+	key          *rsa.PrivateKey
+
+	mu        sync.Mutex
+	codes     map[string]oidcIdentity
+	access    map[string]oidcIdentity
+	exchanges int
+}
+
+const fakeOIDCKeyID = "test-key"
+
+func newFakeOIDC(t *testing.T, clientID, clientSecret, callbackURL string) *fakeOIDC {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate signing key: %v", err)
+	}
+
+	p := &fakeOIDC{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		callbackURL:  callbackURL,
+		key:          key,
+		codes:        make(map[string]oidcIdentity),
+		access:       make(map[string]oidcIdentity),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("POST /token", p.token)
+	mux.HandleFunc("GET /userinfo", p.userinfo)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+func (p *fakeOIDC) DiscoveryURL() string {
+	return p.URL + "/.well-known/openid-configuration"
+}
+
+// issueCode returns an authorization code that signs in as id, as if the
+// user had just approved the login on the provider's page.
+func (p *fakeOIDC) issueCode(id oidcIdentity) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	code := randomString()
+	p.codes[code] = id
+	return code
+}
+
+func (p *fakeOIDC) Exchanges() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.exchanges
+}
+
+func (p *fakeOIDC) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.URL,
+		"authorization_endpoint":                p.URL + "/authorize",
+		"token_endpoint":                        p.URL + "/token",
+		"userinfo_endpoint":                     p.URL + "/userinfo",
+		"jwks_uri":                              p.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *fakeOIDC) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": fakeOIDCKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *fakeOIDC) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != p.clientID || clientSecret != p.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != p.callbackURL {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	p.mu.Lock()
+	p.exchanges++
+	id, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	accessToken := randomString()
+	if ok {
+		p.access[accessToken] = id
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss": p.URL,
+		"aud": p.clientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range id.claims() {
+		claims[k] = v
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = fakeOIDCKeyID
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func (p *fakeOIDC) userinfo(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := bearerToken(r)
+
+	p.mu.Lock()
+	id, known := p.access[accessToken]
+	p.mu.Unlock()
+
+	if !ok || !known {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, http.StatusOK, id.claims())
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if len(h) <= len(prefix) || h[:len(prefix)] != prefix {
+		return "", false
+	}
+	return h[len(prefix):], true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// memDB backs the in-memory stores. Writes made through a transaction only
+// land when it commits, so tests can see what a rollback leaves behind.
+type memDB struct {
+	mu         sync.Mutex
+	nextID     int32
+	users      map[int32]db.User
+	wallets    map[int32]db.Wallet
+	tokens     []db.InsertTokenParams
+	failWallet bool
+}
+
+var _ data.TxBeginner = (*memDB)(nil)
+
+func newMemDB() *memDB {
+	return &memDB{
+		users:   make(map[int32]db.User),
+		wallets: make(map[int32]db.Wallet),
+	}
+}
+
+func (m *memDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &memTx{db: m}, nil
+}
+
+func (m *memDB) addUser(u db.User) db.User {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	u.ID = m.nextID
+	m.users[u.ID] = u
+	return u
+}
+
+func (m *memDB) user(id int32) (db.User, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[id]
+	return u, ok
+}
+
+func (m *memDB) userCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.users)
+}
+
+func (m *memDB) wallet(userID int32) (db.Wallet, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.wallets[userID]
+	return w, ok
+}
+
+func (m *memDB) walletCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.wallets)
+}
+
+func (m *memDB) addRefreshToken(userID int32, plaintext string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hash, _ := hex.DecodeString(token.GenerateTokenHash(plaintext))
+	m.tokens = append(m.tokens, db.InsertTokenParams{
+		Hash:   hash,
+		UserID: int64(userID),
+		Expiry: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+		Scope:  token.ScopeRefresh,
+	})
+}
+
+func (m *memDB) refreshTokens() []db.InsertTokenParams {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]db.InsertTokenParams(nil), m.tokens...)
+}
+
+// write applies op now, or queues it on tx until commit.
+func (m *memDB) write(tx *memTx, op func()) {
+	if tx != nil {
+		tx.ops = append(tx.ops, op)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op()
+}
+
+// memTx implements the parts of pgx.Tx the services use. Calling anything
+// else panics on the nil embedded interface.
+type memTx struct {
+	pgx.Tx
+	db   *memDB
+	ops  []func()
+	done bool
+}
+
+func (t *memTx) Commit(ctx context.Context) error {
+	if t.done {
+		return pgx.ErrTxClosed
+	}
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+	for _, op := range t.ops {
+		op()
+	}
+	t.done = true
+	return nil
+}
+
+func (t *memTx) Rollback(ctx context.Context) error {
+	if t.done {
+		return pgx.ErrTxClosed
+	}
+	t.ops = nil
+	t.done = true
+	return nil
+}
+
+func txOf(tx pgx.Tx) *memTx {
+	return tx.(*memTx)
+}
+
+type memUserStore struct {
+	data.UserStore
+	db *memDB
+	tx *memTx
+}
+
+func (s *memUserStore) WithTx(tx pgx.Tx) data.UserStore {
+	return &memUserStore{db: s.db, tx: txOf(tx)}
+}
+
+func (s *memUserStore) find(match func(db.User) bool) (db.User, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	for _, u := range s.db.users {
+		if match(u) {
+			return u, nil
+		}
+	}
+	return db.User{}, data.ErrRecordNotFound
+}
+
+func (s *memUserStore) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	return s.find(func(u db.User) bool { return u.Email == email })
+}
+
+func (s *memUserStore) GetUserByGoogleID(ctx context.Context, googleID string) (db.User, error) {
+	return s.find(func(u db.User) bool { return u.GoogleID.Valid && u.GoogleID.String == googleID })
+}
+
+func (s *memUserStore) LinkGoogleAccount(ctx context.Context, id int, googleID string) error {
+	u, ok := s.db.user(int32(id))
+	if !ok || u.GoogleID.Valid {
+		return data.ErrEditConflict
+	}
+	s.db.write(s.tx, func() {
+		u := s.db.users[int32(id)]
+		u.GoogleID = pgtype.Text{String: googleID, Valid: true}
+		u.EmailVerified = true
+		s.db.users[u.ID] = u
+	})
+	return nil
+}
+
+func (s *memUserStore) ClearUserPassword(ctx context.Context, id int) error {
+	s.db.write(s.tx, func() {
+		u := s.db.users[int32(id)]
+		u.PasswordHash = pgtype.Text{}
+		s.db.users[u.ID] = u
+	})
+	return nil
+}
+
+func (s *memUserStore) CreateGoogleUser(ctx context.Context, arg db.CreateGoogleUserParams) (db.User, error) {
+	if _, err := s.GetUserByEmail(ctx, arg.Email); err == nil {
+		return db.User{}, data.ErrDuplicateEmail
+	}
+
+	s.db.mu.Lock()
+	s.db.nextID++
+	u := db.User{
+		ID:            s.db.nextID,
+		Name:          arg.Name,
+		Email:         arg.Email,
+		GoogleID:      arg.GoogleID,
+		EmailVerified: true,
+		UserType:      "user",
+		RollNumber:    arg.RollNumber,
+		Batch:         arg.Batch,
+		Branch:        arg.Branch,
+	}
+	s.db.mu.Unlock()
+
+	s.db.write(s.tx, func() { s.db.users[u.ID] = u })
+	return u, nil
+}
+
+type memWalletStore struct {
+	data.WalletStore
+	db *memDB
+	tx *memTx
+}
+
+func (s *memWalletStore) WithTx(tx pgx.Tx) data.WalletStore {
+	return &memWalletStore{db: s.db, tx: txOf(tx)}
+}
+
+func (s *memWalletStore) CreateWallet(ctx context.Context, userID int32) (db.Wallet, error) {
+	if s.db.failWallet {
+		return db.Wallet{}, errors.New("wallet insert failed")
+	}
+	w := db.Wallet{UserID: userID}
+	s.db.write(s.tx, func() { s.db.wallets[userID] = w })
+	return w, nil
+}
+
+type memTokenStore struct {
+	data.TokenStore
+	db *memDB
+	tx *memTx
+}
+
+func (s *memTokenStore) WithTx(tx pgx.Tx) data.TokenStore {
+	return &memTokenStore{db: s.db, tx: txOf(tx)}
+}
+
+func (s *memTokenStore) InsertToken(ctx context.Context, arg db.InsertTokenParams) error {
+	s.db.write(s.tx, func() { s.db.tokens = append(s.db.tokens, arg) })
+	return nil
+}
+
+func (s *memTokenStore) DeleteAllForUserAndScope(ctx context.Context, scope string, userID int64) error {
+	s.db.write(s.tx, func() {
+		kept := s.db.tokens[:0]
+		for _, t := range s.db.tokens {
+			if t.UserID != userID || t.Scope != scope {
+				kept = append(kept, t)
+			}
+		}
+		s.db.tokens = kept
+	})
+	return nil
+}
+
+type noSignupRules struct {
+	data.SignupRuleStore
+}
+
+func (noSignupRules) MatchSignupEmailRules(ctx context.Context, email, domain string) ([]db.SignupEmailRule, error) {
+	return nil, nil
+}
+
+type nopCache struct {
+	cache.Cache
+}
+
+func (nopCache) DeleteEmailVerified(ctx context.Context, userID int64) error {
+	return nil
+}
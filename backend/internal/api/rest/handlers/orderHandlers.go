@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"ecommerce/internal/api/rest"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/middleware"
 	"ecommerce/internal/service"
 	"errors"
 	"log/slog"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -19,6 +22,7 @@ func OrderRoutes(
 	orderSvc *service.OrderService,
 	logger *slog.Logger,
 	protected fiber.Router,
+	verification *middleware.EmailVerificationPolicy,
 ) {
 	h := &OrderHandler{
 		Svc:    orderSvc,
@@ -27,10 +31,11 @@ func OrderRoutes(
 
 	orderGroup := protected.Group("/orders")
 
-	orderGroup.Post("/", h.CreateOrderFromCartHandler)
-	// TODO: Add GET routes for order history
-	// orderGroup.Get("/", h.GetMyOrdersHandler)
-	// orderGroup.Get("/:id", h.GetOrderDetailsHandler)
+	orderGroup.Post("/", verification.Require(middleware.ActionCheckout), h.CreateOrderFromCartHandler)
+	orderGroup.Get("/", h.GetMyOrdersHandler)
+	orderGroup.Get("/:id", h.GetOrderDetailsHandler)
+	orderGroup.Post("/sub-orders/:id/confirm", h.ConfirmSubOrderHandler)
+	orderGroup.Post("/sub-orders/:id/cancel", h.CancelSubOrderHandler)
 }
 
 func (h *OrderHandler) CreateOrderFromCartHandler(c *fiber.Ctx) error {
@@ -39,12 +44,27 @@ func (h *OrderHandler) CreateOrderFromCartHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
 	}
 
-	order, err := h.Svc.CreateOrderFromCart(c.Context(), int64(userID))
+	var req struct {
+		CouponCode string `json:"coupon_code"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+	}
+
+	order, err := h.Svc.CreateOrderFromCart(c.Context(), int64(userID), strings.TrimSpace(req.CouponCode))
 	if err != nil {
+		if status, code := couponErrorStatus(err); status != 0 {
+			return c.Status(status).JSON(dto.ErrorResponse{Code: code, Message: err.Error()})
+		}
 		h.Logger.Error("Failed to create order from cart", "error", err)
 		if errors.Is(err, service.ErrCartEmpty) {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cart is empty"})
 		}
+		if errors.Is(err, service.ErrCartChanged) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "your cart has changed, please review it before checking out"})
+		}
 		if errors.Is(err, service.ErrInsufficientFunds) {
 			return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{"error": "insufficient funds"})
 		}
@@ -56,3 +76,93 @@ func (h *OrderHandler) CreateOrderFromCartHandler(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusCreated).JSON(order)
 }
+
+func (h *OrderHandler) GetMyOrdersHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	orders, err := h.Svc.GetOrdersForBuyer(c.Context(), int64(userID))
+	if err != nil {
+		h.Logger.Error("Failed to get orders", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve orders"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(orders)
+}
+
+func (h *OrderHandler) GetOrderDetailsHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	orderID, err := c.ParamsInt("id")
+	if err != nil || orderID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid order ID"})
+	}
+
+	details, err := h.Svc.GetOrderDetails(c.Context(), int64(userID), int64(orderID))
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		h.Logger.Error("Failed to get order details", "order_id", orderID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve order"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(details)
+}
+
+func (h *OrderHandler) ConfirmSubOrderHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	subOrderID, err := c.ParamsInt("id")
+	if err != nil || subOrderID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid sub-order ID"})
+	}
+
+	subOrder, err := h.Svc.ConfirmSubOrder(c.Context(), int64(userID), int64(subOrderID))
+	if err != nil {
+		return h.subOrderError(c, err, "could not confirm sub-order")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(subOrder)
+}
+
+func (h *OrderHandler) CancelSubOrderHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	subOrderID, err := c.ParamsInt("id")
+	if err != nil || subOrderID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid sub-order ID"})
+	}
+
+	subOrder, err := h.Svc.CancelSubOrder(c.Context(), int64(userID), int64(subOrderID))
+	if err != nil {
+		return h.subOrderError(c, err, "could not cancel sub-order")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(subOrder)
+}
+
+func (h *OrderHandler) subOrderError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrNotOrderParticipant):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrSubOrderNotPending):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	h.Logger.Error("Sub-order operation failed", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+}
@@ -3,19 +3,25 @@ package handlers
 import (
 	"ecommerce/internal/api/rest"
 	"ecommerce/internal/data"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/middleware"
 	"ecommerce/internal/service"
+	"ecommerce/internal/validator"
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type ProductHandler struct {
-	Svc         *service.ProductService
-	UserService *service.UserService
-	Pool        *pgxpool.Pool
+	Svc          *service.ProductService
+	UserService  *service.UserService
+	CategorySvc  *service.CategoryService
+	Pool         *pgxpool.Pool
+	Verification *middleware.EmailVerificationPolicy
 }
 
 func ProductRoutes(
@@ -23,17 +29,23 @@ func ProductRoutes(
 	productSvc *service.ProductService,
 	dbConn *pgxpool.Pool,
 	userService *service.UserService,
+	categorySvc *service.CategoryService,
 	protected fiber.Router,
+	verification *middleware.EmailVerificationPolicy,
 ) {
 	h := ProductHandler{
-		Svc:         productSvc,
-		UserService: userService,
-		Pool:        dbConn,
+		Svc:          productSvc,
+		UserService:  userService,
+		CategorySvc:  categorySvc,
+		Pool:         dbConn,
+		Verification: verification,
 	}
 
 	protected.Get("/products/mine", h.GetMyProductsHandler)
-	rh.App.Get("/products/:id", h.GetProductByIDHandler)
-	protected.Post("/products", h.CreateProductHandler)
+	rh.App.Get("/products/:id", middleware.OptionalAuthMiddleware(), h.GetProductByIDHandler)
+	protected.Post("/products", verification.Require(middleware.ActionSell), h.CreateProductHandler)
+	protected.Patch("/products/:id", h.UpdateProductHandler)
+	protected.Delete("/products/:id", h.DelistProductHandler)
 
 }
 
@@ -59,7 +71,9 @@ func (h *ProductHandler) GetProductByIDHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
 	}
 
-	productDetails, err := h.Svc.GetProductDetails(ctx, int64(id))
+	viewerID, _ := getCurrentUserID(c)
+
+	productDetails, err := h.Svc.GetProductDetails(ctx, int64(id), int64(viewerID))
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "product not found"})
@@ -79,16 +93,10 @@ func (h *ProductHandler) CreateProductHandler(c *fiber.Ctx) error {
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
 	}
 
-	sellerInfo, err := h.UserService.Store.GetUserByID(ctx, int(sellerID))
-	if err != nil {
-		h.Svc.Logger.Warn("Error fetching user data", "error", err)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
-	}
-
 	name := c.FormValue("name")
 	description := c.FormValue("description")
 	category := c.FormValue("category")
-	condition := c.FormValue("condition")
+	condition := service.NormalizeCondition(c.FormValue("condition"))
 
 	price, err := strconv.Atoi(c.FormValue("price"))
 	if err != nil {
@@ -104,6 +112,26 @@ func (h *ProductHandler) CreateProductHandler(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid data: name, price, and stock are required"})
 	}
 
+	v := validator.New()
+	v.Check(validator.PermittedValue(condition, service.ProductConditions...), "condition", "must be one of: "+strings.Join(service.ProductConditions, ", "))
+
+	productCategory, err := h.CategorySvc.LookupCategory(ctx, category)
+	if err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			h.Svc.Logger.Error("Failed to look up category", "category", category, "error", err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+		}
+		v.AddError("category", "unknown category")
+	}
+
+	if !v.Valid() {
+		return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
 	form, err := c.MultipartForm()
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid form data"})
@@ -117,20 +145,39 @@ func (h *ProductHandler) CreateProductHandler(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "maximum 10 images allowed"})
 	}
 
+	if err := h.Svc.ValidateImageFiles(files); err != nil {
+		var validationError *validator.ValidationError
+		if errors.As(err, &validationError) {
+			return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+				Code:    "validation_error",
+				Message: "invalid images",
+				Fields:  validationError.Errors,
+			})
+		}
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid images"})
+	}
+
 	productParams := service.CreateProductParams{
 		SellerID:    int64(sellerID),
-		SellerName:  sellerInfo.Name,
-		SellerPhone: sellerInfo.PhoneNumber.String,
 		Condition:   condition,
 		Name:        name,
 		Description: description,
-		Category:    category,
+		Category:    productCategory.Slug,
+		CategoryID:  productCategory.ID,
 		Price:       int32(price),
 		Stock:       int32(stock),
 	}
 
 	newProduct, err := h.Svc.CreateProductWithFiles(ctx, productParams, files, 5)
 	if err != nil {
+		var validationError *validator.ValidationError
+		if errors.As(err, &validationError) {
+			return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+				Code:    "validation_error",
+				Message: "invalid details",
+				Fields:  validationError.Errors,
+			})
+		}
 		h.Svc.Logger.Warn("Product creation failed", "error", err)
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "failed to create product: " + err.Error()})
 	}
@@ -159,3 +206,93 @@ func (h *ProductHandler) GetMyProductsHandler(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(products)
 }
+
+func (h *ProductHandler) UpdateProductHandler(c *fiber.Ctx) error {
+	sellerID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	var req struct {
+		Price    *int32 `json:"price"`
+		Stock    *int32 `json:"stock"`
+		IsActive *bool  `json:"is_active"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	v := validator.New()
+	v.Check(req.Price != nil || req.Stock != nil || req.IsActive != nil, "body", "nothing to update")
+	v.Check(req.Price == nil || *req.Price > 0, "price", "must be greater than zero")
+	v.Check(req.Stock == nil || *req.Stock >= 0, "stock", "must not be negative")
+	if !v.Valid() {
+		return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	// Relisting puts the item back on sale, so it needs the same verification
+	// as creating a listing. Price and stock edits stay open.
+	if req.IsActive != nil && *req.IsActive {
+		allowed, err := h.Verification.Allows(c.Context(), int64(sellerID), middleware.ActionSell)
+		if err != nil {
+			h.Svc.Logger.Error("Failed to check email verification", "user_id", sellerID, "error", err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+		}
+		if !allowed {
+			return middleware.UnverifiedResponse(c, middleware.ActionSell)
+		}
+	}
+
+	product, err := h.Svc.UpdateProduct(c.Context(), int64(sellerID), int64(id), service.UpdateProductParams{
+		Price:    req.Price,
+		Stock:    req.Stock,
+		IsActive: req.IsActive,
+	})
+	if err != nil {
+		return h.updateProductError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(product)
+}
+
+func (h *ProductHandler) DelistProductHandler(c *fiber.Ctx) error {
+	sellerID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	inactive := false
+	_, err = h.Svc.UpdateProduct(c.Context(), int64(sellerID), int64(id), service.UpdateProductParams{IsActive: &inactive})
+	if err != nil {
+		return h.updateProductError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "product delisted"})
+}
+
+func (h *ProductHandler) updateProductError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "product not found"})
+	case errors.Is(err, service.ErrNotProductOwner):
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "you can only edit your own listings"})
+	case errors.Is(err, service.ErrListingUnderModeration):
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.Svc.Logger.Error("Failed to update product", "error", err)
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "could not update product"})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/imaging"
+	"ecommerce/internal/service"
+	"ecommerce/internal/validator"
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProfileHandler struct {
+	Svc    *service.ProfileService
+	Logger *slog.Logger
+}
+
+func ProfileRoutes(
+	rh *rest.RestHandler,
+	profileSvc *service.ProfileService,
+	logger *slog.Logger,
+	protected fiber.Router,
+) {
+	h := &ProfileHandler{
+		Svc:    profileSvc,
+		Logger: logger,
+	}
+
+	protected.Get("/profile", h.GetProfileHandler)
+	protected.Patch("/profile", h.UpdateProfileHandler)
+	protected.Put("/profile/avatar", h.UpdateAvatarHandler)
+}
+
+func (h *ProfileHandler) GetProfileHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	profile, err := h.Svc.GetProfile(c.Context(), int64(userID))
+	if err != nil {
+		return h.profileError(c, err, "could not retrieve profile")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(profile)
+}
+
+func (h *ProfileHandler) UpdateProfileHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req struct {
+		Name    *string `json:"name"`
+		UpiID   *string `json:"upi_id"`
+		Phone   *string `json:"phone"`
+		Hostel  *string `json:"hostel"`
+		Version *int32  `json:"version"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	v := validator.New()
+	v.Check(req.Version != nil, "version", "must be provided")
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	profile, err := h.Svc.UpdateProfile(c.Context(), int64(userID), service.ProfileUpdate{
+		Name:    req.Name,
+		UpiID:   req.UpiID,
+		Phone:   req.Phone,
+		Hostel:  req.Hostel,
+		Version: *req.Version,
+	})
+	if err != nil {
+		return h.profileError(c, err, "could not update profile")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(profile)
+}
+
+func (h *ProfileHandler) UpdateAvatarHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	fh, err := c.FormFile("avatar")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "avatar file is required"})
+	}
+	if fh.Size > imaging.MaxUploadBytes {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": imaging.ErrTooLarge.Error()})
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "could not read file"})
+	}
+	defer f.Close()
+
+	profile, err := h.Svc.UpdateAvatar(c.Context(), int64(userID), f, fh.Filename)
+	if err != nil {
+		return h.profileError(c, err, "could not update avatar")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(profile)
+}
+
+func (h *ProfileHandler) GetSellerProfileHandler(c *fiber.Ctx) error {
+	sellerID, err := c.ParamsInt("id")
+	if err != nil || sellerID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid seller ID"})
+	}
+
+	profile, err := h.Svc.GetSellerProfile(c.Context(), int64(sellerID))
+	if err != nil {
+		return h.profileError(c, err, "could not retrieve seller")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(profile)
+}
+
+func (h *ProfileHandler) profileError(c *fiber.Ctx, err error, fallback string) error {
+	var validationError *validator.ValidationError
+	switch {
+	case errors.As(err, &validationError):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  validationError.Errors,
+		})
+	case errors.Is(err, service.ErrProfileNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrProfileEditConflict),
+		errors.Is(err, service.ErrUpiIDTaken),
+		errors.Is(err, service.ErrPhoneTaken):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.Logger.Error("Profile operation failed", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/service"
+	"ecommerce/internal/validator"
+	"errors"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReviewHandler struct {
+	Svc    *service.ReviewService
+	Logger *slog.Logger
+}
+
+func ReviewRoutes(
+	rh *rest.RestHandler,
+	reviewSvc *service.ReviewService,
+	logger *slog.Logger,
+	protected fiber.Router,
+	adminOnly fiber.Handler,
+) {
+	h := &ReviewHandler{
+		Svc:    reviewSvc,
+		Logger: logger,
+	}
+
+	reviewGroup := protected.Group("/reviews")
+	reviewGroup.Post("/", h.CreateReviewHandler)
+	reviewGroup.Get("/eligible", h.ListReviewableItemsHandler)
+	reviewGroup.Post("/:id/reply", h.ReplyHandler)
+	reviewGroup.Post("/:id/flag", h.FlagReviewHandler)
+
+	adminGroup := protected.Group("/admin/reviews", adminOnly)
+	adminGroup.Get("/flagged", h.ListFlaggedReviewsHandler)
+	adminGroup.Patch("/:id", h.SetReviewHiddenHandler)
+}
+
+func (h *ReviewHandler) ListProductReviewsHandler(c *fiber.Ctx) error {
+	productID, err := c.ParamsInt("id")
+	if err != nil || productID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	reviews, err := h.Svc.ListProductReviews(c.Context(), int64(productID))
+	if err != nil {
+		h.Logger.Error("Failed to list product reviews", "product_id", productID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve reviews"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(reviews)
+}
+
+func (h *ReviewHandler) ListSellerReviewsHandler(c *fiber.Ctx) error {
+	sellerID, err := c.ParamsInt("id")
+	if err != nil || sellerID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid seller ID"})
+	}
+
+	reviews, err := h.Svc.ListSellerReviews(c.Context(), int64(sellerID))
+	if err != nil {
+		h.Logger.Error("Failed to list seller reviews", "seller_id", sellerID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve reviews"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(reviews)
+}
+
+func (h *ReviewHandler) CreateReviewHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req struct {
+		OrderItemID int64  `json:"order_item_id"`
+		Rating      int32  `json:"rating"`
+		Body        string `json:"body"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	body := strings.TrimSpace(req.Body)
+
+	v := validator.New()
+	v.Check(req.OrderItemID > 0, "order_item_id", "must be provided")
+	v.Check(req.Rating >= 1 && req.Rating <= 5, "rating", "must be between 1 and 5")
+	v.Check(utf8.RuneCountInString(body) <= 2000, "body", "must not be more than 2000 characters")
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	review, err := h.Svc.CreateReview(c.Context(), int64(userID), req.OrderItemID, req.Rating, body)
+	if err != nil {
+		return h.reviewError(c, err, "could not create review")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(review)
+}
+
+func (h *ReviewHandler) ListReviewableItemsHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	items, err := h.Svc.ListReviewableItems(c.Context(), int64(userID))
+	if err != nil {
+		h.Logger.Error("Failed to list reviewable items", "user_id", userID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve reviewable items"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(items)
+}
+
+func (h *ReviewHandler) ReplyHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	reviewID, err := c.ParamsInt("id")
+	if err != nil || reviewID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid review ID"})
+	}
+
+	var req struct {
+		Reply string `json:"reply"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	reply := strings.TrimSpace(req.Reply)
+
+	v := validator.New()
+	v.Check(reply != "", "reply", "must be provided")
+	v.Check(utf8.RuneCountInString(reply) <= 2000, "reply", "must not be more than 2000 characters")
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	review, err := h.Svc.Reply(c.Context(), int64(userID), int64(reviewID), reply)
+	if err != nil {
+		return h.reviewError(c, err, "could not save reply")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(review)
+}
+
+func (h *ReviewHandler) FlagReviewHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	reviewID, err := c.ParamsInt("id")
+	if err != nil || reviewID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid review ID"})
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	v := validator.New()
+	v.Check(validator.PermittedValue(req.Reason, service.ReviewFlagReasons...), "reason", "must be one of: "+strings.Join(service.ReviewFlagReasons, ", "))
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	if err := h.Svc.Flag(c.Context(), int64(userID), int64(reviewID), req.Reason); err != nil {
+		return h.reviewError(c, err, "could not flag review")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ReviewHandler) ListFlaggedReviewsHandler(c *fiber.Ctx) error {
+	reviews, err := h.Svc.ListFlagged(c.Context())
+	if err != nil {
+		h.Logger.Error("Failed to list flagged reviews", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve flagged reviews"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(reviews)
+}
+
+func (h *ReviewHandler) SetReviewHiddenHandler(c *fiber.Ctx) error {
+	reviewID, err := c.ParamsInt("id")
+	if err != nil || reviewID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid review ID"})
+	}
+
+	var req struct {
+		Hidden *bool `json:"hidden"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Hidden == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "hidden is required"})
+	}
+
+	if err := h.Svc.SetHidden(c.Context(), int64(reviewID), *req.Hidden); err != nil {
+		return h.reviewError(c, err, "could not update review")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *ReviewHandler) reviewError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrReviewNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrReviewNotAllowed),
+		errors.Is(err, service.ErrCannotFlagOwnReview):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyReviewed),
+		errors.Is(err, service.ErrAlreadyFlagged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.Logger.Error("Review operation failed", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+}
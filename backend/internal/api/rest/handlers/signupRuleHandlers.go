@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/dto"
+	"ecommerce/internal/service"
+	"ecommerce/internal/validator"
+	"errors"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SignupRuleHandler struct {
+	Svc    *service.SignupPolicyService
+	Logger *slog.Logger
+}
+
+func SignupRuleRoutes(
+	rh *rest.RestHandler,
+	signupPolicySvc *service.SignupPolicyService,
+	logger *slog.Logger,
+	protected fiber.Router,
+	adminOnly fiber.Handler,
+) {
+	h := &SignupRuleHandler{
+		Svc:    signupPolicySvc,
+		Logger: logger,
+	}
+
+	adminGroup := protected.Group("/admin/signup-rules", adminOnly)
+	adminGroup.Get("/", h.ListRulesHandler)
+	adminGroup.Post("/", h.AddRuleHandler)
+	adminGroup.Delete("/:id", h.DeleteRuleHandler)
+}
+
+func (h *SignupRuleHandler) ListRulesHandler(c *fiber.Ctx) error {
+	rules, err := h.Svc.ListRules(c.Context())
+	if err != nil {
+		h.Logger.Error("Failed to list signup rules", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve signup rules"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(rules)
+}
+
+func (h *SignupRuleHandler) AddRuleHandler(c *fiber.Ctx) error {
+	adminID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req struct {
+		Pattern string `json:"pattern"`
+		Rule    string `json:"rule"`
+		Note    string `json:"note"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	pattern := strings.TrimSpace(req.Pattern)
+	note := strings.TrimSpace(req.Note)
+
+	v := validator.New()
+	v.Check(pattern != "", "pattern", "must be provided")
+	v.Check(utf8.RuneCountInString(pattern) <= 254, "pattern", "must not be more than 254 characters")
+	v.Check(validator.PermittedValue(req.Rule, service.SignupRuleKinds...), "rule", "must be one of: "+strings.Join(service.SignupRuleKinds, ", "))
+	v.Check(utf8.RuneCountInString(note) <= 500, "note", "must not be more than 500 characters")
+	if !v.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	rule, err := h.Svc.AddRule(c.Context(), int64(adminID), pattern, req.Rule, note)
+	if err != nil {
+		return h.signupRuleError(c, err, "could not add signup rule")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+func (h *SignupRuleHandler) DeleteRuleHandler(c *fiber.Ctx) error {
+	ruleID, err := c.ParamsInt("id")
+	if err != nil || ruleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid signup rule ID"})
+	}
+
+	if err := h.Svc.DeleteRule(c.Context(), int64(ruleID)); err != nil {
+		return h.signupRuleError(c, err, "could not delete signup rule")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *SignupRuleHandler) signupRuleError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrSignupRuleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidSignupRulePattern):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrSignupRuleExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.Logger.Error("Signup rule operation failed", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+}
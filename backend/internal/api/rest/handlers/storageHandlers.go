@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StorageHandler struct {
+	Svc *service.LocalStorageService
+}
+
+func StorageRoutes(rh *rest.RestHandler, localStorage *service.LocalStorageService) {
+	h := StorageHandler{
+		Svc: localStorage,
+	}
+
+	rh.App.Use(service.LocalStorageRoute, h.VerifySignedURL)
+	rh.App.Static(service.LocalStorageRoute, localStorage.Dir)
+}
+
+func (h *StorageHandler) VerifySignedURL(c *fiber.Ctx) error {
+	key := strings.TrimPrefix(c.Path(), service.LocalStorageRoute+"/")
+
+	if !h.Svc.VerifySignature(key, c.Query("expires"), c.Query("sig")) {
+		h.Svc.Logger.Warn("Rejected unsigned or expired storage URL", "key", key)
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": "invalid or expired link"})
+	}
+
+	return c.Next()
+}
@@ -8,12 +8,15 @@ import (
 	"ecommerce/internal/validator"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type UserHandler struct {
-	Svc *service.UserService
+	Svc   *service.UserService
+	Carts *service.CartService
 }
 
 func UserRoutes(rh *rest.RestHandler, userService *service.UserService, protected fiber.Router) {
@@ -22,10 +25,11 @@ func UserRoutes(rh *rest.RestHandler, userService *service.UserService, protecte
 		Svc: userService,
 	}
 
-	protected.Post("/profile", h.CreateProfile)
-	protected.Get("/profile", h.GetProfile)
 	protected.Post("/order", h.CreateOrder)
 	protected.Get("/order/:id", h.GetOrder)
+	protected.Post("/account/password", h.ChangePassword)
+	protected.Post("/account/email", h.RequestEmailChange)
+	protected.Post("/account/email/confirm", h.ConfirmEmailChange)
 
 }
 
@@ -65,6 +69,8 @@ func (h *UserHandler) LoginUserHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	mergeGuestCart(c, h.Carts, int64(u.ID))
+
 	return c.Status(http.StatusOK).JSON(fiber.Map{
 		"name":          u.Name,
 		"phone":         u.Phone,
@@ -104,8 +110,218 @@ func (h *UserHandler) RegisterUserHandler(c *fiber.Ctx) error {
 	return c.Status(http.StatusCreated).JSON(&user, "application/json")
 }
 
+// GetVerificationCode sends a fresh verification code to ?email=. It answers
+// the same way whether or not the address belongs to an unverified account,
+// and whether or not a code was sent within the resend cooldown.
 func (h *UserHandler) GetVerificationCode(c *fiber.Ctx) error {
-	return nil
+	email := strings.TrimSpace(c.Query("email"))
+
+	v := validator.New()
+	v.Check(email != "", "email", "must be provided")
+	v.Check(v.Matches(email, validator.EmailRX), "email", "must be a valid email address")
+	if !v.Valid() {
+		return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	err := h.Svc.ResendVerification(c.Context(), email)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "An internal server error has occurred",
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{
+		"message": "if the account exists and is unverified, a new code has been sent",
+	})
+}
+
+func (h *UserHandler) ForgotPassword(c *fiber.Ctx) error {
+	var input struct {
+		Email string `json:"email"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	email := strings.TrimSpace(input.Email)
+
+	v := validator.New()
+	v.Check(email != "", "email", "must be provided")
+	v.Check(v.Matches(email, validator.EmailRX), "email", "must be a valid email address")
+	if !v.Valid() {
+		return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	if err := h.Svc.ForgotPassword(c.Context(), email); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "An internal server error has occurred",
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{
+		"message": "if an account exists for this email, reset instructions have been sent",
+	})
+}
+
+func (h *UserHandler) ResetPassword(c *fiber.Ctx) error {
+	var input struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if input.Token == "" {
+		return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  map[string]string{"token": "must be provided"},
+		})
+	}
+
+	err := h.Svc.ResetPassword(c.Context(), input.Token, input.Password)
+	if err != nil {
+		var validationError *validator.ValidationError
+		switch {
+		case errors.As(err, &validationError):
+			return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+				Code:    "validation_error",
+				Message: "invalid details",
+				Fields:  validationError.Errors,
+			})
+		case errors.Is(err, service.ErrInvalidResetToken):
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "An internal server error has occurred",
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "password updated, please log in again",
+	})
+}
+
+func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var input dto.UserPasswordUpdate
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	accessToken, refreshToken, err := h.Svc.ChangePassword(c.Context(), int64(userID), input)
+	if err != nil {
+		return h.accountError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	})
+}
+
+func (h *UserHandler) RequestEmailChange(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var input dto.UserEmailUpdate
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err := h.Svc.UpdateEmail(c.Context(), int64(userID), input); err != nil {
+		if errors.Is(err, service.ErrResendCooldown) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(service.VerificationResendCooldown.Seconds())))
+		}
+		return h.accountError(c, err)
+	}
+
+	return c.Status(http.StatusAccepted).JSON(fiber.Map{
+		"message": "a confirmation code has been sent to the new address",
+	})
+}
+
+func (h *UserHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var input struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	v := validator.New()
+	v.Check(len(input.Token) == 6, "token", "must be 6 digits")
+	if !v.Valid() {
+		return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
+	if err := h.Svc.ConfirmEmailChange(c.Context(), int64(userID), input.Token); err != nil {
+		return h.accountError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "email updated",
+	})
+}
+
+func (h *UserHandler) accountError(c *fiber.Ctx, err error) error {
+	var validationError *validator.ValidationError
+	switch {
+	case errors.As(err, &validationError):
+		return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  validationError.Errors,
+		})
+	case errors.Is(err, service.ErrInvalidVerificationCode),
+		errors.Is(err, service.ErrCurrentPasswordMismatch),
+		errors.Is(err, service.ErrNoPasswordSet):
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrEmailTaken):
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrResendCooldown),
+		errors.Is(err, service.ErrVerificationLocked):
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.Svc.Logger.Error("Account operation failed", "error", err)
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		"error": "An internal server error has occurred",
+	})
 }
 
 func (h *UserHandler) CreateOrder(c *fiber.Ctx) error {
@@ -129,10 +345,32 @@ func (h *UserHandler) Verify(c *fiber.Ctx) error {
 			"error": "invalid request body",
 		})
 	}
+
+	v := validator.New()
+	v.Check(input.ID > 0, "id", "must be provided")
+	v.Check(len(input.Token) == 6, "token", "must be 6 digits")
+	if !v.Valid() {
+		return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{
+			Code:    "validation_error",
+			Message: "invalid details",
+			Fields:  v.Errors,
+		})
+	}
+
 	err = h.Svc.VerifyUser(c.Context(), input)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid token",
+		switch {
+		case errors.Is(err, service.ErrVerificationLocked):
+			return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, service.ErrInvalidVerificationCode):
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid token",
+			})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "An internal server error has occurred",
 		})
 	}
 	return c.Status(http.StatusCreated).JSON(fiber.Map{
@@ -140,10 +378,6 @@ func (h *UserHandler) Verify(c *fiber.Ctx) error {
 	})
 }
 
-func (h *UserHandler) CreateProfile(c *fiber.Ctx) error {
-	return nil
-}
-
 func (h *UserHandler) CreateCart(c *fiber.Ctx) error {
 	return nil
 }
@@ -154,7 +388,3 @@ func (h *UserHandler) GetCart(c *fiber.Ctx) error {
 		"message": "get cart",
 	})
 }
-
-func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
-	return nil
-}
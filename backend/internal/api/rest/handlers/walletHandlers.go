@@ -4,6 +4,7 @@ import (
 	"ecommerce/internal/api/rest"
 	"ecommerce/internal/data"
 	db_gen "ecommerce/internal/data/gen"
+	"ecommerce/internal/middleware"
 	"ecommerce/internal/service"
 	"errors"
 	"net/http"
@@ -26,14 +27,14 @@ type WalletHandler struct {
 	Pool *pgxpool.Pool
 }
 
-func WalletRoutes(rh *rest.RestHandler, walletService *service.WalletService, walletPaymentService *service.WalletPaymentService, dbConn *pgxpool.Pool, protected fiber.Router) {
+func WalletRoutes(rh *rest.RestHandler, walletService *service.WalletService, walletPaymentService *service.WalletPaymentService, dbConn *pgxpool.Pool, protected fiber.Router, verification *middleware.EmailVerificationPolicy) {
 	h := WalletHandler{
 		Svc:  walletService,
 		Pool: dbConn,
 	}
 
 	protected.Get("/wallet/balance", h.GetBalanceHandler)
-	protected.Post("/wallet/transfer", h.TransferHandler)
+	protected.Post("/wallet/transfer", verification.Require(middleware.ActionTransfer), h.TransferHandler)
 	protected.Post("/wallet/credit", h.CreditHandler)
 	protected.Post("/wallet/debit", h.DebitHandler)
 
@@ -176,5 +177,6 @@ func (h *WalletHandler) TransferHandler(c *fiber.Ctx) error {
 	}
 
 	h.Svc.Logger.Info("Atomic Transfer successful, transaction committed", "sender_id", senderID, "recipient_id", input.RecipientUserID)
+	h.Svc.TransferCompleted(ctx, int32(senderID), input.RecipientUserID, input.Amount)
 	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "transfer successful"})
 }
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"ecommerce/internal/api/rest"
+	"ecommerce/internal/data"
+	"ecommerce/internal/service"
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WishlistHandler struct {
+	Svc    *service.WishlistService
+	Logger *slog.Logger
+}
+
+func WishlistRoutes(
+	rh *rest.RestHandler,
+	wishlistSvc *service.WishlistService,
+	logger *slog.Logger,
+	protected fiber.Router,
+) {
+	h := &WishlistHandler{
+		Svc:    wishlistSvc,
+		Logger: logger,
+	}
+
+	wishlistGroup := protected.Group("/wishlist")
+
+	wishlistGroup.Get("/", h.GetWishlistHandler)
+	wishlistGroup.Post("/:product_id", h.AddToWishlistHandler)
+	wishlistGroup.Delete("/:product_id", h.RemoveFromWishlistHandler)
+	wishlistGroup.Post("/:product_id/move-to-cart", h.MoveToCartHandler)
+}
+
+func (h *WishlistHandler) GetWishlistHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	items, err := h.Svc.GetWishlist(c.Context(), int64(userID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not retrieve wishlist"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"items": items})
+}
+
+func (h *WishlistHandler) AddToWishlistHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	productID, err := c.ParamsInt("product_id")
+	if err != nil || productID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	err = h.Svc.AddToWishlist(c.Context(), int64(userID), int64(productID))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "product not found"})
+		}
+		if errors.Is(err, service.ErrCannotBuyOwnItem) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "you cannot wishlist your own item"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not add item to wishlist"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "item added to wishlist"})
+}
+
+func (h *WishlistHandler) RemoveFromWishlistHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	productID, err := c.ParamsInt("product_id")
+	if err != nil || productID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	err = h.Svc.RemoveFromWishlist(c.Context(), int64(userID), int64(productID))
+	if err != nil {
+		if errors.Is(err, service.ErrWishlistItemNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		h.Logger.Error("Failed to remove wishlist item", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not remove item from wishlist"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "item removed from wishlist"})
+}
+
+func (h *WishlistHandler) MoveToCartHandler(c *fiber.Ctx) error {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	productID, err := c.ParamsInt("product_id")
+	if err != nil || productID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid product ID"})
+	}
+
+	var req struct {
+		Quantity int `json:"quantity"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	err = h.Svc.MoveToCart(c.Context(), int64(userID), int64(productID), req.Quantity)
+	if err != nil {
+		if errors.Is(err, service.ErrWishlistItemNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return cartError(c, h.Svc.Carts, err, "could not move item to cart")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "item moved to cart"})
+}
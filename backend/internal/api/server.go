@@ -3,7 +3,9 @@ package api
 import (
 	"ecommerce/internal/api/rest"
 	"ecommerce/internal/api/rest/handlers"
+	"ecommerce/internal/auth"
 	"ecommerce/internal/config"
+	"ecommerce/internal/imaging"
 	"ecommerce/internal/middleware"
 	"ecommerce/internal/service"
 	"log/slog"
@@ -23,15 +25,30 @@ func SetupServer(
 	productService *service.ProductService,
 	cartService *service.CartService,
 	orderService *service.OrderService,
+	categoryService *service.CategoryService,
+	wishlistService *service.WishlistService,
+	alertService *service.AlertService,
+	couponService *service.CouponService,
+	commissionService *service.CommissionService,
+	handoverService *service.HandoverService,
+	messageService *service.MessageService,
+	reviewService *service.ReviewService,
+	moderationService *service.ModerationService,
+	signupPolicyService *service.SignupPolicyService,
+	profileService *service.ProfileService,
+	eventService *service.EventService,
+	cloudService service.CloudService,
 	dbPool *pgxpool.Pool,
 ) {
 
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		BodyLimit: 10*imaging.MaxUploadBytes + 1<<20,
+	})
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:3000/",
-		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, Last-Event-ID",
 		ExposeHeaders:    "Authorization, Content-Length",
 		AllowCredentials: true,
 	}))
@@ -41,28 +58,74 @@ func SetupServer(
 		Logger: logger,
 	}
 
-	userHandler := &handlers.UserHandler{Svc: userService}
+	userHandler := &handlers.UserHandler{Svc: userService, Carts: cartService}
 	ph := &handlers.ProductHandler{Svc: productService}
 	wph := &handlers.WalletPaymentHandler{Svc: walletPaymentService}
+	rvh := &handlers.ReviewHandler{Svc: reviewService, Logger: logger}
+	pfh := &handlers.ProfileHandler{Svc: profileService, Logger: logger}
+	evh := &handlers.EventHandler{Svc: eventService, Logger: logger}
 
 	rh.App.Post("/register", userHandler.RegisterUserHandler)
 	rh.App.Post("/login", userHandler.LoginUserHandler)
 	app.Post("/verify", userHandler.Verify)
 	app.Get("/verify", userHandler.GetVerificationCode)
+	app.Post("/password/forgot", userHandler.ForgotPassword)
+	app.Post("/password/reset", userHandler.ResetPassword)
 	app.Get("/products", ph.GetAllProductsHandler)
+	app.Get("/products/:id/reviews", rvh.ListProductReviewsHandler)
+	app.Get("/sellers/:id", pfh.GetSellerProfileHandler)
+	app.Get("/sellers/:id/reviews", rvh.ListSellerReviewsHandler)
+	// The event stream authenticates itself so EventSource clients can use a
+	// stream token in the query string instead of a bearer header.
+	app.Get("/events", middleware.StreamAuthMiddleware(userService.Store), evh.StreamEventsHandler)
+	handlers.CategoryRoutes(rh, categoryService)
+	handlers.CartRoutes(rh, cartService, logger)
 
 	app.Post("/wallet/webhook", wph.RazorpayWebhook)
 
+	if cfg.GoogleClientID != "" {
+		provider, err := auth.NewAuth(cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GoogleCallbackURL, cfg.GoogleDiscoveryURL)
+		if err != nil {
+			logger.Error("Google sign-in disabled: provider setup failed", "error", err)
+		} else {
+			handlers.OAuthRoutes(rh, &handlers.OAuthHandler{
+				Svc:             userService,
+				Carts:           cartService,
+				Provider:        provider,
+				SuccessRedirect: cfg.OAuthSuccessRedirect,
+				Logger:          logger,
+			})
+		}
+	}
+
+	if localStorage, ok := cloudService.(*service.LocalStorageService); ok {
+		handlers.StorageRoutes(rh, localStorage)
+	}
+
 	authMiddleware := middleware.AuthMiddleware(userService.Store)
 	handlers.TokenRoutes(rh, tokenService)
 
 	protected := app.Group("/", authMiddleware)
 
-	handlers.OrderRoutes(rh, orderService, logger, protected)
+	adminOnly := middleware.RequireUserType(userService.Store, "admin")
+	verification := middleware.NewEmailVerificationPolicy(userService, cfg.UnverifiedRestrictedActions)
+
+	// Sales has to be registered before /orders/:id.
+	handlers.CommissionRoutes(rh, commissionService, logger, protected, adminOnly)
+	handlers.OrderRoutes(rh, orderService, logger, protected, verification)
+	handlers.HandoverRoutes(rh, handoverService, logger, protected)
 	handlers.UserRoutes(rh, userService, protected)
-	handlers.WalletRoutes(rh, walletService, walletPaymentService, dbPool, protected)
-	handlers.ProductRoutes(rh, productService, dbPool, userService, protected)
-	handlers.CartRoutes(rh, cartService, logger, protected)
+	handlers.ProfileRoutes(rh, profileService, logger, protected)
+	handlers.WalletRoutes(rh, walletService, walletPaymentService, dbPool, protected, verification)
+	handlers.ProductRoutes(rh, productService, dbPool, userService, categoryService, protected, verification)
+	handlers.WishlistRoutes(rh, wishlistService, logger, protected)
+	handlers.AlertRoutes(rh, alertService, logger, protected)
+	handlers.CouponRoutes(rh, couponService, logger, protected, adminOnly)
+	handlers.MessageRoutes(rh, messageService, logger, protected, adminOnly)
+	handlers.ReviewRoutes(rh, reviewService, logger, protected, adminOnly)
+	handlers.ModerationRoutes(rh, moderationService, logger, protected, adminOnly)
+	handlers.SignupRuleRoutes(rh, signupPolicyService, logger, protected, adminOnly)
+	handlers.EventRoutes(rh, eventService, logger, protected)
 
 	rh.Logger.Info("Starting server", "server", "server")
 	err := app.Listen(cfg.Port)
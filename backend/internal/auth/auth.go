@@ -1,35 +1,31 @@
 package auth
 
 import (
-	"os"
-
-	"github.com/gorilla/sessions"
 	"github.com/markbates/goth"
-	"github.com/markbates/goth/gothic"
-	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/openidConnect"
 )
 
 const (
-	maxAge = 86400 * 30
-	isProd = false
+	ProviderGoogle = "google"
+
+	googleDiscoveryURL = "https://accounts.google.com/.well-known/openid-configuration"
 )
 
-func NewAuth() {
-	googleClientId := os.Getenv("G_CLIENT_ID")
-	googleClientSecret := os.Getenv("G_CLIENT_SECRET")
+// NewAuth registers Google with goth as an OpenID Connect provider, which
+// gives us the verified email claim. discoveryURL defaults to Google's and
+// can point at a local provider instead. Discovery happens here, so this
+// needs the provider to be reachable.
+func NewAuth(clientID, clientSecret, callbackURL, discoveryURL string) (goth.Provider, error) {
+	if discoveryURL == "" {
+		discoveryURL = googleDiscoveryURL
+	}
 
-	store := sessions.NewCookieStore([]byte(os.Getenv("G_KEY")))
-	store.MaxAge(maxAge)
-	store.Options.Path = "/"
-	store.Options.HttpOnly = true
-	store.Options.Secure = isProd
-	var redirectURL string
-	if isProd {
-		redirectURL = "https://kiitwallet.dev"
-	} else {
-		redirectURL = "http://localhost:8080/auth/google/callback"
+	provider, err := openidConnect.NewNamed(ProviderGoogle, clientID, clientSecret, callbackURL, discoveryURL, "openid", "email", "profile")
+	if err != nil {
+		return nil, err
 	}
-	gothic.Store = store
-	goth.UseProviders(google.New(googleClientId, googleClientSecret, redirectURL))
+	provider.SetName(ProviderGoogle)
 
+	goth.UseProviders(provider)
+	return provider, nil
 }
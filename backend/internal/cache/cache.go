@@ -11,4 +11,15 @@ type Cache interface {
 	GetUserIDByTokenHash(ctx context.Context, tokenHash string) (int64, error)
 	DeleteToken(ctx context.Context, tokenHash string) error
 	GetTokenHashByUserID(ctx context.Context, userID int64) (string, error)
+	GetEmailVerified(ctx context.Context, userID int64) (verified, ok bool, err error)
+	SetEmailVerified(ctx context.Context, userID int64, verified bool, ttl time.Duration) error
+	DeleteEmailVerified(ctx context.Context, userID int64) error
+	IncrVerificationAttempts(ctx context.Context, userID int64, window time.Duration) (int64, error)
+	ResetVerificationAttempts(ctx context.Context, userID int64) error
+	StartVerificationCooldown(ctx context.Context, userID int64, cooldown time.Duration) (bool, error)
+	SetPendingEmailChange(ctx context.Context, userID int64, email, tokenHash string, ttl time.Duration) error
+	GetPendingEmailChange(ctx context.Context, userID int64) (email, tokenHash string, err error)
+	IncrEmailChangeAttempts(ctx context.Context, userID int64, window time.Duration) (int64, error)
+	ResetEmailChangeAttempts(ctx context.Context, userID int64) error
+	DeletePendingEmailChange(ctx context.Context, userID int64) error
 }
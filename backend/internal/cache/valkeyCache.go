@@ -32,24 +32,40 @@ func NewValkeyCache() (*ValkeyCache, error) {
 	return &ValkeyCache{Client: client}, nil
 }
 
-func (v *ValkeyCache) GetTokenHashByUserID(ctx context.Context, userID int64) (string, error) {
+func (v *ValkeyCache) verificationTokenKey(tokenHash string) string {
+	return fmt.Sprintf("%s:hash:%s", Verification, tokenHash)
+}
+
+func (v *ValkeyCache) userVerificationKey(userID int64) string {
+	return fmt.Sprintf("%s:%d:%s", User, userID, Verification)
+}
 
-	key := fmt.Sprintf("%s:%d:%s", User, userID, Verification)
-	tokenHash, err := v.Client.Do(ctx, v.Client.B().Get().Key(key).Build()).ToString()
+// GetTokenHashByUserID returns the hash of the user's current verification
+// code, or "" when they have none.
+func (v *ValkeyCache) GetTokenHashByUserID(ctx context.Context, userID int64) (string, error) {
+	tokenHash, err := v.Client.Do(ctx, v.Client.B().Get().Key(v.userVerificationKey(userID)).Build()).ToString()
 	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return "", nil
+		}
 		return "", err
 	}
 	return tokenHash, nil
 }
 
+// SetVerificationToken stores a user's verification code, replacing any
+// earlier code so only the newest one works.
 func (v *ValkeyCache) SetVerificationToken(ctx context.Context, tokenHash string, userID int64, expiry time.Duration) error {
 	userIDstr := strconv.FormatInt(userID, 10)
 
-	token := fmt.Sprintf("%s:%s:%s", "verification", "hash", tokenHash)
+	previous, err := v.GetTokenHashByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to look up previous verification token: %w", err)
+	}
 
-	tokenKeyCmd := v.Client.B().Set().Key(token).Value(userIDstr).Nx().Ex(expiry).Build()
+	tokenKeyCmd := v.Client.B().Set().Key(v.verificationTokenKey(tokenHash)).Value(userIDstr).Nx().Ex(expiry).Build()
 
-	userVerificationKey := fmt.Sprintf("user:%s:%s", userIDstr, "verification")
+	userVerificationKey := v.userVerificationKey(userID)
 	userKeyCmd := v.Client.B().Set().Key(userVerificationKey).Value(tokenHash).Ex(expiry).Build()
 
 	resp := v.Client.DoMulti(ctx, tokenKeyCmd, userKeyCmd)
@@ -70,9 +86,44 @@ func (v *ValkeyCache) SetVerificationToken(ctx context.Context, tokenHash string
 		return fmt.Errorf("valkey command 2 error on user key SET: %w", err)
 	}
 
+	if previous != "" && previous != tokenHash {
+		if err := v.Client.Do(ctx, v.Client.B().Del().Key(v.verificationTokenKey(previous)).Build()).Error(); err != nil {
+			return fmt.Errorf("failed to remove previous verification token: %w", err)
+		}
+	}
+
 	return nil
 }
 
+func (v *ValkeyCache) emailVerifiedKey(userID int64) string {
+	return fmt.Sprintf("%s:%d:email_verified", User, userID)
+}
+
+// GetEmailVerified returns the cached verification status of a user's email.
+// ok is false when nothing is cached.
+func (v *ValkeyCache) GetEmailVerified(ctx context.Context, userID int64) (verified, ok bool, err error) {
+	n, err := v.Client.Do(ctx, v.Client.B().Get().Key(v.emailVerifiedKey(userID)).Build()).AsInt64()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	return n == 1, true, nil
+}
+
+func (v *ValkeyCache) SetEmailVerified(ctx context.Context, userID int64, verified bool, ttl time.Duration) error {
+	value := "0"
+	if verified {
+		value = "1"
+	}
+	return v.Client.Do(ctx, v.Client.B().Set().Key(v.emailVerifiedKey(userID)).Value(value).Ex(ttl).Build()).Error()
+}
+
+func (v *ValkeyCache) DeleteEmailVerified(ctx context.Context, userID int64) error {
+	return v.Client.Do(ctx, v.Client.B().Del().Key(v.emailVerifiedKey(userID)).Build()).Error()
+}
+
 const EmailQueueKey = "queue:emails"
 
 func (v *ValkeyCache) AddEmailToQueue(ctx context.Context, email, jobJSON string) error {
@@ -83,7 +134,7 @@ func (v *ValkeyCache) AddEmailToQueue(ctx context.Context, email, jobJSON string
 }
 
 func (v *ValkeyCache) GetUserIDByTokenHash(ctx context.Context, tokenHash string) (int64, error) {
-	id, err := v.Client.Do(ctx, v.Client.B().Get().Key(tokenHash).Build()).AsInt64()
+	id, err := v.Client.Do(ctx, v.Client.B().Get().Key(v.verificationTokenKey(tokenHash)).Build()).AsInt64()
 
 	if err != nil {
 		if valkey.IsValkeyNil(err) {
@@ -94,6 +145,8 @@ func (v *ValkeyCache) GetUserIDByTokenHash(ctx context.Context, tokenHash string
 
 	return id, nil
 }
+
+// DeleteToken consumes a verification token, removing both its keys.
 func (v *ValkeyCache) DeleteToken(ctx context.Context, tokenHash string) error {
 	id, err := v.GetUserIDByTokenHash(ctx, tokenHash)
 
@@ -104,10 +157,8 @@ func (v *ValkeyCache) DeleteToken(ctx context.Context, tokenHash string) error {
 		return err
 	}
 
-	userIDstr := strconv.FormatInt(id, 10)
-
-	tokenDelCmd := v.Client.B().Del().Key(tokenHash).Build()
-	userDelCmd := v.Client.B().Del().Key(userIDstr).Build()
+	tokenDelCmd := v.Client.B().Del().Key(v.verificationTokenKey(tokenHash)).Build()
+	userDelCmd := v.Client.B().Del().Key(v.userVerificationKey(id)).Build()
 
 	resp := v.Client.DoMulti(ctx, tokenDelCmd, userDelCmd)
 
@@ -121,20 +172,145 @@ func (v *ValkeyCache) DeleteToken(ctx context.Context, tokenHash string) error {
 
 	return nil
 }
-func (v *ValkeyCache) cartKey(userID int64) string {
+
+func (v *ValkeyCache) verificationAttemptsKey(userID int64) string {
+	return fmt.Sprintf("%s:%d:%s:attempts", User, userID, Verification)
+}
+
+// IncrVerificationAttempts counts a verification attempt and returns the new
+// total. The count expires window after the first attempt.
+func (v *ValkeyCache) IncrVerificationAttempts(ctx context.Context, userID int64, window time.Duration) (int64, error) {
+	return v.incrWithTTL(ctx, v.verificationAttemptsKey(userID), window)
+}
+
+// incrWithTTLScript increments a counter and sets its expiry when it is
+// created, in one step so a failure in between can't leave it without one.
+var incrWithTTLScript = valkey.NewLuaScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// incrWithTTL increments key, starting its window on the first increment.
+func (v *ValkeyCache) incrWithTTL(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return incrWithTTLScript.Exec(ctx, v.Client, []string{key}, []string{strconv.FormatInt(window.Milliseconds(), 10)}).AsInt64()
+}
+
+func (v *ValkeyCache) ResetVerificationAttempts(ctx context.Context, userID int64) error {
+	return v.Client.Do(ctx, v.Client.B().Del().Key(v.verificationAttemptsKey(userID)).Build()).Error()
+}
+
+// StartVerificationCooldown claims the right to send a user a new code. It
+// returns false while the previous send is still cooling down.
+func (v *ValkeyCache) StartVerificationCooldown(ctx context.Context, userID int64, cooldown time.Duration) (bool, error) {
+	key := fmt.Sprintf("%s:%d:%s:cooldown", User, userID, Verification)
+
+	err := v.Client.Do(ctx, v.Client.B().Set().Key(key).Value("1").Nx().Ex(cooldown).Build()).Error()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (v *ValkeyCache) emailChangeKey(userID int64) string {
+	return fmt.Sprintf("%s:%d:email_change", User, userID)
+}
+
+// SetPendingEmailChange stores a requested address change with the hash of
+// the code sent to the new address, replacing any earlier request.
+func (v *ValkeyCache) SetPendingEmailChange(ctx context.Context, userID int64, email, tokenHash string, ttl time.Duration) error {
+	key := v.emailChangeKey(userID)
+
+	cmds := valkey.Commands{
+		v.Client.B().Del().Key(key).Build(),
+		v.Client.B().Hset().Key(key).FieldValue().FieldValue("email", email).FieldValue("hash", tokenHash).Build(),
+		v.Client.B().Expire().Key(key).Seconds(int64(ttl.Seconds())).Build(),
+	}
+	for _, resp := range v.Client.DoMulti(ctx, cmds...) {
+		if err := resp.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPendingEmailChange returns the requested address and code hash, or
+// empty strings when there is no pending change.
+func (v *ValkeyCache) GetPendingEmailChange(ctx context.Context, userID int64) (email, tokenHash string, err error) {
+	fields, err := v.Client.Do(ctx, v.Client.B().Hgetall().Key(v.emailChangeKey(userID)).Build()).AsStrMap()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+	return fields["email"], fields["hash"], nil
+}
+
+func (v *ValkeyCache) emailChangeAttemptsKey(userID int64) string {
+	return fmt.Sprintf("%s:%d:email_change:attempts", User, userID)
+}
+
+// IncrEmailChangeAttempts counts an attempt to confirm an email change. It is
+// kept apart from the pending change so issuing a new code doesn't reset it.
+func (v *ValkeyCache) IncrEmailChangeAttempts(ctx context.Context, userID int64, window time.Duration) (int64, error) {
+	return v.incrWithTTL(ctx, v.emailChangeAttemptsKey(userID), window)
+}
+
+func (v *ValkeyCache) ResetEmailChangeAttempts(ctx context.Context, userID int64) error {
+	return v.Client.Do(ctx, v.Client.B().Del().Key(v.emailChangeAttemptsKey(userID)).Build()).Error()
+}
+
+func (v *ValkeyCache) DeletePendingEmailChange(ctx context.Context, userID int64) error {
+	return v.Client.Do(ctx, v.Client.B().Del().Key(v.emailChangeKey(userID)).Build()).Error()
+}
+
+func (v *ValkeyCache) CartKey(userID int64) string {
 	return fmt.Sprintf("cart:%d", userID)
 }
 
-func (v *ValkeyCache) AddToCart(ctx context.Context, userID int64, productID int64, quantity int) error {
-	key := v.cartKey(userID)
+func (v *ValkeyCache) GuestCartKey(sessionID string) string {
+	return fmt.Sprintf("cart:guest:%s", sessionID)
+}
+
+func (v *ValkeyCache) cartPricesKey(key string) string {
+	return key + ":prices"
+}
+
+func (v *ValkeyCache) AddToCart(ctx context.Context, key string, productID int64, quantity int, price int32) error {
 	field := strconv.FormatInt(productID, 10)
 
-	cmd := v.Client.B().Hincrby().Key(key).Field(field).Increment(int64(quantity)).Build()
-	return v.Client.Do(ctx, cmd).Error()
+	qtyCmd := v.Client.B().Hincrby().Key(key).Field(field).Increment(int64(quantity)).Build()
+	priceCmd := v.Client.B().Hset().Key(v.cartPricesKey(key)).FieldValue().FieldValue(field, strconv.Itoa(int(price))).Build()
+
+	for _, resp := range v.Client.DoMulti(ctx, qtyCmd, priceCmd) {
+		if err := resp.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *ValkeyCache) SetCartItem(ctx context.Context, key string, productID int64, quantity int, price int32) error {
+	field := strconv.FormatInt(productID, 10)
+
+	qtyCmd := v.Client.B().Hset().Key(key).FieldValue().FieldValue(field, strconv.Itoa(quantity)).Build()
+	priceCmd := v.Client.B().Hset().Key(v.cartPricesKey(key)).FieldValue().FieldValue(field, strconv.Itoa(int(price))).Build()
+
+	for _, resp := range v.Client.DoMulti(ctx, qtyCmd, priceCmd) {
+		if err := resp.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (v *ValkeyCache) GetCart(ctx context.Context, userID int64) (map[string]string, error) {
-	key := v.cartKey(userID)
+func (v *ValkeyCache) GetCart(ctx context.Context, key string) (map[string]string, error) {
 	cmd := v.Client.B().Hgetall().Key(key).Build()
 
 	res, err := v.Client.Do(ctx, cmd).AsStrMap()
@@ -147,34 +323,199 @@ func (v *ValkeyCache) GetCart(ctx context.Context, userID int64) (map[string]str
 	return res, nil
 }
 
-func (v *ValkeyCache) UpdateCartItemQuantity(ctx context.Context, userID int64, productID int64, quantity int) error {
-	key := v.cartKey(userID)
+func (v *ValkeyCache) GetCartPrices(ctx context.Context, key string) (map[string]string, error) {
+	return v.GetCart(ctx, v.cartPricesKey(key))
+}
+
+func (v *ValkeyCache) DeleteCartItem(ctx context.Context, key string, productID int64) error {
 	field := strconv.FormatInt(productID, 10)
 
-	if quantity <= 0 {
-		return v.DeleteCartItem(ctx, userID, productID)
+	qtyCmd := v.Client.B().Hdel().Key(key).Field(field).Build()
+	priceCmd := v.Client.B().Hdel().Key(v.cartPricesKey(key)).Field(field).Build()
+
+	for _, resp := range v.Client.DoMulti(ctx, qtyCmd, priceCmd) {
+		if err := resp.Error(); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	cmd := v.Client.B().Hset().Key(key).FieldValue().FieldValue(field, strconv.Itoa(quantity)).Build()
+func (v *ValkeyCache) ClearCart(ctx context.Context, key string) error {
+	cmd := v.Client.B().Del().Key(key, v.cartPricesKey(key)).Build()
 	return v.Client.Do(ctx, cmd).Error()
 }
 
-func (v *ValkeyCache) DeleteCartItem(ctx context.Context, userID int64, productID int64) error {
-	key := v.cartKey(userID)
-	field := strconv.FormatInt(productID, 10)
+// ExpireCart (re)sets the TTL on a cart and its price hash. Guest carts call
+// this on every write so abandoned carts age out.
+func (v *ValkeyCache) ExpireCart(ctx context.Context, key string, ttl time.Duration) error {
+	seconds := int64(ttl.Seconds())
+	qtyCmd := v.Client.B().Expire().Key(key).Seconds(seconds).Build()
+	priceCmd := v.Client.B().Expire().Key(v.cartPricesKey(key)).Seconds(seconds).Build()
 
-	cmd := v.Client.B().Hdel().Key(key).Field().Field(field).Build()
-	return v.Client.Do(ctx, cmd).Error()
+	for _, resp := range v.Client.DoMulti(ctx, qtyCmd, priceCmd) {
+		if err := resp.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *ValkeyCache) GetCartCount(ctx context.Context, key string) (int64, error) {
+	cmd := v.Client.B().Hlen().Key(key).Build()
+	return v.Client.Do(ctx, cmd).AsInt64()
+}
+
+const alertDigestUsersKey = "alerts:digest:users"
+
+func (v *ValkeyCache) alertDigestKey(userID int64) string {
+	return fmt.Sprintf("alerts:digest:%d", userID)
+}
+
+// AddEmailsToQueue pushes a batch of mail jobs onto the email queue in a
+// single LPUSH.
+func (v *ValkeyCache) AddEmailsToQueue(ctx context.Context, jobsJSON []string) error {
+	if len(jobsJSON) == 0 {
+		return nil
+	}
+	return v.Client.Do(ctx, v.Client.B().Lpush().Key(EmailQueueKey).Element(jobsJSON...).Build()).Error()
+}
+
+// IncrAlertCount counts the alerts sent to a user in the current window. The
+// window starts with the first alert.
+func (v *ValkeyCache) IncrAlertCount(ctx context.Context, userID int64, window time.Duration) (int64, error) {
+	return v.incrWithTTL(ctx, fmt.Sprintf("alerts:rate:%d", userID), window)
+}
+
+func (v *ValkeyCache) PushDigestAlert(ctx context.Context, userID int64, entryJSON string) error {
+	pushCmd := v.Client.B().Rpush().Key(v.alertDigestKey(userID)).Element(entryJSON).Build()
+	userCmd := v.Client.B().Sadd().Key(alertDigestUsersKey).Member(strconv.FormatInt(userID, 10)).Build()
+
+	for _, resp := range v.Client.DoMulti(ctx, pushCmd, userCmd) {
+		if err := resp.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *ValkeyCache) DigestUsers(ctx context.Context) ([]int64, error) {
+	members, err := v.Client.Do(ctx, v.Client.B().Smembers().Key(alertDigestUsersKey).Build()).AsStrSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseInt(m, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// PopDigestAlerts removes and returns up to max pending digest entries for a
+// user. The user is dropped from the pending set first, so an alert pushed
+// concurrently re-adds them for the next run instead of being stranded.
+func (v *ValkeyCache) PopDigestAlerts(ctx context.Context, userID int64, max int64) ([]string, error) {
+	err := v.Client.Do(ctx, v.Client.B().Srem().Key(alertDigestUsersKey).Member(strconv.FormatInt(userID, 10)).Build()).Error()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := v.Client.Do(ctx, v.Client.B().Lpop().Key(v.alertDigestKey(userID)).Count(max).Build()).AsStrSlice()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return entries, nil
+}
+
+// UserEvent is one entry in a user's event log. ID is the stream entry ID,
+// which clients send back as Last-Event-ID to resume.
+type UserEvent struct {
+	ID      string
+	Payload string
+}
+
+func (v *ValkeyCache) userEventsChannel(userID int64) string {
+	return fmt.Sprintf("events:user:%d", userID)
 }
 
-func (v *ValkeyCache) ClearCart(ctx context.Context, userID int64) error {
-	key := v.cartKey(userID)
-	cmd := v.Client.B().Del().Key(key).Build()
+func (v *ValkeyCache) userEventsLogKey(userID int64) string {
+	return fmt.Sprintf("events:user:%d:log", userID)
+}
+
+// AppendUserEvent adds an event to the user's log, keeping roughly the last
+// maxLen entries for up to ttl, and returns its ID.
+func (v *ValkeyCache) AppendUserEvent(ctx context.Context, userID int64, payload string, maxLen int64, ttl time.Duration) (string, error) {
+	key := v.userEventsLogKey(userID)
+
+	addCmd := v.Client.B().Xadd().Key(key).Maxlen().Almost().Threshold(strconv.FormatInt(maxLen, 10)).Id("*").FieldValue().FieldValue("event", payload).Build()
+	expireCmd := v.Client.B().Expire().Key(key).Seconds(int64(ttl.Seconds())).Build()
+
+	resps := v.Client.DoMulti(ctx, addCmd, expireCmd)
+	id, err := resps[0].ToString()
+	if err != nil {
+		return "", err
+	}
+	if err := resps[1].Error(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// UserEventsAfter returns up to count events logged after the given ID,
+// oldest first.
+func (v *ValkeyCache) UserEventsAfter(ctx context.Context, userID int64, afterID string, count int64) ([]UserEvent, error) {
+	cmd := v.Client.B().Xrange().Key(v.userEventsLogKey(userID)).Start("(" + afterID).End("+").Count(count).Build()
+
+	entries, err := v.Client.Do(ctx, cmd).AsXRange()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	events := make([]UserEvent, 0, len(entries))
+	for _, e := range entries {
+		events = append(events, UserEvent{ID: e.ID, Payload: e.FieldValues["event"]})
+	}
+	return events, nil
+}
+
+// LatestUserEventID is the ID of the newest logged event, or "0" when the
+// user has none.
+func (v *ValkeyCache) LatestUserEventID(ctx context.Context, userID int64) (string, error) {
+	cmd := v.Client.B().Xrevrange().Key(v.userEventsLogKey(userID)).End("+").Start("-").Count(1).Build()
+
+	entries, err := v.Client.Do(ctx, cmd).AsXRange()
+	if err != nil && !valkey.IsValkeyNil(err) {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "0", nil
+	}
+	return entries[0].ID, nil
+}
+
+// PublishUserEvent tells every API instance holding a connection for the
+// user that there's a new event with the given ID.
+func (v *ValkeyCache) PublishUserEvent(ctx context.Context, userID int64, eventID string) error {
+	cmd := v.Client.B().Publish().Channel(v.userEventsChannel(userID)).Message(eventID).Build()
 	return v.Client.Do(ctx, cmd).Error()
 }
 
-func (v *ValkeyCache) GetCartCount(ctx context.Context, userID int64) (int64, error) {
-	key := v.cartKey(userID)
-	cmd := v.Client.B().Hlen().Key(key).Build()
-	return v.Client.Do(ctx, cmd).AsInt64()
+// SubscribeUserEvents calls fn for each event published for the user until
+// ctx is cancelled.
+func (v *ValkeyCache) SubscribeUserEvents(ctx context.Context, userID int64, fn func(eventID string)) error {
+	cmd := v.Client.B().Subscribe().Channel(v.userEventsChannel(userID)).Build()
+	return v.Client.Receive(ctx, cmd, func(msg valkey.PubSubMessage) {
+		fn(msg.Message)
+	})
 }
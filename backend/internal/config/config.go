@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -23,6 +26,45 @@ type Config struct {
 	MailerPassword string
 	CloudinaryURL  string
 
+	StorageBackend    string
+	StorageSigningKey string
+	LocalStorageDir   string
+	PublicBaseURL     string
+	S3Endpoint        string
+	S3AccessKey       string
+	S3SecretKey       string
+	S3Bucket          string
+	S3UseSSL          bool
+	S3PublicURL       string
+
+	OrphanSweepInterval time.Duration
+	OrphanGracePeriod   time.Duration
+
+	CartPersistence     bool
+	CartSessionSecret   string
+	GuestCartTTL        time.Duration
+	MaxCartItemQuantity int
+	MaxCartItems        int
+
+	AlertRateLimit  int
+	AlertDigestTime time.Duration
+
+	CommissionDefaultBps int
+	CommissionMinFee     int
+
+	ListingReportThreshold int
+
+	UnverifiedRestrictedActions []string
+
+	AllowedEmailDomains []string
+	RollNumberPattern   *regexp.Regexp
+
+	GoogleClientID       string
+	GoogleClientSecret   string
+	GoogleCallbackURL    string
+	GoogleDiscoveryURL   string
+	OAuthSuccessRedirect string
+
 	ESDSN string `env:"ES_DSN"`
 }
 
@@ -33,6 +75,102 @@ func NewConfig() (cfg Config, err error) {
 	cfg.CloudinaryURL = os.Getenv("CLOUDINARY_URL")
 	cfg.DBString = os.Getenv("GOOSE_DBSTRING")
 
+	cfg.StorageBackend = os.Getenv("STORAGE_BACKEND")
+	if cfg.StorageBackend == "" {
+		cfg.StorageBackend = "cloudinary"
+	}
+	cfg.StorageSigningKey = os.Getenv("STORAGE_SIGNING_KEY")
+	cfg.LocalStorageDir = os.Getenv("LOCAL_STORAGE_DIR")
+	if cfg.LocalStorageDir == "" {
+		cfg.LocalStorageDir = "./uploads"
+	}
+	cfg.PublicBaseURL = os.Getenv("PUBLIC_BASE_URL")
+	cfg.S3Endpoint = os.Getenv("S3_ENDPOINT")
+	cfg.S3AccessKey = os.Getenv("S3_ACCESS_KEY")
+	cfg.S3SecretKey = os.Getenv("S3_SECRET_KEY")
+	cfg.S3Bucket = os.Getenv("S3_BUCKET")
+	cfg.S3UseSSL = os.Getenv("S3_USE_SSL") == "true"
+	cfg.S3PublicURL = os.Getenv("S3_PUBLIC_URL")
+
+	cfg.OrphanSweepInterval, err = durationEnv("ORPHAN_SWEEP_INTERVAL", 6*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.OrphanGracePeriod, err = durationEnv("ORPHAN_GRACE_PERIOD", 24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.CartPersistence = os.Getenv("CART_PERSISTENCE") == "postgres"
+	cfg.CartSessionSecret = os.Getenv("CART_SESSION_SECRET")
+	cfg.GuestCartTTL, err = durationEnv("GUEST_CART_TTL", 7*24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.MaxCartItemQuantity, err = intEnv("MAX_CART_ITEM_QUANTITY", 10)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.MaxCartItems, err = intEnv("MAX_CART_ITEMS", 50)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.AlertRateLimit, err = intEnv("ALERT_RATE_LIMIT", 5)
+	if err != nil {
+		return Config{}, err
+	}
+	// Digests go out once a day at this local time, e.g. 08:00.
+	cfg.AlertDigestTime, err = clockEnv("ALERT_DIGEST_TIME", 8*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Basis points and paise respectively.
+	cfg.CommissionDefaultBps, err = intEnv("COMMISSION_DEFAULT_BPS", 500)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.CommissionMinFee, err = intEnv("COMMISSION_MIN_FEE", 500)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Open reports that automatically hold a listing for review; 0 disables it.
+	cfg.ListingReportThreshold, err = intEnv("LISTING_REPORT_THRESHOLD", 3)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Actions that need a verified email; "none" opens everything up.
+	cfg.UnverifiedRestrictedActions, err = listEnv("UNVERIFIED_RESTRICTED_ACTIONS", []string{"sell", "transfer", "checkout"}, "sell", "transfer", "checkout")
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Signup is limited to these domains and their subdomains; empty allows
+	// any. The roll number pattern is matched against the local part, e.g.
+	// ^(?P<roll>(?P<batch>\d{2})(?P<branch>\d{2})\d{3,4})$ for 2105123@kiit.ac.in.
+	cfg.AllowedEmailDomains = csvEnv("ALLOWED_EMAIL_DOMAINS")
+	if raw := os.Getenv("ROLL_NUMBER_PATTERN"); raw != "" {
+		cfg.RollNumberPattern, err = regexp.Compile(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("config error: invalid ROLL_NUMBER_PATTERN value '%s': %w", raw, err)
+		}
+	}
+
+	// Google sign-in is off unless a client ID is set. With a success
+	// redirect the tokens are handed to the frontend in the URL fragment
+	// instead of being returned as JSON.
+	cfg.GoogleClientID = os.Getenv("G_CLIENT_ID")
+	cfg.GoogleClientSecret = os.Getenv("G_CLIENT_SECRET")
+	cfg.GoogleCallbackURL = os.Getenv("G_CALLBACK_URL")
+	if cfg.GoogleCallbackURL == "" {
+		cfg.GoogleCallbackURL = "http://localhost:8080/auth/google/callback"
+	}
+	cfg.GoogleDiscoveryURL = os.Getenv("G_DISCOVERY_URL")
+	cfg.OAuthSuccessRedirect = os.Getenv("OAUTH_SUCCESS_REDIRECT")
+
 	cfg.MailerHost = os.Getenv("MAILER_HOST")
 	mailerPortStr := os.Getenv("MAILER_PORT")
 
@@ -60,3 +198,78 @@ func NewConfig() (cfg Config, err error) {
 
 	return cfg, nil
 }
+
+func durationEnv(key string, fallback time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("config error: invalid %s value '%s': %w", key, raw, err)
+	}
+	return d, nil
+}
+
+// clockEnv reads a time of day as HH:MM and returns it as the offset from
+// midnight.
+func clockEnv(key string, fallback time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	t, err := time.Parse("15:04", raw)
+	if err != nil {
+		return 0, fmt.Errorf("config error: invalid %s value '%s': %w", key, raw, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func intEnv(key string, fallback int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("config error: invalid %s value '%s': %w", key, raw, err)
+	}
+	return n, nil
+}
+
+// csvEnv reads a comma-separated list, lower-cased with blanks dropped.
+func csvEnv(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// listEnv reads a comma-separated list whose items must all be permitted.
+// An empty variable gives the fallback and "none" an empty list.
+func listEnv(key string, fallback []string, permitted ...string) ([]string, error) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	switch raw {
+	case "":
+		return fallback, nil
+	case "none":
+		return []string{}, nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if !slices.Contains(permitted, item) {
+			return nil, fmt.Errorf("config error: invalid %s value '%s': must be one of %s", key, item, strings.Join(permitted, ", "))
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
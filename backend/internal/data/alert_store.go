@@ -0,0 +1,80 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type AlertStore interface {
+	CreateProductAlert(ctx context.Context, userID, productID int64, kind string) error
+	DeleteProductAlert(ctx context.Context, userID, productID int64, kind string) error
+	ListProductAlertsForUser(ctx context.Context, userID int64) ([]db.ListProductAlertsForUserRow, error)
+	ListAlertSubscribers(ctx context.Context, productID int64, kind string) ([]db.ListAlertSubscribersRow, error)
+	GetAlertMode(ctx context.Context, userID int32) (string, error)
+	SetAlertMode(ctx context.Context, userID int32, mode string) error
+}
+
+type sqlAlertStore struct {
+	q *db.Queries
+}
+
+func NewAlertStore(queries *db.Queries) AlertStore {
+	return &sqlAlertStore{
+		q: queries,
+	}
+}
+
+func (s *sqlAlertStore) CreateProductAlert(ctx context.Context, userID, productID int64, kind string) error {
+	return s.q.CreateProductAlert(ctx, db.CreateProductAlertParams{
+		UserID:    userID,
+		ProductID: productID,
+		Kind:      kind,
+	})
+}
+
+func (s *sqlAlertStore) DeleteProductAlert(ctx context.Context, userID, productID int64, kind string) error {
+	rows, err := s.q.DeleteProductAlert(ctx, db.DeleteProductAlertParams{
+		UserID:    userID,
+		ProductID: productID,
+		Kind:      kind,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *sqlAlertStore) ListProductAlertsForUser(ctx context.Context, userID int64) ([]db.ListProductAlertsForUserRow, error) {
+	return s.q.ListProductAlertsForUser(ctx, userID)
+}
+
+func (s *sqlAlertStore) ListAlertSubscribers(ctx context.Context, productID int64, kind string) ([]db.ListAlertSubscribersRow, error) {
+	return s.q.ListAlertSubscribers(ctx, db.ListAlertSubscribersParams{
+		ProductID: productID,
+		Kind:      kind,
+	})
+}
+
+func (s *sqlAlertStore) GetAlertMode(ctx context.Context, userID int32) (string, error) {
+	mode, err := s.q.GetAlertMode(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrRecordNotFound
+		}
+		return "", err
+	}
+	return mode, nil
+}
+
+func (s *sqlAlertStore) SetAlertMode(ctx context.Context, userID int32, mode string) error {
+	return s.q.SetAlertMode(ctx, db.SetAlertModeParams{
+		ID:        userID,
+		AlertMode: mode,
+	})
+}
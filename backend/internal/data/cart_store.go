@@ -0,0 +1,57 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+)
+
+type CartStore interface {
+	AddCartItem(ctx context.Context, userID, productID int64, quantity int, price int32) error
+	SetCartItem(ctx context.Context, userID, productID int64, quantity int, price int32) error
+	GetCartItems(ctx context.Context, userID int64) ([]db.GetCartItemsRow, error)
+	DeleteCartItem(ctx context.Context, userID, productID int64) error
+	ClearCart(ctx context.Context, userID int64) error
+}
+
+type sqlCartStore struct {
+	q *db.Queries
+}
+
+func NewCartStore(queries *db.Queries) CartStore {
+	return &sqlCartStore{
+		q: queries,
+	}
+}
+
+func (s *sqlCartStore) AddCartItem(ctx context.Context, userID, productID int64, quantity int, price int32) error {
+	return s.q.AddCartItem(ctx, db.AddCartItemParams{
+		UserID:     userID,
+		ProductID:  productID,
+		Quantity:   int32(quantity),
+		PriceAtAdd: price,
+	})
+}
+
+func (s *sqlCartStore) SetCartItem(ctx context.Context, userID, productID int64, quantity int, price int32) error {
+	return s.q.SetCartItem(ctx, db.SetCartItemParams{
+		UserID:     userID,
+		ProductID:  productID,
+		Quantity:   int32(quantity),
+		PriceAtAdd: price,
+	})
+}
+
+func (s *sqlCartStore) GetCartItems(ctx context.Context, userID int64) ([]db.GetCartItemsRow, error) {
+	return s.q.GetCartItems(ctx, userID)
+}
+
+func (s *sqlCartStore) DeleteCartItem(ctx context.Context, userID, productID int64) error {
+	return s.q.DeleteCartItem(ctx, db.DeleteCartItemParams{
+		UserID:    userID,
+		ProductID: productID,
+	})
+}
+
+func (s *sqlCartStore) ClearCart(ctx context.Context, userID int64) error {
+	return s.q.ClearCart(ctx, userID)
+}
@@ -0,0 +1,39 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CategoryStore interface {
+	ListCategoriesWithCounts(ctx context.Context) ([]db.ListCategoriesWithCountsRow, error)
+	GetCategoryBySlugOrName(ctx context.Context, term string) (db.Category, error)
+}
+
+type sqlCategoryStore struct {
+	q *db.Queries
+}
+
+func NewCategoryStore(queries *db.Queries) CategoryStore {
+	return &sqlCategoryStore{
+		q: queries,
+	}
+}
+
+func (s *sqlCategoryStore) ListCategoriesWithCounts(ctx context.Context) ([]db.ListCategoriesWithCountsRow, error) {
+	return s.q.ListCategoriesWithCounts(ctx)
+}
+
+func (s *sqlCategoryStore) GetCategoryBySlugOrName(ctx context.Context, term string) (db.Category, error) {
+	category, err := s.q.GetCategoryBySlugOrName(ctx, term)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Category{}, ErrRecordNotFound
+		}
+		return db.Category{}, err
+	}
+	return category, nil
+}
@@ -0,0 +1,70 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CommissionStore interface {
+	ListCategoryCommissionRates(ctx context.Context) ([]db.ListCategoryCommissionRatesRow, error)
+	SetCategoryCommission(ctx context.Context, categoryID int64, bps *int32) error
+	ListVerifiedClubs(ctx context.Context, ids []int32) ([]int32, error)
+	SetClubVerified(ctx context.Context, userID int32, verified bool) error
+	ListSalesBySeller(ctx context.Context, sellerID int64) ([]db.ListSalesBySellerRow, error)
+}
+
+type sqlCommissionStore struct {
+	q *db.Queries
+}
+
+func NewCommissionStore(queries *db.Queries) CommissionStore {
+	return &sqlCommissionStore{
+		q: queries,
+	}
+}
+
+func (s *sqlCommissionStore) ListCategoryCommissionRates(ctx context.Context) ([]db.ListCategoryCommissionRatesRow, error) {
+	return s.q.ListCategoryCommissionRates(ctx)
+}
+
+func (s *sqlCommissionStore) SetCategoryCommission(ctx context.Context, categoryID int64, bps *int32) error {
+	arg := db.SetCategoryCommissionParams{ID: categoryID}
+	if bps != nil {
+		arg.CommissionBps = pgtype.Int4{Int32: *bps, Valid: true}
+	}
+
+	rows, err := s.q.SetCategoryCommission(ctx, arg)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *sqlCommissionStore) ListVerifiedClubs(ctx context.Context, ids []int32) ([]int32, error) {
+	return s.q.ListVerifiedClubs(ctx, ids)
+}
+
+// SetClubVerified only touches ordinary and club accounts; admin and
+// platform accounts report not found.
+func (s *sqlCommissionStore) SetClubVerified(ctx context.Context, userID int32, verified bool) error {
+	rows, err := s.q.SetClubVerified(ctx, db.SetClubVerifiedParams{
+		ID:       userID,
+		Verified: verified,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *sqlCommissionStore) ListSalesBySeller(ctx context.Context, sellerID int64) ([]db.ListSalesBySellerRow, error) {
+	return s.q.ListSalesBySeller(ctx, sellerID)
+}
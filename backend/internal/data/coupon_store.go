@@ -0,0 +1,110 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrDuplicateCoupon = errors.New("coupon code already exists")
+
+type CouponStore interface {
+	CreateCoupon(ctx context.Context, arg db.CreateCouponParams) (db.Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (db.Coupon, error)
+	GetCouponByCodeForUpdate(ctx context.Context, code string) (db.Coupon, error)
+	ListCoupons(ctx context.Context) ([]db.Coupon, error)
+	SetCouponActive(ctx context.Context, id int64, active bool) (db.Coupon, error)
+	CountCouponRedemptionsByUser(ctx context.Context, couponID, userID int64) (int64, error)
+	CreateCouponRedemption(ctx context.Context, arg db.CreateCouponRedemptionParams) error
+	IncrementCouponUsage(ctx context.Context, id int64) error
+	GetCategoryDescendantIDs(ctx context.Context, categoryID int64) ([]int64, error)
+	WithTx(tx pgx.Tx) CouponStore
+}
+
+type sqlCouponStore struct {
+	q *db.Queries
+}
+
+func NewCouponStore(queries *db.Queries) CouponStore {
+	return &sqlCouponStore{
+		q: queries,
+	}
+}
+
+func (s *sqlCouponStore) WithTx(tx pgx.Tx) CouponStore {
+	return &sqlCouponStore{
+		q: db.New(tx),
+	}
+}
+
+func (s *sqlCouponStore) CreateCoupon(ctx context.Context, arg db.CreateCouponParams) (db.Coupon, error) {
+	coupon, err := s.q.CreateCoupon(ctx, arg)
+	if err != nil {
+		if e, ok := pgErr(err); ok && e.Code == "23505" {
+			return db.Coupon{}, ErrDuplicateCoupon
+		}
+		return db.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+func (s *sqlCouponStore) GetCouponByCode(ctx context.Context, code string) (db.Coupon, error) {
+	coupon, err := s.q.GetCouponByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Coupon{}, ErrRecordNotFound
+		}
+		return db.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+func (s *sqlCouponStore) GetCouponByCodeForUpdate(ctx context.Context, code string) (db.Coupon, error) {
+	coupon, err := s.q.GetCouponByCodeForUpdate(ctx, code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Coupon{}, ErrRecordNotFound
+		}
+		return db.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+func (s *sqlCouponStore) ListCoupons(ctx context.Context) ([]db.Coupon, error) {
+	return s.q.ListCoupons(ctx)
+}
+
+func (s *sqlCouponStore) SetCouponActive(ctx context.Context, id int64, active bool) (db.Coupon, error) {
+	coupon, err := s.q.SetCouponActive(ctx, db.SetCouponActiveParams{
+		ID:       id,
+		IsActive: active,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Coupon{}, ErrRecordNotFound
+		}
+		return db.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+func (s *sqlCouponStore) CountCouponRedemptionsByUser(ctx context.Context, couponID, userID int64) (int64, error) {
+	return s.q.CountCouponRedemptionsByUser(ctx, db.CountCouponRedemptionsByUserParams{
+		CouponID: couponID,
+		UserID:   userID,
+	})
+}
+
+func (s *sqlCouponStore) CreateCouponRedemption(ctx context.Context, arg db.CreateCouponRedemptionParams) error {
+	return s.q.CreateCouponRedemption(ctx, arg)
+}
+
+func (s *sqlCouponStore) IncrementCouponUsage(ctx context.Context, id int64) error {
+	return s.q.IncrementCouponUsage(ctx, id)
+}
+
+func (s *sqlCouponStore) GetCategoryDescendantIDs(ctx context.Context, categoryID int64) ([]int64, error) {
+	return s.q.GetCategoryDescendantIDs(ctx, categoryID)
+}
@@ -4,10 +4,17 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// TxBeginner starts transactions. *pgxpool.Pool satisfies it; services that
+// only need transactions take this so they can run without a database.
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 func NewDBPool(dsn string) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
@@ -33,9 +40,31 @@ func NewPGInt32(i int32) pgtype.Int4 {
 		Valid: true,
 	}
 }
+func NewPGInt64(i int64) pgtype.Int8 {
+	return pgtype.Int8{
+		Int64: i,
+		Valid: true,
+	}
+}
+
 func NewPGText(s string) pgtype.Text {
 	return pgtype.Text{
 		String: s,
 		Valid:  true,
 	}
 }
+
+// NewNullPGText stores an empty string as NULL.
+func NewNullPGText(s string) pgtype.Text {
+	return pgtype.Text{
+		String: s,
+		Valid:  s != "",
+	}
+}
+
+func NewPGBool(b bool) pgtype.Bool {
+	return pgtype.Bool{
+		Bool:  b,
+		Valid: true,
+	}
+}
@@ -0,0 +1,180 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: alerts.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createProductAlert = `-- name: CreateProductAlert :exec
+INSERT INTO product_alerts (user_id, product_id, kind)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, product_id, kind) DO NOTHING
+`
+
+type CreateProductAlertParams struct {
+	UserID    int64
+	ProductID int64
+	Kind      string
+}
+
+func (q *Queries) CreateProductAlert(ctx context.Context, arg CreateProductAlertParams) error {
+	_, err := q.db.Exec(ctx, createProductAlert, arg.UserID, arg.ProductID, arg.Kind)
+	return err
+}
+
+const deleteProductAlert = `-- name: DeleteProductAlert :execrows
+DELETE FROM product_alerts
+WHERE user_id = $1 AND product_id = $2 AND kind = $3
+`
+
+type DeleteProductAlertParams struct {
+	UserID    int64
+	ProductID int64
+	Kind      string
+}
+
+func (q *Queries) DeleteProductAlert(ctx context.Context, arg DeleteProductAlertParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteProductAlert, arg.UserID, arg.ProductID, arg.Kind)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAlertMode = `-- name: GetAlertMode :one
+SELECT alert_mode FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetAlertMode(ctx context.Context, id int32) (string, error) {
+	row := q.db.QueryRow(ctx, getAlertMode, id)
+	var alert_mode string
+	err := row.Scan(&alert_mode)
+	return alert_mode, err
+}
+
+const listAlertSubscribers = `-- name: ListAlertSubscribers :many
+SELECT u.id, u.name, u.email, u.alert_mode
+FROM users u
+WHERE u.alert_mode <> 'off'
+  AND (
+    EXISTS (
+        SELECT 1 FROM product_alerts a
+        WHERE a.user_id = u.id AND a.product_id = $1::bigint AND a.kind = $2::text
+    )
+    OR EXISTS (
+        SELECT 1 FROM wishlist_items w
+        WHERE w.user_id = u.id AND w.product_id = $1::bigint
+    )
+  )
+`
+
+type ListAlertSubscribersParams struct {
+	ProductID int64
+	Kind      string
+}
+
+type ListAlertSubscribersRow struct {
+	ID        int32
+	Name      string
+	Email     string
+	AlertMode string
+}
+
+// Wishlisting a product implies subscribing to every alert for it.
+func (q *Queries) ListAlertSubscribers(ctx context.Context, arg ListAlertSubscribersParams) ([]ListAlertSubscribersRow, error) {
+	rows, err := q.db.Query(ctx, listAlertSubscribers, arg.ProductID, arg.Kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAlertSubscribersRow
+	for rows.Next() {
+		var i ListAlertSubscribersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.AlertMode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProductAlertsForUser = `-- name: ListProductAlertsForUser :many
+SELECT
+    a.product_id,
+    a.kind,
+    a.created_at,
+    p.name,
+    p.price,
+    p.stock
+FROM product_alerts a
+JOIN products p ON p.id = a.product_id
+WHERE a.user_id = $1
+ORDER BY a.created_at DESC
+`
+
+type ListProductAlertsForUserRow struct {
+	ProductID int64
+	Kind      string
+	CreatedAt pgtype.Timestamptz
+	Name      string
+	Price     int32
+	Stock     int32
+}
+
+func (q *Queries) ListProductAlertsForUser(ctx context.Context, userID int64) ([]ListProductAlertsForUserRow, error) {
+	rows, err := q.db.Query(ctx, listProductAlertsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProductAlertsForUserRow
+	for rows.Next() {
+		var i ListProductAlertsForUserRow
+		if err := rows.Scan(
+			&i.ProductID,
+			&i.Kind,
+			&i.CreatedAt,
+			&i.Name,
+			&i.Price,
+			&i.Stock,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setAlertMode = `-- name: SetAlertMode :exec
+UPDATE users
+SET alert_mode = $2
+WHERE id = $1
+`
+
+type SetAlertModeParams struct {
+	ID        int32
+	AlertMode string
+}
+
+func (q *Queries) SetAlertMode(ctx context.Context, arg SetAlertModeParams) error {
+	_, err := q.db.Exec(ctx, setAlertMode, arg.ID, arg.AlertMode)
+	return err
+}
@@ -0,0 +1,118 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: cart.sql
+
+package db
+
+import (
+	"context"
+)
+
+const addCartItem = `-- name: AddCartItem :exec
+INSERT INTO cart_items (user_id, product_id, quantity, price_at_add)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, product_id) DO UPDATE
+SET quantity = cart_items.quantity + EXCLUDED.quantity,
+    price_at_add = EXCLUDED.price_at_add
+`
+
+type AddCartItemParams struct {
+	UserID     int64
+	ProductID  int64
+	Quantity   int32
+	PriceAtAdd int32
+}
+
+func (q *Queries) AddCartItem(ctx context.Context, arg AddCartItemParams) error {
+	_, err := q.db.Exec(ctx, addCartItem,
+		arg.UserID,
+		arg.ProductID,
+		arg.Quantity,
+		arg.PriceAtAdd,
+	)
+	return err
+}
+
+const clearCart = `-- name: ClearCart :exec
+DELETE FROM cart_items
+WHERE user_id = $1
+`
+
+func (q *Queries) ClearCart(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, clearCart, userID)
+	return err
+}
+
+const deleteCartItem = `-- name: DeleteCartItem :exec
+DELETE FROM cart_items
+WHERE user_id = $1 AND product_id = $2
+`
+
+type DeleteCartItemParams struct {
+	UserID    int64
+	ProductID int64
+}
+
+func (q *Queries) DeleteCartItem(ctx context.Context, arg DeleteCartItemParams) error {
+	_, err := q.db.Exec(ctx, deleteCartItem, arg.UserID, arg.ProductID)
+	return err
+}
+
+const getCartItems = `-- name: GetCartItems :many
+SELECT product_id, quantity, price_at_add
+FROM cart_items
+WHERE user_id = $1
+ORDER BY created_at ASC
+`
+
+type GetCartItemsRow struct {
+	ProductID  int64
+	Quantity   int32
+	PriceAtAdd int32
+}
+
+func (q *Queries) GetCartItems(ctx context.Context, userID int64) ([]GetCartItemsRow, error) {
+	rows, err := q.db.Query(ctx, getCartItems, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCartItemsRow
+	for rows.Next() {
+		var i GetCartItemsRow
+		if err := rows.Scan(&i.ProductID, &i.Quantity, &i.PriceAtAdd); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setCartItem = `-- name: SetCartItem :exec
+INSERT INTO cart_items (user_id, product_id, quantity, price_at_add)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, product_id) DO UPDATE
+SET quantity = EXCLUDED.quantity,
+    price_at_add = EXCLUDED.price_at_add
+`
+
+type SetCartItemParams struct {
+	UserID     int64
+	ProductID  int64
+	Quantity   int32
+	PriceAtAdd int32
+}
+
+func (q *Queries) SetCartItem(ctx context.Context, arg SetCartItemParams) error {
+	_, err := q.db.Exec(ctx, setCartItem,
+		arg.UserID,
+		arg.ProductID,
+		arg.Quantity,
+		arg.PriceAtAdd,
+	)
+	return err
+}
@@ -0,0 +1,117 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: categories.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getCategoryBySlugOrName = `-- name: GetCategoryBySlugOrName :one
+SELECT id, parent_id, name, slug, icon, display_order, created_at, commission_bps FROM categories
+WHERE slug = lower($1::text) OR lower(name) = lower($1::text)
+ORDER BY slug = lower($1::text) DESC, parent_id NULLS FIRST, id ASC
+LIMIT 1
+`
+
+func (q *Queries) GetCategoryBySlugOrName(ctx context.Context, term string) (Category, error) {
+	row := q.db.QueryRow(ctx, getCategoryBySlugOrName, term)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.ParentID,
+		&i.Name,
+		&i.Slug,
+		&i.Icon,
+		&i.DisplayOrder,
+		&i.CreatedAt,
+		&i.CommissionBps,
+	)
+	return i, err
+}
+
+const getCategoryDescendantIDs = `-- name: GetCategoryDescendantIDs :many
+WITH RECURSIVE tree AS (
+    SELECT c.id FROM categories c WHERE c.id = $1
+    UNION ALL
+    SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+SELECT id FROM tree
+`
+
+func (q *Queries) GetCategoryDescendantIDs(ctx context.Context, id int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, getCategoryDescendantIDs, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoriesWithCounts = `-- name: ListCategoriesWithCounts :many
+SELECT
+    c.id,
+    c.parent_id,
+    c.name,
+    c.slug,
+    c.icon,
+    c.display_order,
+    COUNT(p.id) FILTER (WHERE p.is_active) AS product_count
+FROM categories c
+LEFT JOIN products p ON p.category_id = c.id
+GROUP BY c.id
+ORDER BY c.display_order ASC, c.name ASC
+`
+
+type ListCategoriesWithCountsRow struct {
+	ID           int64
+	ParentID     pgtype.Int8
+	Name         string
+	Slug         string
+	Icon         pgtype.Text
+	DisplayOrder int32
+	ProductCount int64
+}
+
+func (q *Queries) ListCategoriesWithCounts(ctx context.Context) ([]ListCategoriesWithCountsRow, error) {
+	rows, err := q.db.Query(ctx, listCategoriesWithCounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCategoriesWithCountsRow
+	for rows.Next() {
+		var i ListCategoriesWithCountsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.Name,
+			&i.Slug,
+			&i.Icon,
+			&i.DisplayOrder,
+			&i.ProductCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,181 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: commission.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const listCategoryCommissionRates = `-- name: ListCategoryCommissionRates :many
+WITH RECURSIVE tree (id, commission_bps) AS (
+    SELECT c.id, c.commission_bps FROM categories c WHERE c.parent_id IS NULL
+    UNION ALL
+    SELECT c.id, COALESCE(c.commission_bps, t.commission_bps)
+    FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+SELECT id, commission_bps FROM tree
+`
+
+type ListCategoryCommissionRatesRow struct {
+	ID            int64
+	CommissionBps pgtype.Int4
+}
+
+func (q *Queries) ListCategoryCommissionRates(ctx context.Context) ([]ListCategoryCommissionRatesRow, error) {
+	rows, err := q.db.Query(ctx, listCategoryCommissionRates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCategoryCommissionRatesRow
+	for rows.Next() {
+		var i ListCategoryCommissionRatesRow
+		if err := rows.Scan(&i.ID, &i.CommissionBps); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSalesBySeller = `-- name: ListSalesBySeller :many
+SELECT
+    oi.id,
+    oi.order_id,
+    oi.sub_order_id,
+    oi.product_id,
+    p.name AS product_name,
+    oi.quantity,
+    oi.price_at_purchase,
+    oi.commission_bps,
+    oi.commission_amount,
+    oi.seller_payout,
+    so.status,
+    oi.created_at
+FROM order_items oi
+JOIN sub_orders so ON so.id = oi.sub_order_id
+JOIN products p ON p.id = oi.product_id
+WHERE oi.seller_id = $1
+ORDER BY oi.created_at DESC, oi.id DESC
+`
+
+type ListSalesBySellerRow struct {
+	ID               int64
+	OrderID          int64
+	SubOrderID       pgtype.Int8
+	ProductID        int64
+	ProductName      string
+	Quantity         int32
+	PriceAtPurchase  int32
+	CommissionBps    int32
+	CommissionAmount int64
+	SellerPayout     int64
+	Status           string
+	CreatedAt        pgtype.Timestamptz
+}
+
+func (q *Queries) ListSalesBySeller(ctx context.Context, sellerID int64) ([]ListSalesBySellerRow, error) {
+	rows, err := q.db.Query(ctx, listSalesBySeller, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSalesBySellerRow
+	for rows.Next() {
+		var i ListSalesBySellerRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.SubOrderID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.Quantity,
+			&i.PriceAtPurchase,
+			&i.CommissionBps,
+			&i.CommissionAmount,
+			&i.SellerPayout,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVerifiedClubs = `-- name: ListVerifiedClubs :many
+SELECT id FROM users
+WHERE id = ANY($1::int[])
+  AND user_type = 'club'
+`
+
+func (q *Queries) ListVerifiedClubs(ctx context.Context, ids []int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listVerifiedClubs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setCategoryCommission = `-- name: SetCategoryCommission :execrows
+UPDATE categories
+SET commission_bps = $1
+WHERE id = $2
+`
+
+type SetCategoryCommissionParams struct {
+	CommissionBps pgtype.Int4
+	ID            int64
+}
+
+func (q *Queries) SetCategoryCommission(ctx context.Context, arg SetCategoryCommissionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setCategoryCommission, arg.CommissionBps, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setClubVerified = `-- name: SetClubVerified :execrows
+UPDATE users
+SET user_type = CASE WHEN $1::boolean THEN 'club' ELSE 'customer' END
+WHERE id = $2
+  AND user_type IN ('customer', 'club')
+`
+
+type SetClubVerifiedParams struct {
+	Verified bool
+	ID       int32
+}
+
+func (q *Queries) SetClubVerified(ctx context.Context, arg SetClubVerifiedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setClubVerified, arg.Verified, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
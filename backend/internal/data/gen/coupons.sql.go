@@ -0,0 +1,278 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: coupons.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countCouponRedemptionsByUser = `-- name: CountCouponRedemptionsByUser :one
+SELECT COUNT(*) FROM coupon_redemptions
+WHERE coupon_id = $1 AND user_id = $2
+`
+
+type CountCouponRedemptionsByUserParams struct {
+	CouponID int64
+	UserID   int64
+}
+
+func (q *Queries) CountCouponRedemptionsByUser(ctx context.Context, arg CountCouponRedemptionsByUserParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countCouponRedemptionsByUser, arg.CouponID, arg.UserID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createCoupon = `-- name: CreateCoupon :one
+INSERT INTO coupons (
+    code,
+    description,
+    discount_type,
+    discount_value,
+    max_discount,
+    min_cart_value,
+    usage_limit,
+    per_user_limit,
+    category_id,
+    seller_id,
+    starts_at,
+    ends_at,
+    created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) RETURNING id, code, description, discount_type, discount_value, max_discount, min_cart_value, usage_limit, per_user_limit, times_used, category_id, seller_id, starts_at, ends_at, is_active, created_by, created_at
+`
+
+type CreateCouponParams struct {
+	Code          string
+	Description   string
+	DiscountType  string
+	DiscountValue int32
+	MaxDiscount   pgtype.Int4
+	MinCartValue  int32
+	UsageLimit    pgtype.Int4
+	PerUserLimit  int32
+	CategoryID    pgtype.Int8
+	SellerID      pgtype.Int8
+	StartsAt      pgtype.Timestamptz
+	EndsAt        pgtype.Timestamptz
+	CreatedBy     pgtype.Int8
+}
+
+func (q *Queries) CreateCoupon(ctx context.Context, arg CreateCouponParams) (Coupon, error) {
+	row := q.db.QueryRow(ctx, createCoupon,
+		arg.Code,
+		arg.Description,
+		arg.DiscountType,
+		arg.DiscountValue,
+		arg.MaxDiscount,
+		arg.MinCartValue,
+		arg.UsageLimit,
+		arg.PerUserLimit,
+		arg.CategoryID,
+		arg.SellerID,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.CreatedBy,
+	)
+	var i Coupon
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Description,
+		&i.DiscountType,
+		&i.DiscountValue,
+		&i.MaxDiscount,
+		&i.MinCartValue,
+		&i.UsageLimit,
+		&i.PerUserLimit,
+		&i.TimesUsed,
+		&i.CategoryID,
+		&i.SellerID,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.IsActive,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createCouponRedemption = `-- name: CreateCouponRedemption :exec
+INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateCouponRedemptionParams struct {
+	CouponID       int64
+	UserID         int64
+	OrderID        int64
+	DiscountAmount int64
+}
+
+func (q *Queries) CreateCouponRedemption(ctx context.Context, arg CreateCouponRedemptionParams) error {
+	_, err := q.db.Exec(ctx, createCouponRedemption,
+		arg.CouponID,
+		arg.UserID,
+		arg.OrderID,
+		arg.DiscountAmount,
+	)
+	return err
+}
+
+const getCouponByCode = `-- name: GetCouponByCode :one
+SELECT id, code, description, discount_type, discount_value, max_discount, min_cart_value, usage_limit, per_user_limit, times_used, category_id, seller_id, starts_at, ends_at, is_active, created_by, created_at FROM coupons
+WHERE code = $1
+`
+
+func (q *Queries) GetCouponByCode(ctx context.Context, code string) (Coupon, error) {
+	row := q.db.QueryRow(ctx, getCouponByCode, code)
+	var i Coupon
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Description,
+		&i.DiscountType,
+		&i.DiscountValue,
+		&i.MaxDiscount,
+		&i.MinCartValue,
+		&i.UsageLimit,
+		&i.PerUserLimit,
+		&i.TimesUsed,
+		&i.CategoryID,
+		&i.SellerID,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.IsActive,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCouponByCodeForUpdate = `-- name: GetCouponByCodeForUpdate :one
+SELECT id, code, description, discount_type, discount_value, max_discount, min_cart_value, usage_limit, per_user_limit, times_used, category_id, seller_id, starts_at, ends_at, is_active, created_by, created_at FROM coupons
+WHERE code = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetCouponByCodeForUpdate(ctx context.Context, code string) (Coupon, error) {
+	row := q.db.QueryRow(ctx, getCouponByCodeForUpdate, code)
+	var i Coupon
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Description,
+		&i.DiscountType,
+		&i.DiscountValue,
+		&i.MaxDiscount,
+		&i.MinCartValue,
+		&i.UsageLimit,
+		&i.PerUserLimit,
+		&i.TimesUsed,
+		&i.CategoryID,
+		&i.SellerID,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.IsActive,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const incrementCouponUsage = `-- name: IncrementCouponUsage :exec
+UPDATE coupons
+SET times_used = times_used + 1
+WHERE id = $1
+`
+
+func (q *Queries) IncrementCouponUsage(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, incrementCouponUsage, id)
+	return err
+}
+
+const listCoupons = `-- name: ListCoupons :many
+SELECT id, code, description, discount_type, discount_value, max_discount, min_cart_value, usage_limit, per_user_limit, times_used, category_id, seller_id, starts_at, ends_at, is_active, created_by, created_at FROM coupons
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListCoupons(ctx context.Context) ([]Coupon, error) {
+	rows, err := q.db.Query(ctx, listCoupons)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Coupon
+	for rows.Next() {
+		var i Coupon
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Description,
+			&i.DiscountType,
+			&i.DiscountValue,
+			&i.MaxDiscount,
+			&i.MinCartValue,
+			&i.UsageLimit,
+			&i.PerUserLimit,
+			&i.TimesUsed,
+			&i.CategoryID,
+			&i.SellerID,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.IsActive,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setCouponActive = `-- name: SetCouponActive :one
+UPDATE coupons
+SET is_active = $2
+WHERE id = $1
+RETURNING id, code, description, discount_type, discount_value, max_discount, min_cart_value, usage_limit, per_user_limit, times_used, category_id, seller_id, starts_at, ends_at, is_active, created_by, created_at
+`
+
+type SetCouponActiveParams struct {
+	ID       int64
+	IsActive bool
+}
+
+func (q *Queries) SetCouponActive(ctx context.Context, arg SetCouponActiveParams) (Coupon, error) {
+	row := q.db.QueryRow(ctx, setCouponActive, arg.ID, arg.IsActive)
+	var i Coupon
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Description,
+		&i.DiscountType,
+		&i.DiscountValue,
+		&i.MaxDiscount,
+		&i.MinCartValue,
+		&i.UsageLimit,
+		&i.PerUserLimit,
+		&i.TimesUsed,
+		&i.CategoryID,
+		&i.SellerID,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.IsActive,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,271 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: handover.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimHandoverAttempt = `-- name: ClaimHandoverAttempt :one
+UPDATE handovers
+SET failed_attempts = failed_attempts + 1
+WHERE sub_order_id = $1
+  AND failed_attempts < $2
+  AND code_hash IS NOT NULL
+  AND handed_over_at IS NULL
+RETURNING sub_order_id, slot_id, code_hash, code_expires_at, failed_attempts, handed_over_at, created_at
+`
+
+type ClaimHandoverAttemptParams struct {
+	SubOrderID     int64
+	FailedAttempts int32
+}
+
+func (q *Queries) ClaimHandoverAttempt(ctx context.Context, arg ClaimHandoverAttemptParams) (Handover, error) {
+	row := q.db.QueryRow(ctx, claimHandoverAttempt, arg.SubOrderID, arg.FailedAttempts)
+	var i Handover
+	err := row.Scan(
+		&i.SubOrderID,
+		&i.SlotID,
+		&i.CodeHash,
+		&i.CodeExpiresAt,
+		&i.FailedAttempts,
+		&i.HandedOverAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const completeHandover = `-- name: CompleteHandover :execrows
+UPDATE handovers
+SET handed_over_at = NOW(), code_hash = NULL, failed_attempts = failed_attempts - 1
+WHERE sub_order_id = $1
+  AND code_hash = $2
+  AND handed_over_at IS NULL
+`
+
+type CompleteHandoverParams struct {
+	SubOrderID int64
+	CodeHash   pgtype.Text
+}
+
+func (q *Queries) CompleteHandover(ctx context.Context, arg CompleteHandoverParams) (int64, error) {
+	result, err := q.db.Exec(ctx, completeHandover, arg.SubOrderID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createPickupSlot = `-- name: CreatePickupSlot :one
+INSERT INTO pickup_slots (sub_order_id, location_id, starts_at, ends_at)
+SELECT $1, l.id, $2, $3
+FROM campus_locations l
+WHERE l.id = $4 AND l.is_active
+RETURNING id, sub_order_id, location_id, starts_at, ends_at, created_at
+`
+
+type CreatePickupSlotParams struct {
+	SubOrderID int64
+	StartsAt   pgtype.Timestamptz
+	EndsAt     pgtype.Timestamptz
+	LocationID int64
+}
+
+func (q *Queries) CreatePickupSlot(ctx context.Context, arg CreatePickupSlotParams) (PickupSlot, error) {
+	row := q.db.QueryRow(ctx, createPickupSlot,
+		arg.SubOrderID,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.LocationID,
+	)
+	var i PickupSlot
+	err := row.Scan(
+		&i.ID,
+		&i.SubOrderID,
+		&i.LocationID,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deletePickupSlots = `-- name: DeletePickupSlots :exec
+DELETE FROM pickup_slots
+WHERE sub_order_id = $1
+`
+
+func (q *Queries) DeletePickupSlots(ctx context.Context, subOrderID int64) error {
+	_, err := q.db.Exec(ctx, deletePickupSlots, subOrderID)
+	return err
+}
+
+const getHandover = `-- name: GetHandover :one
+SELECT sub_order_id, slot_id, code_hash, code_expires_at, failed_attempts, handed_over_at, created_at FROM handovers
+WHERE sub_order_id = $1
+`
+
+func (q *Queries) GetHandover(ctx context.Context, subOrderID int64) (Handover, error) {
+	row := q.db.QueryRow(ctx, getHandover, subOrderID)
+	var i Handover
+	err := row.Scan(
+		&i.SubOrderID,
+		&i.SlotID,
+		&i.CodeHash,
+		&i.CodeExpiresAt,
+		&i.FailedAttempts,
+		&i.HandedOverAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPickupSlot = `-- name: GetPickupSlot :one
+SELECT id, sub_order_id, location_id, starts_at, ends_at, created_at FROM pickup_slots
+WHERE id = $1 AND sub_order_id = $2
+`
+
+type GetPickupSlotParams struct {
+	ID         int64
+	SubOrderID int64
+}
+
+func (q *Queries) GetPickupSlot(ctx context.Context, arg GetPickupSlotParams) (PickupSlot, error) {
+	row := q.db.QueryRow(ctx, getPickupSlot, arg.ID, arg.SubOrderID)
+	var i PickupSlot
+	err := row.Scan(
+		&i.ID,
+		&i.SubOrderID,
+		&i.LocationID,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listCampusLocations = `-- name: ListCampusLocations :many
+SELECT id, name, display_order, is_active FROM campus_locations
+WHERE is_active
+ORDER BY display_order ASC, name ASC
+`
+
+func (q *Queries) ListCampusLocations(ctx context.Context) ([]CampusLocation, error) {
+	rows, err := q.db.Query(ctx, listCampusLocations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CampusLocation
+	for rows.Next() {
+		var i CampusLocation
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.DisplayOrder,
+			&i.IsActive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPickupSlots = `-- name: ListPickupSlots :many
+SELECT
+    ps.id,
+    ps.sub_order_id,
+    ps.location_id,
+    l.name AS location,
+    ps.starts_at,
+    ps.ends_at
+FROM pickup_slots ps
+JOIN campus_locations l ON l.id = ps.location_id
+WHERE ps.sub_order_id = $1
+ORDER BY ps.starts_at ASC
+`
+
+type ListPickupSlotsRow struct {
+	ID         int64
+	SubOrderID int64
+	LocationID int64
+	Location   string
+	StartsAt   pgtype.Timestamptz
+	EndsAt     pgtype.Timestamptz
+}
+
+func (q *Queries) ListPickupSlots(ctx context.Context, subOrderID int64) ([]ListPickupSlotsRow, error) {
+	rows, err := q.db.Query(ctx, listPickupSlots, subOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPickupSlotsRow
+	for rows.Next() {
+		var i ListPickupSlotsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubOrderID,
+			&i.LocationID,
+			&i.Location,
+			&i.StartsAt,
+			&i.EndsAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertHandover = `-- name: UpsertHandover :one
+INSERT INTO handovers (sub_order_id, slot_id, code_hash, code_expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (sub_order_id) DO UPDATE
+SET slot_id = EXCLUDED.slot_id,
+    code_hash = EXCLUDED.code_hash,
+    code_expires_at = EXCLUDED.code_expires_at,
+    failed_attempts = 0
+WHERE handovers.handed_over_at IS NULL
+RETURNING sub_order_id, slot_id, code_hash, code_expires_at, failed_attempts, handed_over_at, created_at
+`
+
+type UpsertHandoverParams struct {
+	SubOrderID    int64
+	SlotID        int64
+	CodeHash      pgtype.Text
+	CodeExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) UpsertHandover(ctx context.Context, arg UpsertHandoverParams) (Handover, error) {
+	row := q.db.QueryRow(ctx, upsertHandover,
+		arg.SubOrderID,
+		arg.SlotID,
+		arg.CodeHash,
+		arg.CodeExpiresAt,
+	)
+	var i Handover
+	err := row.Scan(
+		&i.SubOrderID,
+		&i.SlotID,
+		&i.CodeHash,
+		&i.CodeExpiresAt,
+		&i.FailedAttempts,
+		&i.HandedOverAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,496 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: messages.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const blockUser = `-- name: BlockUser :exec
+INSERT INTO user_blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type BlockUserParams struct {
+	BlockerID int64
+	BlockedID int64
+}
+
+func (q *Queries) BlockUser(ctx context.Context, arg BlockUserParams) error {
+	_, err := q.db.Exec(ctx, blockUser, arg.BlockerID, arg.BlockedID)
+	return err
+}
+
+const countUnreadMessages = `-- name: CountUnreadMessages :one
+SELECT COUNT(*)::bigint
+FROM messages m
+JOIN conversations c ON c.id = m.conversation_id
+WHERE (c.buyer_id = $1 OR c.seller_id = $1)
+  AND m.sender_id <> $1
+  AND m.id > (CASE WHEN c.buyer_id = $1 THEN c.buyer_last_read_id ELSE c.seller_last_read_id END)
+`
+
+func (q *Queries) CountUnreadMessages(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countUnreadMessages, userID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const createMessage = `-- name: CreateMessage :one
+INSERT INTO messages (conversation_id, sender_id, body)
+VALUES ($1, $2, $3)
+RETURNING id, conversation_id, sender_id, body, created_at
+`
+
+type CreateMessageParams struct {
+	ConversationID int64
+	SenderID       int64
+	Body           string
+}
+
+func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
+	row := q.db.QueryRow(ctx, createMessage, arg.ConversationID, arg.SenderID, arg.Body)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ConversationID,
+		&i.SenderID,
+		&i.Body,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createMessageReport = `-- name: CreateMessageReport :one
+INSERT INTO message_reports (message_id, reporter_id, reason, details)
+VALUES ($1, $2, $3, $4)
+RETURNING id, message_id, reporter_id, reason, details, created_at
+`
+
+type CreateMessageReportParams struct {
+	MessageID  int64
+	ReporterID int64
+	Reason     string
+	Details    string
+}
+
+func (q *Queries) CreateMessageReport(ctx context.Context, arg CreateMessageReportParams) (MessageReport, error) {
+	row := q.db.QueryRow(ctx, createMessageReport,
+		arg.MessageID,
+		arg.ReporterID,
+		arg.Reason,
+		arg.Details,
+	)
+	var i MessageReport
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.ReporterID,
+		&i.Reason,
+		&i.Details,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getConversation = `-- name: GetConversation :one
+SELECT id, product_id, sub_order_id, buyer_id, seller_id, buyer_last_read_id, seller_last_read_id, last_message_at, created_at FROM conversations
+WHERE id = $1
+`
+
+func (q *Queries) GetConversation(ctx context.Context, id int64) (Conversation, error) {
+	row := q.db.QueryRow(ctx, getConversation, id)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.SubOrderID,
+		&i.BuyerID,
+		&i.SellerID,
+		&i.BuyerLastReadID,
+		&i.SellerLastReadID,
+		&i.LastMessageAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getMessage = `-- name: GetMessage :one
+SELECT id, conversation_id, sender_id, body, created_at FROM messages
+WHERE id = $1
+`
+
+func (q *Queries) GetMessage(ctx context.Context, id int64) (Message, error) {
+	row := q.db.QueryRow(ctx, getMessage, id)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ConversationID,
+		&i.SenderID,
+		&i.Body,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const isBlockedBetween = `-- name: IsBlockedBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = $1 AND blocked_id = $2)
+       OR (blocker_id = $2 AND blocked_id = $1)
+)
+`
+
+type IsBlockedBetweenParams struct {
+	UserA int64
+	UserB int64
+}
+
+func (q *Queries) IsBlockedBetween(ctx context.Context, arg IsBlockedBetweenParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isBlockedBetween, arg.UserA, arg.UserB)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listBlockedUsers = `-- name: ListBlockedUsers :many
+SELECT ub.blocked_id, u.name, ub.created_at
+FROM user_blocks ub
+JOIN users u ON u.id = ub.blocked_id
+WHERE ub.blocker_id = $1
+ORDER BY ub.created_at DESC
+`
+
+type ListBlockedUsersRow struct {
+	BlockedID int64
+	Name      string
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListBlockedUsers(ctx context.Context, blockerID int64) ([]ListBlockedUsersRow, error) {
+	rows, err := q.db.Query(ctx, listBlockedUsers, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBlockedUsersRow
+	for rows.Next() {
+		var i ListBlockedUsersRow
+		if err := rows.Scan(&i.BlockedID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listConversationsForUser = `-- name: ListConversationsForUser :many
+SELECT
+    c.id,
+    c.product_id,
+    c.sub_order_id,
+    c.buyer_id,
+    c.seller_id,
+    (CASE WHEN c.buyer_id = $1 THEN s.name ELSE b.name END)::text AS counterpart_name,
+    COALESCE(p.name, '')::text AS product_name,
+    COALESCE((
+        SELECT m.body FROM messages m
+        WHERE m.conversation_id = c.id
+        ORDER BY m.id DESC
+        LIMIT 1
+    ), '')::text AS last_message,
+    c.last_message_at,
+    (
+        SELECT COUNT(*) FROM messages m
+        WHERE m.conversation_id = c.id
+          AND m.sender_id <> $1
+          AND m.id > (CASE WHEN c.buyer_id = $1 THEN c.buyer_last_read_id ELSE c.seller_last_read_id END)
+    )::bigint AS unread_count,
+    c.created_at
+FROM conversations c
+JOIN users b ON b.id = c.buyer_id
+JOIN users s ON s.id = c.seller_id
+LEFT JOIN products p ON p.id = c.product_id
+WHERE c.buyer_id = $1 OR c.seller_id = $1
+ORDER BY COALESCE(c.last_message_at, c.created_at) DESC
+`
+
+type ListConversationsForUserRow struct {
+	ID              int64
+	ProductID       pgtype.Int8
+	SubOrderID      pgtype.Int8
+	BuyerID         int64
+	SellerID        int64
+	CounterpartName string
+	ProductName     string
+	LastMessage     string
+	LastMessageAt   pgtype.Timestamptz
+	UnreadCount     int64
+	CreatedAt       pgtype.Timestamptz
+}
+
+func (q *Queries) ListConversationsForUser(ctx context.Context, userID int64) ([]ListConversationsForUserRow, error) {
+	rows, err := q.db.Query(ctx, listConversationsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListConversationsForUserRow
+	for rows.Next() {
+		var i ListConversationsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.SubOrderID,
+			&i.BuyerID,
+			&i.SellerID,
+			&i.CounterpartName,
+			&i.ProductName,
+			&i.LastMessage,
+			&i.LastMessageAt,
+			&i.UnreadCount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMessageReports = `-- name: ListMessageReports :many
+SELECT
+    r.id,
+    r.message_id,
+    r.reason,
+    r.details,
+    r.created_at,
+    r.reporter_id,
+    m.sender_id,
+    m.conversation_id,
+    m.body,
+    m.created_at AS sent_at
+FROM message_reports r
+JOIN messages m ON m.id = r.message_id
+ORDER BY r.created_at DESC
+`
+
+type ListMessageReportsRow struct {
+	ID             int64
+	MessageID      int64
+	Reason         string
+	Details        string
+	CreatedAt      pgtype.Timestamptz
+	ReporterID     int64
+	SenderID       int64
+	ConversationID int64
+	Body           string
+	SentAt         pgtype.Timestamptz
+}
+
+func (q *Queries) ListMessageReports(ctx context.Context) ([]ListMessageReportsRow, error) {
+	rows, err := q.db.Query(ctx, listMessageReports)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMessageReportsRow
+	for rows.Next() {
+		var i ListMessageReportsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.Reason,
+			&i.Details,
+			&i.CreatedAt,
+			&i.ReporterID,
+			&i.SenderID,
+			&i.ConversationID,
+			&i.Body,
+			&i.SentAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMessages = `-- name: ListMessages :many
+SELECT id, conversation_id, sender_id, body, created_at FROM messages
+WHERE conversation_id = $1
+  AND ($2::bigint IS NULL OR id < $2)
+ORDER BY id DESC
+LIMIT $3
+`
+
+type ListMessagesParams struct {
+	ConversationID int64
+	BeforeID       pgtype.Int8
+	MaxRows        int32
+}
+
+func (q *Queries) ListMessages(ctx context.Context, arg ListMessagesParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, listMessages, arg.ConversationID, arg.BeforeID, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ConversationID,
+			&i.SenderID,
+			&i.Body,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markConversationRead = `-- name: MarkConversationRead :exec
+UPDATE conversations c
+SET buyer_last_read_id = CASE WHEN c.buyer_id = $1 THEN latest.id ELSE c.buyer_last_read_id END,
+    seller_last_read_id = CASE WHEN c.seller_id = $1 THEN latest.id ELSE c.seller_last_read_id END
+FROM (
+    SELECT COALESCE(MAX(m.id), 0)::bigint AS id
+    FROM messages m
+    WHERE m.conversation_id = $2
+) latest
+WHERE c.id = $2
+`
+
+type MarkConversationReadParams struct {
+	UserID int64
+	ID     int64
+}
+
+func (q *Queries) MarkConversationRead(ctx context.Context, arg MarkConversationReadParams) error {
+	_, err := q.db.Exec(ctx, markConversationRead, arg.UserID, arg.ID)
+	return err
+}
+
+const touchConversation = `-- name: TouchConversation :exec
+UPDATE conversations
+SET last_message_at = NOW(),
+    buyer_last_read_id = CASE WHEN buyer_id = $1 THEN $2 ELSE buyer_last_read_id END,
+    seller_last_read_id = CASE WHEN seller_id = $1 THEN $2 ELSE seller_last_read_id END
+WHERE id = $3
+`
+
+type TouchConversationParams struct {
+	SenderID  int64
+	MessageID int64
+	ID        int64
+}
+
+// Sending a message also marks the conversation read up to it for the
+// sender.
+func (q *Queries) TouchConversation(ctx context.Context, arg TouchConversationParams) error {
+	_, err := q.db.Exec(ctx, touchConversation, arg.SenderID, arg.MessageID, arg.ID)
+	return err
+}
+
+const unblockUser = `-- name: UnblockUser :execrows
+DELETE FROM user_blocks
+WHERE blocker_id = $1 AND blocked_id = $2
+`
+
+type UnblockUserParams struct {
+	BlockerID int64
+	BlockedID int64
+}
+
+func (q *Queries) UnblockUser(ctx context.Context, arg UnblockUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, unblockUser, arg.BlockerID, arg.BlockedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const upsertProductConversation = `-- name: UpsertProductConversation :one
+INSERT INTO conversations (product_id, buyer_id, seller_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (product_id, buyer_id) WHERE product_id IS NOT NULL
+DO UPDATE SET seller_id = EXCLUDED.seller_id
+RETURNING id, product_id, sub_order_id, buyer_id, seller_id, buyer_last_read_id, seller_last_read_id, last_message_at, created_at
+`
+
+type UpsertProductConversationParams struct {
+	ProductID pgtype.Int8
+	BuyerID   int64
+	SellerID  int64
+}
+
+func (q *Queries) UpsertProductConversation(ctx context.Context, arg UpsertProductConversationParams) (Conversation, error) {
+	row := q.db.QueryRow(ctx, upsertProductConversation, arg.ProductID, arg.BuyerID, arg.SellerID)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.SubOrderID,
+		&i.BuyerID,
+		&i.SellerID,
+		&i.BuyerLastReadID,
+		&i.SellerLastReadID,
+		&i.LastMessageAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertSubOrderConversation = `-- name: UpsertSubOrderConversation :one
+INSERT INTO conversations (sub_order_id, buyer_id, seller_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (sub_order_id) WHERE sub_order_id IS NOT NULL
+DO UPDATE SET seller_id = EXCLUDED.seller_id
+RETURNING id, product_id, sub_order_id, buyer_id, seller_id, buyer_last_read_id, seller_last_read_id, last_message_at, created_at
+`
+
+type UpsertSubOrderConversationParams struct {
+	SubOrderID pgtype.Int8
+	BuyerID    int64
+	SellerID   int64
+}
+
+func (q *Queries) UpsertSubOrderConversation(ctx context.Context, arg UpsertSubOrderConversationParams) (Conversation, error) {
+	row := q.db.QueryRow(ctx, upsertSubOrderConversation, arg.SubOrderID, arg.BuyerID, arg.SellerID)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.SubOrderID,
+		&i.BuyerID,
+		&i.SellerID,
+		&i.BuyerLastReadID,
+		&i.SellerLastReadID,
+		&i.LastMessageAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -8,47 +8,240 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type BannedTerm struct {
+	ID        int64
+	Pattern   string
+	IsRegex   bool
+	CreatedBy pgtype.Int8
+	CreatedAt pgtype.Timestamptz
+}
+
+type CampusLocation struct {
+	ID           int64
+	Name         string
+	DisplayOrder int32
+	IsActive     bool
+}
+
+type CartItem struct {
+	UserID     int64
+	ProductID  int64
+	Quantity   int32
+	PriceAtAdd int32
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+}
+
+type Category struct {
+	ID            int64
+	ParentID      pgtype.Int8
+	Name          string
+	Slug          string
+	Icon          pgtype.Text
+	DisplayOrder  int32
+	CreatedAt     pgtype.Timestamptz
+	CommissionBps pgtype.Int4
+}
+
+type Conversation struct {
+	ID               int64
+	ProductID        pgtype.Int8
+	SubOrderID       pgtype.Int8
+	BuyerID          int64
+	SellerID         int64
+	BuyerLastReadID  int64
+	SellerLastReadID int64
+	LastMessageAt    pgtype.Timestamptz
+	CreatedAt        pgtype.Timestamptz
+}
+
+type Coupon struct {
+	ID            int64
+	Code          string
+	Description   string
+	DiscountType  string
+	DiscountValue int32
+	MaxDiscount   pgtype.Int4
+	MinCartValue  int32
+	UsageLimit    pgtype.Int4
+	PerUserLimit  int32
+	TimesUsed     int32
+	CategoryID    pgtype.Int8
+	SellerID      pgtype.Int8
+	StartsAt      pgtype.Timestamptz
+	EndsAt        pgtype.Timestamptz
+	IsActive      bool
+	CreatedBy     pgtype.Int8
+	CreatedAt     pgtype.Timestamptz
+}
+
+type CouponRedemption struct {
+	ID             int64
+	CouponID       int64
+	UserID         int64
+	OrderID        int64
+	DiscountAmount int64
+	CreatedAt      pgtype.Timestamptz
+}
+
+type Handover struct {
+	SubOrderID     int64
+	SlotID         int64
+	CodeHash       pgtype.Text
+	CodeExpiresAt  pgtype.Timestamptz
+	FailedAttempts int32
+	HandedOverAt   pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
+}
+
+type ListingReport struct {
+	ID         int64
+	ProductID  int64
+	ReporterID int64
+	Reason     string
+	Details    string
+	Status     string
+	ResolvedBy pgtype.Int8
+	ResolvedAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+}
+
+type Message struct {
+	ID             int64
+	ConversationID int64
+	SenderID       int64
+	Body           string
+	CreatedAt      pgtype.Timestamptz
+}
+
+type MessageReport struct {
+	ID         int64
+	MessageID  int64
+	ReporterID int64
+	Reason     string
+	Details    string
+	CreatedAt  pgtype.Timestamptz
+}
+
 type Order struct {
-	ID          int64
-	UserID      int64
-	TotalAmount int64
-	Status      string
-	CreatedAt   pgtype.Timestamptz
+	ID             int64
+	UserID         int64
+	TotalAmount    int64
+	Status         string
+	CreatedAt      pgtype.Timestamptz
+	CouponID       pgtype.Int8
+	DiscountAmount int64
 }
 
 type OrderItem struct {
-	ID              int64
-	OrderID         int64
-	ProductID       int64
-	SellerID        int64
-	Quantity        int32
-	PriceAtPurchase int32
-	CreatedAt       pgtype.Timestamptz
+	ID               int64
+	OrderID          int64
+	ProductID        int64
+	SellerID         int64
+	Quantity         int32
+	PriceAtPurchase  int32
+	CreatedAt        pgtype.Timestamptz
+	CommissionBps    int32
+	CommissionAmount int64
+	SellerPayout     int64
+	SubOrderID       pgtype.Int8
+}
+
+type PickupSlot struct {
+	ID         int64
+	SubOrderID int64
+	LocationID int64
+	StartsAt   pgtype.Timestamptz
+	EndsAt     pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+}
+
+type PlatformAccount struct {
+	Name   string
+	UserID int32
 }
 
 type Product struct {
+	ID               int64
+	SellerID         int64
+	Name             string
+	Description      pgtype.Text
+	Condition        string
+	Price            int32
+	Stock            int32
+	Category         string
+	ImageUrl         pgtype.Text
+	IsActive         bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	CategoryID       int64
+	ModerationStatus string
+	ModerationReason pgtype.Text
+}
+
+type ProductAlert struct {
+	UserID    int64
+	ProductID int64
+	Kind      string
+	CreatedAt pgtype.Timestamptz
+}
+
+type ProductImage struct {
+	ID                   int64
+	ProductID            int64
+	ImageUrl             string
+	DisplayOrder         int32
+	CreatedAt            pgtype.Timestamptz
+	ThumbnailUrl         pgtype.Text
+	MediumUrl            pgtype.Text
+	Phash                pgtype.Int8
+	DuplicateOfProductID pgtype.Int8
+}
+
+type Review struct {
 	ID          int64
+	OrderItemID int64
+	ProductID   int64
 	SellerID    int64
-	SellerName  string
-	SellerPhone string
-	Name        string
-	Description pgtype.Text
-	Condition   string
-	Price       int32
-	Stock       int32
-	Category    string
-	ImageUrl    pgtype.Text
-	IsActive    bool
+	BuyerID     int64
+	Rating      int32
+	Body        string
+	SellerReply pgtype.Text
+	RepliedAt   pgtype.Timestamptz
+	FlagCount   int32
+	IsHidden    bool
 	CreatedAt   pgtype.Timestamptz
-	UpdatedAt   pgtype.Timestamptz
 }
 
-type ProductImage struct {
-	ID           int64
-	ProductID    int64
-	ImageUrl     string
-	DisplayOrder int32
-	CreatedAt    pgtype.Timestamptz
+type ReviewFlag struct {
+	ReviewID  int64
+	UserID    int64
+	Reason    string
+	CreatedAt pgtype.Timestamptz
+}
+
+type SignupEmailRule struct {
+	ID        int64
+	Pattern   string
+	Rule      string
+	Note      string
+	CreatedBy pgtype.Int8
+	CreatedAt pgtype.Timestamptz
+}
+
+type SubOrder struct {
+	ID          int64
+	OrderID     int64
+	SellerID    int64
+	Status      string
+	Gross       int64
+	Discount    int64
+	Commission  int64
+	Payout      int64
+	CompletedAt pgtype.Timestamptz
+	CancelledAt pgtype.Timestamptz
+	CancelledBy pgtype.Int8
+	CreatedAt   pgtype.Timestamptz
 }
 
 type Token struct {
@@ -71,6 +264,20 @@ type User struct {
 	EmailVerified bool
 	UserType      string
 	Version       int32
+	RatingTotal   int32
+	RatingCount   int32
+	AlertMode     string
+	RollNumber    pgtype.Text
+	Batch         pgtype.Text
+	Branch        pgtype.Text
+	AvatarUrl     pgtype.Text
+	Hostel        pgtype.Text
+}
+
+type UserBlock struct {
+	BlockerID int64
+	BlockedID int64
+	CreatedAt pgtype.Timestamptz
 }
 
 type Wallet struct {
@@ -95,3 +302,10 @@ type WalletTransaction struct {
 	CreatedAt         pgtype.Timestamp
 	UpdatedAt         pgtype.Timestamp
 }
+
+type WishlistItem struct {
+	UserID     int64
+	ProductID  int64
+	PriceAtAdd int32
+	CreatedAt  pgtype.Timestamptz
+}
@@ -0,0 +1,302 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: moderation.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countOpenListingReports = `-- name: CountOpenListingReports :one
+SELECT COUNT(*) FROM listing_reports
+WHERE product_id = $1 AND status = 'open'
+`
+
+func (q *Queries) CountOpenListingReports(ctx context.Context, productID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countOpenListingReports, productID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createBannedTerm = `-- name: CreateBannedTerm :one
+INSERT INTO banned_terms (pattern, is_regex, created_by)
+VALUES ($1, $2, $3)
+RETURNING id, pattern, is_regex, created_by, created_at
+`
+
+type CreateBannedTermParams struct {
+	Pattern   string
+	IsRegex   bool
+	CreatedBy pgtype.Int8
+}
+
+func (q *Queries) CreateBannedTerm(ctx context.Context, arg CreateBannedTermParams) (BannedTerm, error) {
+	row := q.db.QueryRow(ctx, createBannedTerm, arg.Pattern, arg.IsRegex, arg.CreatedBy)
+	var i BannedTerm
+	err := row.Scan(
+		&i.ID,
+		&i.Pattern,
+		&i.IsRegex,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createListingReport = `-- name: CreateListingReport :one
+INSERT INTO listing_reports (product_id, reporter_id, reason, details)
+VALUES ($1, $2, $3, $4)
+RETURNING id, product_id, reporter_id, reason, details, status, resolved_by, resolved_at, created_at
+`
+
+type CreateListingReportParams struct {
+	ProductID  int64
+	ReporterID int64
+	Reason     string
+	Details    string
+}
+
+func (q *Queries) CreateListingReport(ctx context.Context, arg CreateListingReportParams) (ListingReport, error) {
+	row := q.db.QueryRow(ctx, createListingReport,
+		arg.ProductID,
+		arg.ReporterID,
+		arg.Reason,
+		arg.Details,
+	)
+	var i ListingReport
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.ReporterID,
+		&i.Reason,
+		&i.Details,
+		&i.Status,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteBannedTerm = `-- name: DeleteBannedTerm :execrows
+DELETE FROM banned_terms
+WHERE id = $1
+`
+
+func (q *Queries) DeleteBannedTerm(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteBannedTerm, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listBannedTerms = `-- name: ListBannedTerms :many
+SELECT id, pattern, is_regex, created_by, created_at FROM banned_terms
+ORDER BY pattern
+`
+
+func (q *Queries) ListBannedTerms(ctx context.Context) ([]BannedTerm, error) {
+	rows, err := q.db.Query(ctx, listBannedTerms)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BannedTerm
+	for rows.Next() {
+		var i BannedTerm
+		if err := rows.Scan(
+			&i.ID,
+			&i.Pattern,
+			&i.IsRegex,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listListingReports = `-- name: ListListingReports :many
+SELECT r.id, r.product_id, r.reporter_id, r.reason, r.details, r.status, r.resolved_by, r.resolved_at, r.created_at, u.name AS reporter_name
+FROM listing_reports r
+JOIN users u ON u.id = r.reporter_id
+WHERE r.product_id = $1
+ORDER BY r.created_at DESC
+`
+
+type ListListingReportsRow struct {
+	ID           int64
+	ProductID    int64
+	ReporterID   int64
+	Reason       string
+	Details      string
+	Status       string
+	ResolvedBy   pgtype.Int8
+	ResolvedAt   pgtype.Timestamptz
+	CreatedAt    pgtype.Timestamptz
+	ReporterName string
+}
+
+func (q *Queries) ListListingReports(ctx context.Context, productID int64) ([]ListListingReportsRow, error) {
+	rows, err := q.db.Query(ctx, listListingReports, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListListingReportsRow
+	for rows.Next() {
+		var i ListListingReportsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.ReporterID,
+			&i.Reason,
+			&i.Details,
+			&i.Status,
+			&i.ResolvedBy,
+			&i.ResolvedAt,
+			&i.CreatedAt,
+			&i.ReporterName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listModerationQueue = `-- name: ListModerationQueue :many
+SELECT
+    p.id,
+    p.seller_id,
+    p.name,
+    p.moderation_status,
+    p.moderation_reason,
+    COUNT(r.id) FILTER (WHERE r.status = 'open') AS open_reports,
+    COALESCE(string_agg(DISTINCT r.reason, ',') FILTER (WHERE r.status = 'open'), '')::text AS reasons,
+    MAX(r.created_at)::timestamptz AS last_reported_at
+FROM products p
+LEFT JOIN listing_reports r ON r.product_id = p.id
+WHERE p.moderation_status = 'held'
+   OR EXISTS (SELECT 1 FROM listing_reports o WHERE o.product_id = p.id AND o.status = 'open')
+GROUP BY p.id
+ORDER BY (p.moderation_status = 'held') DESC, open_reports DESC, last_reported_at DESC
+`
+
+type ListModerationQueueRow struct {
+	ID               int64
+	SellerID         int64
+	Name             string
+	ModerationStatus string
+	ModerationReason pgtype.Text
+	OpenReports      int64
+	Reasons          string
+	LastReportedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) ListModerationQueue(ctx context.Context) ([]ListModerationQueueRow, error) {
+	rows, err := q.db.Query(ctx, listModerationQueue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListModerationQueueRow
+	for rows.Next() {
+		var i ListModerationQueueRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SellerID,
+			&i.Name,
+			&i.ModerationStatus,
+			&i.ModerationReason,
+			&i.OpenReports,
+			&i.Reasons,
+			&i.LastReportedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resolveListingReports = `-- name: ResolveListingReports :exec
+UPDATE listing_reports
+SET status = $1,
+    resolved_by = $2,
+    resolved_at = NOW()
+WHERE product_id = $3 AND status = 'open'
+`
+
+type ResolveListingReportsParams struct {
+	Status     string
+	ResolvedBy pgtype.Int8
+	ProductID  int64
+}
+
+func (q *Queries) ResolveListingReports(ctx context.Context, arg ResolveListingReportsParams) error {
+	_, err := q.db.Exec(ctx, resolveListingReports, arg.Status, arg.ResolvedBy, arg.ProductID)
+	return err
+}
+
+const setProductModeration = `-- name: SetProductModeration :one
+UPDATE products
+SET moderation_status = $1,
+    moderation_reason = $2,
+    is_active = $3,
+    updated_at = NOW()
+WHERE id = $4
+RETURNING id, seller_id, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at, category_id, moderation_status, moderation_reason
+`
+
+type SetProductModerationParams struct {
+	ModerationStatus string
+	ModerationReason pgtype.Text
+	IsActive         bool
+	ID               int64
+}
+
+func (q *Queries) SetProductModeration(ctx context.Context, arg SetProductModerationParams) (Product, error) {
+	row := q.db.QueryRow(ctx, setProductModeration,
+		arg.ModerationStatus,
+		arg.ModerationReason,
+		arg.IsActive,
+		arg.ID,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.SellerID,
+		&i.Name,
+		&i.Description,
+		&i.Condition,
+		&i.Price,
+		&i.Stock,
+		&i.Category,
+		&i.ImageUrl,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.ModerationStatus,
+		&i.ModerationReason,
+	)
+	return i, err
+}
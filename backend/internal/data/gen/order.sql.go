@@ -7,26 +7,97 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelSubOrder = `-- name: CancelSubOrder :one
+UPDATE sub_orders
+SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $1
+WHERE id = $2 AND status = 'pending'
+RETURNING id, order_id, seller_id, status, gross, discount, commission, payout, completed_at, cancelled_at, cancelled_by, created_at
+`
+
+type CancelSubOrderParams struct {
+	CancelledBy pgtype.Int8
+	ID          int64
+}
+
+func (q *Queries) CancelSubOrder(ctx context.Context, arg CancelSubOrderParams) (SubOrder, error) {
+	row := q.db.QueryRow(ctx, cancelSubOrder, arg.CancelledBy, arg.ID)
+	var i SubOrder
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.SellerID,
+		&i.Status,
+		&i.Gross,
+		&i.Discount,
+		&i.Commission,
+		&i.Payout,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const completeSubOrder = `-- name: CompleteSubOrder :one
+UPDATE sub_orders
+SET status = 'completed', completed_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING id, order_id, seller_id, status, gross, discount, commission, payout, completed_at, cancelled_at, cancelled_by, created_at
+`
+
+func (q *Queries) CompleteSubOrder(ctx context.Context, id int64) (SubOrder, error) {
+	row := q.db.QueryRow(ctx, completeSubOrder, id)
+	var i SubOrder
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.SellerID,
+		&i.Status,
+		&i.Gross,
+		&i.Discount,
+		&i.Commission,
+		&i.Payout,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createOrder = `-- name: CreateOrder :one
 INSERT INTO orders (
     user_id,
     total_amount,
-    status
+    status,
+    coupon_id,
+    discount_amount
 ) VALUES (
-    $1, $2, $3
-) RETURNING id, user_id, total_amount, status, created_at
+    $1, $2, $3, $4, $5
+) RETURNING id, user_id, total_amount, status, created_at, coupon_id, discount_amount
 `
 
 type CreateOrderParams struct {
-	UserID      int64
-	TotalAmount int64
-	Status      string
+	UserID         int64
+	TotalAmount    int64
+	Status         string
+	CouponID       pgtype.Int8
+	DiscountAmount int64
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
-	row := q.db.QueryRow(ctx, createOrder, arg.UserID, arg.TotalAmount, arg.Status)
+	row := q.db.QueryRow(ctx, createOrder,
+		arg.UserID,
+		arg.TotalAmount,
+		arg.Status,
+		arg.CouponID,
+		arg.DiscountAmount,
+	)
 	var i Order
 	err := row.Scan(
 		&i.ID,
@@ -34,6 +105,8 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.TotalAmount,
 		&i.Status,
 		&i.CreatedAt,
+		&i.CouponID,
+		&i.DiscountAmount,
 	)
 	return i, err
 }
@@ -41,36 +114,97 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 const createOrderItem = `-- name: CreateOrderItem :exec
 INSERT INTO order_items (
     order_id,
+    sub_order_id,
     product_id,
     seller_id,
     quantity,
-    price_at_purchase
+    price_at_purchase,
+    commission_bps,
+    commission_amount,
+    seller_payout
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
 `
 
 type CreateOrderItemParams struct {
-	OrderID         int64
-	ProductID       int64
-	SellerID        int64
-	Quantity        int32
-	PriceAtPurchase int32
+	OrderID          int64
+	SubOrderID       pgtype.Int8
+	ProductID        int64
+	SellerID         int64
+	Quantity         int32
+	PriceAtPurchase  int32
+	CommissionBps    int32
+	CommissionAmount int64
+	SellerPayout     int64
 }
 
 func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) error {
 	_, err := q.db.Exec(ctx, createOrderItem,
 		arg.OrderID,
+		arg.SubOrderID,
 		arg.ProductID,
 		arg.SellerID,
 		arg.Quantity,
 		arg.PriceAtPurchase,
+		arg.CommissionBps,
+		arg.CommissionAmount,
+		arg.SellerPayout,
 	)
 	return err
 }
 
+const createSubOrder = `-- name: CreateSubOrder :one
+INSERT INTO sub_orders (
+    order_id,
+    seller_id,
+    gross,
+    discount,
+    commission,
+    payout
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, order_id, seller_id, status, gross, discount, commission, payout, completed_at, cancelled_at, cancelled_by, created_at
+`
+
+type CreateSubOrderParams struct {
+	OrderID    int64
+	SellerID   int64
+	Gross      int64
+	Discount   int64
+	Commission int64
+	Payout     int64
+}
+
+func (q *Queries) CreateSubOrder(ctx context.Context, arg CreateSubOrderParams) (SubOrder, error) {
+	row := q.db.QueryRow(ctx, createSubOrder,
+		arg.OrderID,
+		arg.SellerID,
+		arg.Gross,
+		arg.Discount,
+		arg.Commission,
+		arg.Payout,
+	)
+	var i SubOrder
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.SellerID,
+		&i.Status,
+		&i.Gross,
+		&i.Discount,
+		&i.Commission,
+		&i.Payout,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getOrderByID = `-- name: GetOrderByID :one
-SELECT id, user_id, total_amount, status, created_at FROM orders
+SELECT id, user_id, total_amount, status, created_at, coupon_id, discount_amount FROM orders
 WHERE id = $1
 `
 
@@ -83,12 +217,14 @@ func (q *Queries) GetOrderByID(ctx context.Context, id int64) (Order, error) {
 		&i.TotalAmount,
 		&i.Status,
 		&i.CreatedAt,
+		&i.CouponID,
+		&i.DiscountAmount,
 	)
 	return i, err
 }
 
 const getOrderItemsByOrderID = `-- name: GetOrderItemsByOrderID :many
-SELECT id, order_id, product_id, seller_id, quantity, price_at_purchase, created_at FROM order_items
+SELECT id, order_id, product_id, seller_id, quantity, price_at_purchase, created_at, commission_bps, commission_amount, seller_payout, sub_order_id FROM order_items
 WHERE order_id = $1
 `
 
@@ -109,6 +245,10 @@ func (q *Queries) GetOrderItemsByOrderID(ctx context.Context, orderID int64) ([]
 			&i.Quantity,
 			&i.PriceAtPurchase,
 			&i.CreatedAt,
+			&i.CommissionBps,
+			&i.CommissionAmount,
+			&i.SellerPayout,
+			&i.SubOrderID,
 		); err != nil {
 			return nil, err
 		}
@@ -121,7 +261,7 @@ func (q *Queries) GetOrderItemsByOrderID(ctx context.Context, orderID int64) ([]
 }
 
 const getOrdersByUserID = `-- name: GetOrdersByUserID :many
-SELECT id, user_id, total_amount, status, created_at FROM orders
+SELECT id, user_id, total_amount, status, created_at, coupon_id, discount_amount FROM orders
 WHERE user_id = $1
 ORDER BY created_at DESC
 `
@@ -141,6 +281,136 @@ func (q *Queries) GetOrdersByUserID(ctx context.Context, userID int64) ([]Order,
 			&i.TotalAmount,
 			&i.Status,
 			&i.CreatedAt,
+			&i.CouponID,
+			&i.DiscountAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSubOrder = `-- name: GetSubOrder :one
+SELECT so.id, so.order_id, so.seller_id, so.status, so.gross, so.discount, so.commission, so.payout, so.completed_at, so.cancelled_at, so.cancelled_by, so.created_at, o.user_id AS buyer_id
+FROM sub_orders so
+JOIN orders o ON o.id = so.order_id
+WHERE so.id = $1
+`
+
+type GetSubOrderRow struct {
+	ID          int64
+	OrderID     int64
+	SellerID    int64
+	Status      string
+	Gross       int64
+	Discount    int64
+	Commission  int64
+	Payout      int64
+	CompletedAt pgtype.Timestamptz
+	CancelledAt pgtype.Timestamptz
+	CancelledBy pgtype.Int8
+	CreatedAt   pgtype.Timestamptz
+	BuyerID     int64
+}
+
+func (q *Queries) GetSubOrder(ctx context.Context, id int64) (GetSubOrderRow, error) {
+	row := q.db.QueryRow(ctx, getSubOrder, id)
+	var i GetSubOrderRow
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.SellerID,
+		&i.Status,
+		&i.Gross,
+		&i.Discount,
+		&i.Commission,
+		&i.Payout,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.CreatedAt,
+		&i.BuyerID,
+	)
+	return i, err
+}
+
+const getSubOrderForUpdate = `-- name: GetSubOrderForUpdate :one
+SELECT so.id, so.order_id, so.seller_id, so.status, so.gross, so.discount, so.commission, so.payout, so.completed_at, so.cancelled_at, so.cancelled_by, so.created_at, o.user_id AS buyer_id
+FROM sub_orders so
+JOIN orders o ON o.id = so.order_id
+WHERE so.id = $1
+FOR UPDATE OF so
+`
+
+type GetSubOrderForUpdateRow struct {
+	ID          int64
+	OrderID     int64
+	SellerID    int64
+	Status      string
+	Gross       int64
+	Discount    int64
+	Commission  int64
+	Payout      int64
+	CompletedAt pgtype.Timestamptz
+	CancelledAt pgtype.Timestamptz
+	CancelledBy pgtype.Int8
+	CreatedAt   pgtype.Timestamptz
+	BuyerID     int64
+}
+
+func (q *Queries) GetSubOrderForUpdate(ctx context.Context, id int64) (GetSubOrderForUpdateRow, error) {
+	row := q.db.QueryRow(ctx, getSubOrderForUpdate, id)
+	var i GetSubOrderForUpdateRow
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.SellerID,
+		&i.Status,
+		&i.Gross,
+		&i.Discount,
+		&i.Commission,
+		&i.Payout,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.CreatedAt,
+		&i.BuyerID,
+	)
+	return i, err
+}
+
+const getSubOrdersByOrderID = `-- name: GetSubOrdersByOrderID :many
+SELECT id, order_id, seller_id, status, gross, discount, commission, payout, completed_at, cancelled_at, cancelled_by, created_at FROM sub_orders
+WHERE order_id = $1
+ORDER BY id
+`
+
+func (q *Queries) GetSubOrdersByOrderID(ctx context.Context, orderID int64) ([]SubOrder, error) {
+	rows, err := q.db.Query(ctx, getSubOrdersByOrderID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SubOrder
+	for rows.Next() {
+		var i SubOrder
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.SellerID,
+			&i.Status,
+			&i.Gross,
+			&i.Discount,
+			&i.Commission,
+			&i.Payout,
+			&i.CompletedAt,
+			&i.CancelledAt,
+			&i.CancelledBy,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -151,3 +421,41 @@ func (q *Queries) GetOrdersByUserID(ctx context.Context, userID int64) ([]Order,
 	}
 	return items, nil
 }
+
+const hasActiveOrderWithSeller = `-- name: HasActiveOrderWithSeller :one
+SELECT EXISTS (
+    SELECT 1
+    FROM orders o
+    JOIN sub_orders so ON so.order_id = o.id
+    WHERE o.user_id = $1
+      AND so.seller_id = $2
+      AND so.status = 'pending'
+)
+`
+
+type HasActiveOrderWithSellerParams struct {
+	BuyerID  int64
+	SellerID int64
+}
+
+func (q *Queries) HasActiveOrderWithSeller(ctx context.Context, arg HasActiveOrderWithSellerParams) (bool, error) {
+	row := q.db.QueryRow(ctx, hasActiveOrderWithSeller, arg.BuyerID, arg.SellerID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const refreshOrderStatus = `-- name: RefreshOrderStatus :exec
+UPDATE orders o
+SET status = CASE
+    WHEN EXISTS (SELECT 1 FROM sub_orders so WHERE so.order_id = o.id AND so.status = 'pending') THEN 'pending'
+    WHEN EXISTS (SELECT 1 FROM sub_orders so WHERE so.order_id = o.id AND so.status = 'completed') THEN 'completed'
+    ELSE 'cancelled'
+END
+WHERE o.id = $1
+`
+
+func (q *Queries) RefreshOrderStatus(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, refreshOrderStatus, id)
+	return err
+}
@@ -14,24 +14,21 @@ import (
 const createProduct = `-- name: CreateProduct :one
 INSERT INTO products (
     seller_id, 
-	seller_name,
-	seller_phone,
     name, 
     description, 
 	condition,
 	category,
     price, 
     stock, 
-    image_url
+    image_url,
+    category_id
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
-) RETURNING id, seller_id, seller_name, seller_phone, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, seller_id, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at, category_id, moderation_status, moderation_reason
 `
 
 type CreateProductParams struct {
 	SellerID    int64
-	SellerName  string
-	SellerPhone string
 	Name        string
 	Description pgtype.Text
 	Condition   string
@@ -39,13 +36,12 @@ type CreateProductParams struct {
 	Price       int32
 	Stock       int32
 	ImageUrl    pgtype.Text
+	CategoryID  int64
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
 	row := q.db.QueryRow(ctx, createProduct,
 		arg.SellerID,
-		arg.SellerName,
-		arg.SellerPhone,
 		arg.Name,
 		arg.Description,
 		arg.Condition,
@@ -53,13 +49,12 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		arg.Price,
 		arg.Stock,
 		arg.ImageUrl,
+		arg.CategoryID,
 	)
 	var i Product
 	err := row.Scan(
 		&i.ID,
 		&i.SellerID,
-		&i.SellerName,
-		&i.SellerPhone,
 		&i.Name,
 		&i.Description,
 		&i.Condition,
@@ -70,6 +65,9 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.ModerationStatus,
+		&i.ModerationReason,
 	)
 	return i, err
 }
@@ -78,49 +76,120 @@ const createProductImage = `-- name: CreateProductImage :exec
 INSERT INTO product_images (
     product_id, 
     image_url, 
-    display_order
+    display_order,
+    thumbnail_url,
+    medium_url,
+    phash,
+    duplicate_of_product_id
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, $6, $7
 )
 `
 
 type CreateProductImageParams struct {
-	ProductID    int64
-	ImageUrl     string
-	DisplayOrder int32
+	ProductID            int64
+	ImageUrl             string
+	DisplayOrder         int32
+	ThumbnailUrl         pgtype.Text
+	MediumUrl            pgtype.Text
+	Phash                pgtype.Int8
+	DuplicateOfProductID pgtype.Int8
 }
 
 func (q *Queries) CreateProductImage(ctx context.Context, arg CreateProductImageParams) error {
-	_, err := q.db.Exec(ctx, createProductImage, arg.ProductID, arg.ImageUrl, arg.DisplayOrder)
+	_, err := q.db.Exec(ctx, createProductImage,
+		arg.ProductID,
+		arg.ImageUrl,
+		arg.DisplayOrder,
+		arg.ThumbnailUrl,
+		arg.MediumUrl,
+		arg.Phash,
+		arg.DuplicateOfProductID,
+	)
 	return err
 }
 
+const findSimilarProductImage = `-- name: FindSimilarProductImage :one
+SELECT product_id FROM product_images
+WHERE phash IS NOT NULL
+  AND product_id <> $1
+  AND bit_count((phash # $2::bigint)::bit(64)) <= $3::int
+ORDER BY bit_count((phash # $2::bigint)::bit(64)) ASC, created_at ASC
+LIMIT 1
+`
+
+type FindSimilarProductImageParams struct {
+	ExcludeProductID int64
+	Phash            int64
+	MaxDistance      int32
+}
+
+func (q *Queries) FindSimilarProductImage(ctx context.Context, arg FindSimilarProductImageParams) (int64, error) {
+	row := q.db.QueryRow(ctx, findSimilarProductImage, arg.ExcludeProductID, arg.Phash, arg.MaxDistance)
+	var product_id int64
+	err := row.Scan(&product_id)
+	return product_id, err
+}
+
 const getAllProducts = `-- name: GetAllProducts :many
-SELECT id, seller_id, seller_name, seller_phone, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at FROM products
-WHERE is_active = TRUE
-ORDER BY created_at DESC
+SELECT
+    p.id,
+    p.seller_id,
+    u.name AS seller_name,
+    p.name,
+    p.description,
+    p.condition,
+    p.price,
+    p.stock,
+    p.category,
+    p.category_id,
+    p.image_url,
+    p.is_active,
+    p.created_at,
+    p.updated_at
+FROM products p
+JOIN users u ON u.id = p.seller_id
+WHERE p.is_active = TRUE
+ORDER BY p.created_at DESC
 `
 
-func (q *Queries) GetAllProducts(ctx context.Context) ([]Product, error) {
+type GetAllProductsRow struct {
+	ID          int64
+	SellerID    int64
+	SellerName  string
+	Name        string
+	Description pgtype.Text
+	Condition   string
+	Price       int32
+	Stock       int32
+	Category    string
+	CategoryID  int64
+	ImageUrl    pgtype.Text
+	IsActive    bool
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) GetAllProducts(ctx context.Context) ([]GetAllProductsRow, error) {
 	rows, err := q.db.Query(ctx, getAllProducts)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Product
+	var items []GetAllProductsRow
 	for rows.Next() {
-		var i Product
+		var i GetAllProductsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.SellerID,
 			&i.SellerName,
-			&i.SellerPhone,
 			&i.Name,
 			&i.Description,
 			&i.Condition,
 			&i.Price,
 			&i.Stock,
 			&i.Category,
+			&i.CategoryID,
 			&i.ImageUrl,
 			&i.IsActive,
 			&i.CreatedAt,
@@ -137,7 +206,7 @@ func (q *Queries) GetAllProducts(ctx context.Context) ([]Product, error) {
 }
 
 const getProductByID = `-- name: GetProductByID :one
-SELECT id, seller_id, seller_name, seller_phone, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at FROM products
+SELECT id, seller_id, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at, category_id, moderation_status, moderation_reason FROM products
 WHERE id = $1 AND is_active = TRUE
 `
 
@@ -147,8 +216,6 @@ func (q *Queries) GetProductByID(ctx context.Context, id int64) (Product, error)
 	err := row.Scan(
 		&i.ID,
 		&i.SellerID,
-		&i.SellerName,
-		&i.SellerPhone,
 		&i.Name,
 		&i.Description,
 		&i.Condition,
@@ -159,12 +226,44 @@ func (q *Queries) GetProductByID(ctx context.Context, id int64) (Product, error)
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.ModerationStatus,
+		&i.ModerationReason,
+	)
+	return i, err
+}
+
+const getProductForUpdate = `-- name: GetProductForUpdate :one
+SELECT id, seller_id, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at, category_id, moderation_status, moderation_reason FROM products
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetProductForUpdate(ctx context.Context, id int64) (Product, error) {
+	row := q.db.QueryRow(ctx, getProductForUpdate, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.SellerID,
+		&i.Name,
+		&i.Description,
+		&i.Condition,
+		&i.Price,
+		&i.Stock,
+		&i.Category,
+		&i.ImageUrl,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.ModerationStatus,
+		&i.ModerationReason,
 	)
 	return i, err
 }
 
 const getProductImages = `-- name: GetProductImages :many
-SELECT id, product_id, image_url, display_order, created_at FROM product_images
+SELECT id, product_id, image_url, display_order, created_at, thumbnail_url, medium_url, phash, duplicate_of_product_id FROM product_images
 WHERE product_id = $1
 ORDER BY display_order ASC
 `
@@ -184,6 +283,10 @@ func (q *Queries) GetProductImages(ctx context.Context, productID int64) ([]Prod
 			&i.ImageUrl,
 			&i.DisplayOrder,
 			&i.CreatedAt,
+			&i.ThumbnailUrl,
+			&i.MediumUrl,
+			&i.Phash,
+			&i.DuplicateOfProductID,
 		); err != nil {
 			return nil, err
 		}
@@ -196,7 +299,7 @@ func (q *Queries) GetProductImages(ctx context.Context, productID int64) ([]Prod
 }
 
 const getProductsByCategory = `-- name: GetProductsByCategory :many
-SELECT id, seller_id, seller_name, seller_phone, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at FROM products
+SELECT id, seller_id, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at, category_id, moderation_status, moderation_reason FROM products
 WHERE category = $1 AND is_active = TRUE
 ORDER BY created_at DESC
 `
@@ -213,8 +316,6 @@ func (q *Queries) GetProductsByCategory(ctx context.Context, category string) ([
 		if err := rows.Scan(
 			&i.ID,
 			&i.SellerID,
-			&i.SellerName,
-			&i.SellerPhone,
 			&i.Name,
 			&i.Description,
 			&i.Condition,
@@ -225,6 +326,9 @@ func (q *Queries) GetProductsByCategory(ctx context.Context, category string) ([
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.ModerationStatus,
+			&i.ModerationReason,
 		); err != nil {
 			return nil, err
 		}
@@ -237,7 +341,7 @@ func (q *Queries) GetProductsByCategory(ctx context.Context, category string) ([
 }
 
 const getProductsByIDs = `-- name: GetProductsByIDs :many
-SELECT id, seller_id, seller_name, seller_phone, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at FROM products
+SELECT id, seller_id, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at, category_id, moderation_status, moderation_reason FROM products
 WHERE id = ANY($1::bigint[])
 `
 
@@ -253,8 +357,6 @@ func (q *Queries) GetProductsByIDs(ctx context.Context, dollar_1 []int64) ([]Pro
 		if err := rows.Scan(
 			&i.ID,
 			&i.SellerID,
-			&i.SellerName,
-			&i.SellerPhone,
 			&i.Name,
 			&i.Description,
 			&i.Condition,
@@ -265,6 +367,9 @@ func (q *Queries) GetProductsByIDs(ctx context.Context, dollar_1 []int64) ([]Pro
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.ModerationStatus,
+			&i.ModerationReason,
 		); err != nil {
 			return nil, err
 		}
@@ -277,7 +382,7 @@ func (q *Queries) GetProductsByIDs(ctx context.Context, dollar_1 []int64) ([]Pro
 }
 
 const getProductsByPriceRange = `-- name: GetProductsByPriceRange :many
-SELECT id, seller_id, seller_name, seller_phone, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at FROM products
+SELECT id, seller_id, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at, category_id, moderation_status, moderation_reason FROM products
 WHERE is_active = TRUE
   AND price BETWEEN $1 AND $2 
 ORDER BY 
@@ -304,8 +409,6 @@ func (q *Queries) GetProductsByPriceRange(ctx context.Context, arg GetProductsBy
 		if err := rows.Scan(
 			&i.ID,
 			&i.SellerID,
-			&i.SellerName,
-			&i.SellerPhone,
 			&i.Name,
 			&i.Description,
 			&i.Condition,
@@ -316,6 +419,9 @@ func (q *Queries) GetProductsByPriceRange(ctx context.Context, arg GetProductsBy
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.ModerationStatus,
+			&i.ModerationReason,
 		); err != nil {
 			return nil, err
 		}
@@ -328,7 +434,7 @@ func (q *Queries) GetProductsByPriceRange(ctx context.Context, arg GetProductsBy
 }
 
 const getProductsBySeller = `-- name: GetProductsBySeller :many
-SELECT id, seller_id, seller_name, seller_phone, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at FROM products
+SELECT id, seller_id, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at, category_id, moderation_status, moderation_reason FROM products
 WHERE seller_id = $1
 ORDER BY created_at DESC
 `
@@ -345,8 +451,6 @@ func (q *Queries) GetProductsBySeller(ctx context.Context, sellerID int64) ([]Pr
 		if err := rows.Scan(
 			&i.ID,
 			&i.SellerID,
-			&i.SellerName,
-			&i.SellerPhone,
 			&i.Name,
 			&i.Description,
 			&i.Condition,
@@ -357,6 +461,9 @@ func (q *Queries) GetProductsBySeller(ctx context.Context, sellerID int64) ([]Pr
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.ModerationStatus,
+			&i.ModerationReason,
 		); err != nil {
 			return nil, err
 		}
@@ -367,3 +474,80 @@ func (q *Queries) GetProductsBySeller(ctx context.Context, sellerID int64) ([]Pr
 	}
 	return items, nil
 }
+
+const listReferencedImageURLs = `-- name: ListReferencedImageURLs :many
+SELECT image_url AS url FROM product_images
+UNION
+SELECT thumbnail_url FROM product_images WHERE thumbnail_url IS NOT NULL
+UNION
+SELECT medium_url FROM product_images WHERE medium_url IS NOT NULL
+UNION
+SELECT image_url FROM products WHERE image_url IS NOT NULL
+UNION
+SELECT avatar_url FROM users WHERE avatar_url IS NOT NULL
+`
+
+func (q *Queries) ListReferencedImageURLs(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listReferencedImageURLs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		items = append(items, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateProductListing = `-- name: UpdateProductListing :one
+UPDATE products
+SET price = COALESCE($1, price),
+    stock = COALESCE($2, stock),
+    is_active = COALESCE($3, is_active),
+    updated_at = NOW()
+WHERE id = $4
+RETURNING id, seller_id, name, description, condition, price, stock, category, image_url, is_active, created_at, updated_at, category_id, moderation_status, moderation_reason
+`
+
+type UpdateProductListingParams struct {
+	Price    pgtype.Int4
+	Stock    pgtype.Int4
+	IsActive pgtype.Bool
+	ID       int64
+}
+
+func (q *Queries) UpdateProductListing(ctx context.Context, arg UpdateProductListingParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProductListing,
+		arg.Price,
+		arg.Stock,
+		arg.IsActive,
+		arg.ID,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.SellerID,
+		&i.Name,
+		&i.Description,
+		&i.Condition,
+		&i.Price,
+		&i.Stock,
+		&i.Category,
+		&i.ImageUrl,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.ModerationStatus,
+		&i.ModerationReason,
+	)
+	return i, err
+}
@@ -11,6 +11,29 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const consumeToken = `-- name: ConsumeToken :one
+DELETE FROM tokens
+WHERE hash = $1 AND scope = $2
+RETURNING hash, user_id, expiry, scope
+`
+
+type ConsumeTokenParams struct {
+	Hash  []byte
+	Scope string
+}
+
+func (q *Queries) ConsumeToken(ctx context.Context, arg ConsumeTokenParams) (Token, error) {
+	row := q.db.QueryRow(ctx, consumeToken, arg.Hash, arg.Scope)
+	var i Token
+	err := row.Scan(
+		&i.Hash,
+		&i.UserID,
+		&i.Expiry,
+		&i.Scope,
+	)
+	return i, err
+}
+
 const deleteToken = `-- name: DeleteToken :exec
 DELETE FROM tokens
 WHERE scope = $1 AND user_id = $2
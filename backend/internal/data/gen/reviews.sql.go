@@ -0,0 +1,437 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: reviews.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const adjustSellerRating = `-- name: AdjustSellerRating :exec
+UPDATE users
+SET rating_total = rating_total + $1,
+    rating_count = rating_count + $2
+WHERE id = $3
+`
+
+type AdjustSellerRatingParams struct {
+	TotalDelta int32
+	CountDelta int32
+	SellerID   int32
+}
+
+func (q *Queries) AdjustSellerRating(ctx context.Context, arg AdjustSellerRatingParams) error {
+	_, err := q.db.Exec(ctx, adjustSellerRating, arg.TotalDelta, arg.CountDelta, arg.SellerID)
+	return err
+}
+
+const createReview = `-- name: CreateReview :one
+INSERT INTO reviews (order_item_id, product_id, seller_id, buyer_id, rating, body)
+SELECT oi.id, oi.product_id, oi.seller_id, o.user_id, $1, $2
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN sub_orders so ON so.id = oi.sub_order_id
+WHERE oi.id = $3
+  AND o.user_id = $4
+  AND so.status = 'completed'
+RETURNING id, order_item_id, product_id, seller_id, buyer_id, rating, body, seller_reply, replied_at, flag_count, is_hidden, created_at
+`
+
+type CreateReviewParams struct {
+	Rating      int32
+	Body        string
+	OrderItemID int64
+	BuyerID     int64
+}
+
+func (q *Queries) CreateReview(ctx context.Context, arg CreateReviewParams) (Review, error) {
+	row := q.db.QueryRow(ctx, createReview,
+		arg.Rating,
+		arg.Body,
+		arg.OrderItemID,
+		arg.BuyerID,
+	)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.OrderItemID,
+		&i.ProductID,
+		&i.SellerID,
+		&i.BuyerID,
+		&i.Rating,
+		&i.Body,
+		&i.SellerReply,
+		&i.RepliedAt,
+		&i.FlagCount,
+		&i.IsHidden,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createReviewFlag = `-- name: CreateReviewFlag :exec
+INSERT INTO review_flags (review_id, user_id, reason)
+VALUES ($1, $2, $3)
+`
+
+type CreateReviewFlagParams struct {
+	ReviewID int64
+	UserID   int64
+	Reason   string
+}
+
+func (q *Queries) CreateReviewFlag(ctx context.Context, arg CreateReviewFlagParams) error {
+	_, err := q.db.Exec(ctx, createReviewFlag, arg.ReviewID, arg.UserID, arg.Reason)
+	return err
+}
+
+const getProductRating = `-- name: GetProductRating :one
+SELECT COALESCE(SUM(rating), 0)::bigint AS rating_total, COUNT(*)::bigint AS rating_count
+FROM reviews
+WHERE product_id = $1 AND NOT is_hidden
+`
+
+type GetProductRatingRow struct {
+	RatingTotal int64
+	RatingCount int64
+}
+
+func (q *Queries) GetProductRating(ctx context.Context, productID int64) (GetProductRatingRow, error) {
+	row := q.db.QueryRow(ctx, getProductRating, productID)
+	var i GetProductRatingRow
+	err := row.Scan(&i.RatingTotal, &i.RatingCount)
+	return i, err
+}
+
+const getReviewForUpdate = `-- name: GetReviewForUpdate :one
+SELECT id, order_item_id, product_id, seller_id, buyer_id, rating, body, seller_reply, replied_at, flag_count, is_hidden, created_at FROM reviews
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetReviewForUpdate(ctx context.Context, id int64) (Review, error) {
+	row := q.db.QueryRow(ctx, getReviewForUpdate, id)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.OrderItemID,
+		&i.ProductID,
+		&i.SellerID,
+		&i.BuyerID,
+		&i.Rating,
+		&i.Body,
+		&i.SellerReply,
+		&i.RepliedAt,
+		&i.FlagCount,
+		&i.IsHidden,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const incrementReviewFlagCount = `-- name: IncrementReviewFlagCount :exec
+UPDATE reviews
+SET flag_count = flag_count + 1
+WHERE id = $1
+`
+
+func (q *Queries) IncrementReviewFlagCount(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, incrementReviewFlagCount, id)
+	return err
+}
+
+const listFlaggedReviews = `-- name: ListFlaggedReviews :many
+SELECT
+    r.id, r.order_item_id, r.product_id, r.seller_id, r.buyer_id, r.rating, r.body, r.seller_reply, r.replied_at, r.flag_count, r.is_hidden, r.created_at,
+    COALESCE((SELECT string_agg(DISTINCT f.reason, ',') FROM review_flags f WHERE f.review_id = r.id), '')::text AS reasons
+FROM reviews r
+WHERE r.flag_count > 0
+ORDER BY r.is_hidden ASC, r.flag_count DESC, r.created_at DESC
+`
+
+type ListFlaggedReviewsRow struct {
+	ID          int64
+	OrderItemID int64
+	ProductID   int64
+	SellerID    int64
+	BuyerID     int64
+	Rating      int32
+	Body        string
+	SellerReply pgtype.Text
+	RepliedAt   pgtype.Timestamptz
+	FlagCount   int32
+	IsHidden    bool
+	CreatedAt   pgtype.Timestamptz
+	Reasons     string
+}
+
+func (q *Queries) ListFlaggedReviews(ctx context.Context) ([]ListFlaggedReviewsRow, error) {
+	rows, err := q.db.Query(ctx, listFlaggedReviews)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFlaggedReviewsRow
+	for rows.Next() {
+		var i ListFlaggedReviewsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderItemID,
+			&i.ProductID,
+			&i.SellerID,
+			&i.BuyerID,
+			&i.Rating,
+			&i.Body,
+			&i.SellerReply,
+			&i.RepliedAt,
+			&i.FlagCount,
+			&i.IsHidden,
+			&i.CreatedAt,
+			&i.Reasons,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProductReviews = `-- name: ListProductReviews :many
+SELECT r.id, r.order_item_id, r.product_id, r.seller_id, r.buyer_id, r.rating, r.body, r.seller_reply, r.replied_at, r.flag_count, r.is_hidden, r.created_at, u.name AS reviewer_name
+FROM reviews r
+JOIN users u ON u.id = r.buyer_id
+WHERE r.product_id = $1 AND NOT r.is_hidden
+ORDER BY r.created_at DESC
+`
+
+type ListProductReviewsRow struct {
+	ID           int64
+	OrderItemID  int64
+	ProductID    int64
+	SellerID     int64
+	BuyerID      int64
+	Rating       int32
+	Body         string
+	SellerReply  pgtype.Text
+	RepliedAt    pgtype.Timestamptz
+	FlagCount    int32
+	IsHidden     bool
+	CreatedAt    pgtype.Timestamptz
+	ReviewerName string
+}
+
+func (q *Queries) ListProductReviews(ctx context.Context, productID int64) ([]ListProductReviewsRow, error) {
+	rows, err := q.db.Query(ctx, listProductReviews, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProductReviewsRow
+	for rows.Next() {
+		var i ListProductReviewsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderItemID,
+			&i.ProductID,
+			&i.SellerID,
+			&i.BuyerID,
+			&i.Rating,
+			&i.Body,
+			&i.SellerReply,
+			&i.RepliedAt,
+			&i.FlagCount,
+			&i.IsHidden,
+			&i.CreatedAt,
+			&i.ReviewerName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReviewableItems = `-- name: ListReviewableItems :many
+SELECT oi.id AS order_item_id, oi.order_id, oi.product_id, p.name AS product_name, oi.seller_id, so.completed_at
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN sub_orders so ON so.id = oi.sub_order_id
+JOIN products p ON p.id = oi.product_id
+LEFT JOIN reviews r ON r.order_item_id = oi.id
+WHERE o.user_id = $1
+  AND so.status = 'completed'
+  AND r.id IS NULL
+ORDER BY so.completed_at DESC
+`
+
+type ListReviewableItemsRow struct {
+	OrderItemID int64
+	OrderID     int64
+	ProductID   int64
+	ProductName string
+	SellerID    int64
+	CompletedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListReviewableItems(ctx context.Context, userID int64) ([]ListReviewableItemsRow, error) {
+	rows, err := q.db.Query(ctx, listReviewableItems, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReviewableItemsRow
+	for rows.Next() {
+		var i ListReviewableItemsRow
+		if err := rows.Scan(
+			&i.OrderItemID,
+			&i.OrderID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.SellerID,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSellerReviews = `-- name: ListSellerReviews :many
+SELECT r.id, r.order_item_id, r.product_id, r.seller_id, r.buyer_id, r.rating, r.body, r.seller_reply, r.replied_at, r.flag_count, r.is_hidden, r.created_at, u.name AS reviewer_name, p.name AS product_name
+FROM reviews r
+JOIN users u ON u.id = r.buyer_id
+JOIN products p ON p.id = r.product_id
+WHERE r.seller_id = $1 AND NOT r.is_hidden
+ORDER BY r.created_at DESC
+`
+
+type ListSellerReviewsRow struct {
+	ID           int64
+	OrderItemID  int64
+	ProductID    int64
+	SellerID     int64
+	BuyerID      int64
+	Rating       int32
+	Body         string
+	SellerReply  pgtype.Text
+	RepliedAt    pgtype.Timestamptz
+	FlagCount    int32
+	IsHidden     bool
+	CreatedAt    pgtype.Timestamptz
+	ReviewerName string
+	ProductName  string
+}
+
+func (q *Queries) ListSellerReviews(ctx context.Context, sellerID int64) ([]ListSellerReviewsRow, error) {
+	rows, err := q.db.Query(ctx, listSellerReviews, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSellerReviewsRow
+	for rows.Next() {
+		var i ListSellerReviewsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderItemID,
+			&i.ProductID,
+			&i.SellerID,
+			&i.BuyerID,
+			&i.Rating,
+			&i.Body,
+			&i.SellerReply,
+			&i.RepliedAt,
+			&i.FlagCount,
+			&i.IsHidden,
+			&i.CreatedAt,
+			&i.ReviewerName,
+			&i.ProductName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setReviewHidden = `-- name: SetReviewHidden :one
+UPDATE reviews
+SET is_hidden = $2
+WHERE id = $1
+RETURNING id, order_item_id, product_id, seller_id, buyer_id, rating, body, seller_reply, replied_at, flag_count, is_hidden, created_at
+`
+
+type SetReviewHiddenParams struct {
+	ID       int64
+	IsHidden bool
+}
+
+func (q *Queries) SetReviewHidden(ctx context.Context, arg SetReviewHiddenParams) (Review, error) {
+	row := q.db.QueryRow(ctx, setReviewHidden, arg.ID, arg.IsHidden)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.OrderItemID,
+		&i.ProductID,
+		&i.SellerID,
+		&i.BuyerID,
+		&i.Rating,
+		&i.Body,
+		&i.SellerReply,
+		&i.RepliedAt,
+		&i.FlagCount,
+		&i.IsHidden,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const setReviewReply = `-- name: SetReviewReply :one
+UPDATE reviews
+SET seller_reply = $1, replied_at = NOW()
+WHERE id = $2 AND seller_id = $3 AND NOT is_hidden
+RETURNING id, order_item_id, product_id, seller_id, buyer_id, rating, body, seller_reply, replied_at, flag_count, is_hidden, created_at
+`
+
+type SetReviewReplyParams struct {
+	Reply    pgtype.Text
+	ID       int64
+	SellerID int64
+}
+
+func (q *Queries) SetReviewReply(ctx context.Context, arg SetReviewReplyParams) (Review, error) {
+	row := q.db.QueryRow(ctx, setReviewReply, arg.Reply, arg.ID, arg.SellerID)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.OrderItemID,
+		&i.ProductID,
+		&i.SellerID,
+		&i.BuyerID,
+		&i.Rating,
+		&i.Body,
+		&i.SellerReply,
+		&i.RepliedAt,
+		&i.FlagCount,
+		&i.IsHidden,
+		&i.CreatedAt,
+	)
+	return i, err
+}
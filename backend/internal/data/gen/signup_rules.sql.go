@@ -0,0 +1,126 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: signup_rules.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createSignupEmailRule = `-- name: CreateSignupEmailRule :one
+INSERT INTO signup_email_rules (pattern, rule, note, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING id, pattern, rule, note, created_by, created_at
+`
+
+type CreateSignupEmailRuleParams struct {
+	Pattern   string
+	Rule      string
+	Note      string
+	CreatedBy pgtype.Int8
+}
+
+func (q *Queries) CreateSignupEmailRule(ctx context.Context, arg CreateSignupEmailRuleParams) (SignupEmailRule, error) {
+	row := q.db.QueryRow(ctx, createSignupEmailRule,
+		arg.Pattern,
+		arg.Rule,
+		arg.Note,
+		arg.CreatedBy,
+	)
+	var i SignupEmailRule
+	err := row.Scan(
+		&i.ID,
+		&i.Pattern,
+		&i.Rule,
+		&i.Note,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteSignupEmailRule = `-- name: DeleteSignupEmailRule :execrows
+DELETE FROM signup_email_rules
+WHERE id = $1
+`
+
+func (q *Queries) DeleteSignupEmailRule(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSignupEmailRule, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listSignupEmailRules = `-- name: ListSignupEmailRules :many
+SELECT id, pattern, rule, note, created_by, created_at FROM signup_email_rules
+ORDER BY rule, pattern
+`
+
+func (q *Queries) ListSignupEmailRules(ctx context.Context) ([]SignupEmailRule, error) {
+	rows, err := q.db.Query(ctx, listSignupEmailRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SignupEmailRule
+	for rows.Next() {
+		var i SignupEmailRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Pattern,
+			&i.Rule,
+			&i.Note,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const matchSignupEmailRules = `-- name: MatchSignupEmailRules :many
+SELECT id, pattern, rule, note, created_by, created_at FROM signup_email_rules
+WHERE pattern = $1::text OR pattern = $2::text
+`
+
+type MatchSignupEmailRulesParams struct {
+	Email  string
+	Domain string
+}
+
+func (q *Queries) MatchSignupEmailRules(ctx context.Context, arg MatchSignupEmailRulesParams) ([]SignupEmailRule, error) {
+	rows, err := q.db.Query(ctx, matchSignupEmailRules, arg.Email, arg.Domain)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SignupEmailRule
+	for rows.Next() {
+		var i SignupEmailRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Pattern,
+			&i.Rule,
+			&i.Note,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -11,13 +11,80 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const clearUserPassword = `-- name: ClearUserPassword :exec
+UPDATE users
+SET
+  password_hash = NULL,
+  updated_at = CURRENT_TIMESTAMP,
+  version = version + 1
+WHERE id = $1
+`
+
+func (q *Queries) ClearUserPassword(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, clearUserPassword, id)
+	return err
+}
+
+const createGoogleUser = `-- name: CreateGoogleUser :one
+INSERT INTO users (
+  name, email, google_id, email_verified, roll_number, batch, branch
+) VALUES (
+  $1, $2, $3, TRUE, $4, $5, $6
+)
+RETURNING id, name, email, password_hash, google_id, upi_id, phone_number, created_at, updated_at, email_verified, user_type, version, rating_total, rating_count, alert_mode, roll_number, batch, branch, avatar_url, hostel
+`
+
+type CreateGoogleUserParams struct {
+	Name       string
+	Email      string
+	GoogleID   pgtype.Text
+	RollNumber pgtype.Text
+	Batch      pgtype.Text
+	Branch     pgtype.Text
+}
+
+func (q *Queries) CreateGoogleUser(ctx context.Context, arg CreateGoogleUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, createGoogleUser,
+		arg.Name,
+		arg.Email,
+		arg.GoogleID,
+		arg.RollNumber,
+		arg.Batch,
+		arg.Branch,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.GoogleID,
+		&i.UpiID,
+		&i.PhoneNumber,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailVerified,
+		&i.UserType,
+		&i.Version,
+		&i.RatingTotal,
+		&i.RatingCount,
+		&i.AlertMode,
+		&i.RollNumber,
+		&i.Batch,
+		&i.Branch,
+		&i.AvatarUrl,
+		&i.Hostel,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
-  name, email, phone_number, password_hash
+  name, email, phone_number, password_hash, roll_number, batch, branch
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6, $7
 )
-RETURNING id, name, email, password_hash, google_id, upi_id, phone_number, created_at, updated_at, email_verified, user_type, version
+RETURNING id, name, email, password_hash, google_id, upi_id, phone_number, created_at, updated_at, email_verified, user_type, version, rating_total, rating_count, alert_mode, roll_number, batch, branch, avatar_url, hostel
 `
 
 type CreateUserParams struct {
@@ -25,6 +92,9 @@ type CreateUserParams struct {
 	Email        string
 	PhoneNumber  pgtype.Text
 	PasswordHash pgtype.Text
+	RollNumber   pgtype.Text
+	Batch        pgtype.Text
+	Branch       pgtype.Text
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -33,6 +103,9 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.Email,
 		arg.PhoneNumber,
 		arg.PasswordHash,
+		arg.RollNumber,
+		arg.Batch,
+		arg.Branch,
 	)
 	var i User
 	err := row.Scan(
@@ -48,6 +121,47 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.EmailVerified,
 		&i.UserType,
 		&i.Version,
+		&i.RatingTotal,
+		&i.RatingCount,
+		&i.AlertMode,
+		&i.RollNumber,
+		&i.Batch,
+		&i.Branch,
+		&i.AvatarUrl,
+		&i.Hostel,
+	)
+	return i, err
+}
+
+const getSellerSummary = `-- name: GetSellerSummary :one
+SELECT id, name, created_at, email_verified, rating_total, rating_count, avatar_url, hostel
+FROM users
+WHERE id = $1
+`
+
+type GetSellerSummaryRow struct {
+	ID            int32
+	Name          string
+	CreatedAt     pgtype.Timestamp
+	EmailVerified bool
+	RatingTotal   int32
+	RatingCount   int32
+	AvatarUrl     pgtype.Text
+	Hostel        pgtype.Text
+}
+
+func (q *Queries) GetSellerSummary(ctx context.Context, id int32) (GetSellerSummaryRow, error) {
+	row := q.db.QueryRow(ctx, getSellerSummary, id)
+	var i GetSellerSummaryRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.EmailVerified,
+		&i.RatingTotal,
+		&i.RatingCount,
+		&i.AvatarUrl,
+		&i.Hostel,
 	)
 	return i, err
 }
@@ -78,7 +192,7 @@ func (q *Queries) GetUserAuthByEmail(ctx context.Context, email string) (GetUser
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, password_hash, google_id, upi_id, phone_number, created_at, updated_at, email_verified, user_type, version FROM users
+SELECT id, name, email, password_hash, google_id, upi_id, phone_number, created_at, updated_at, email_verified, user_type, version, rating_total, rating_count, alert_mode, roll_number, batch, branch, avatar_url, hostel FROM users
 WHERE email = $1
 `
 
@@ -98,12 +212,54 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.EmailVerified,
 		&i.UserType,
 		&i.Version,
+		&i.RatingTotal,
+		&i.RatingCount,
+		&i.AlertMode,
+		&i.RollNumber,
+		&i.Batch,
+		&i.Branch,
+		&i.AvatarUrl,
+		&i.Hostel,
+	)
+	return i, err
+}
+
+const getUserByGoogleID = `-- name: GetUserByGoogleID :one
+SELECT id, name, email, password_hash, google_id, upi_id, phone_number, created_at, updated_at, email_verified, user_type, version, rating_total, rating_count, alert_mode, roll_number, batch, branch, avatar_url, hostel FROM users
+WHERE google_id = $1
+`
+
+func (q *Queries) GetUserByGoogleID(ctx context.Context, googleID pgtype.Text) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByGoogleID, googleID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.GoogleID,
+		&i.UpiID,
+		&i.PhoneNumber,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailVerified,
+		&i.UserType,
+		&i.Version,
+		&i.RatingTotal,
+		&i.RatingCount,
+		&i.AlertMode,
+		&i.RollNumber,
+		&i.Batch,
+		&i.Branch,
+		&i.AvatarUrl,
+		&i.Hostel,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, upi_id, phone_number, email_verified, created_at, version
+SELECT id, name, email, upi_id, phone_number, email_verified, user_type, created_at, version,
+  avatar_url, hostel, roll_number, batch, branch
 FROM users
 WHERE id = $1
 `
@@ -115,8 +271,14 @@ type GetUserByIDRow struct {
 	UpiID         pgtype.Text
 	PhoneNumber   pgtype.Text
 	EmailVerified bool
+	UserType      string
 	CreatedAt     pgtype.Timestamp
 	Version       int32
+	AvatarUrl     pgtype.Text
+	Hostel        pgtype.Text
+	RollNumber    pgtype.Text
+	Batch         pgtype.Text
+	Branch        pgtype.Text
 }
 
 func (q *Queries) GetUserByID(ctx context.Context, id int32) (GetUserByIDRow, error) {
@@ -129,8 +291,91 @@ func (q *Queries) GetUserByID(ctx context.Context, id int32) (GetUserByIDRow, er
 		&i.UpiID,
 		&i.PhoneNumber,
 		&i.EmailVerified,
+		&i.UserType,
+		&i.CreatedAt,
+		&i.Version,
+		&i.AvatarUrl,
+		&i.Hostel,
+		&i.RollNumber,
+		&i.Batch,
+		&i.Branch,
+	)
+	return i, err
+}
+
+const getUserPasswordHash = `-- name: GetUserPasswordHash :one
+SELECT password_hash FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserPasswordHash(ctx context.Context, id int32) (pgtype.Text, error) {
+	row := q.db.QueryRow(ctx, getUserPasswordHash, id)
+	var password_hash pgtype.Text
+	err := row.Scan(&password_hash)
+	return password_hash, err
+}
+
+const linkGoogleAccount = `-- name: LinkGoogleAccount :execrows
+UPDATE users
+SET
+  google_id = $1,
+  email_verified = TRUE,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = $2 AND google_id IS NULL
+`
+
+type LinkGoogleAccountParams struct {
+	GoogleID pgtype.Text
+	ID       int32
+}
+
+func (q *Queries) LinkGoogleAccount(ctx context.Context, arg LinkGoogleAccountParams) (int64, error) {
+	result, err := q.db.Exec(ctx, linkGoogleAccount, arg.GoogleID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateUserAvatar = `-- name: UpdateUserAvatar :one
+UPDATE users
+SET
+  avatar_url = $1,
+  updated_at = CURRENT_TIMESTAMP,
+  version = version + 1
+WHERE id = $2
+RETURNING id, name, email, password_hash, google_id, upi_id, phone_number, created_at, updated_at, email_verified, user_type, version, rating_total, rating_count, alert_mode, roll_number, batch, branch, avatar_url, hostel
+`
+
+type UpdateUserAvatarParams struct {
+	AvatarUrl pgtype.Text
+	ID        int32
+}
+
+func (q *Queries) UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserAvatar, arg.AvatarUrl, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PasswordHash,
+		&i.GoogleID,
+		&i.UpiID,
+		&i.PhoneNumber,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailVerified,
+		&i.UserType,
 		&i.Version,
+		&i.RatingTotal,
+		&i.RatingCount,
+		&i.AlertMode,
+		&i.RollNumber,
+		&i.Batch,
+		&i.Branch,
+		&i.AvatarUrl,
+		&i.Hostel,
 	)
 	return i, err
 }
@@ -139,7 +384,9 @@ const updateUserEmail = `-- name: UpdateUserEmail :exec
 UPDATE users
 SET
     email = $1,
-    updated_at = CURRENT_TIMESTAMP
+    email_verified = TRUE,
+    updated_at = CURRENT_TIMESTAMP,
+    version = version + 1
 where id = $2
 `
 
@@ -153,28 +400,53 @@ func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams
 	return err
 }
 
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET
+  password_hash = $1,
+  updated_at = CURRENT_TIMESTAMP,
+  version = version + 1
+WHERE id = $2
+`
+
+type UpdateUserPasswordParams struct {
+	PasswordHash pgtype.Text
+	ID           int32
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateUserPassword, arg.PasswordHash, arg.ID)
+	return err
+}
+
 const updateUserProfile = `-- name: UpdateUserProfile :one
 UPDATE users
 SET 
   name = $1, 
   upi_id = $2,
+  phone_number = $3,
+  hostel = $4,
   updated_at = CURRENT_TIMESTAMP,
   version = version + 1
-WHERE id = $3 AND version = $4
-RETURNING id, name, email, password_hash, google_id, upi_id, phone_number, created_at, updated_at, email_verified, user_type, version
+WHERE id = $5 AND version = $6
+RETURNING id, name, email, password_hash, google_id, upi_id, phone_number, created_at, updated_at, email_verified, user_type, version, rating_total, rating_count, alert_mode, roll_number, batch, branch, avatar_url, hostel
 `
 
 type UpdateUserProfileParams struct {
-	Name    string
-	UpiID   pgtype.Text
-	ID      int32
-	Version int32
+	Name        string
+	UpiID       pgtype.Text
+	PhoneNumber pgtype.Text
+	Hostel      pgtype.Text
+	ID          int32
+	Version     int32
 }
 
 func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
 	row := q.db.QueryRow(ctx, updateUserProfile,
 		arg.Name,
 		arg.UpiID,
+		arg.PhoneNumber,
+		arg.Hostel,
 		arg.ID,
 		arg.Version,
 	)
@@ -192,6 +464,14 @@ func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfilePa
 		&i.EmailVerified,
 		&i.UserType,
 		&i.Version,
+		&i.RatingTotal,
+		&i.RatingCount,
+		&i.AlertMode,
+		&i.RollNumber,
+		&i.Batch,
+		&i.Branch,
+		&i.AvatarUrl,
+		&i.Hostel,
 	)
 	return i, err
 }
@@ -108,6 +108,18 @@ func (q *Queries) GetBalanceById(ctx context.Context, userID int32) (Wallet, err
 	return i, err
 }
 
+const getPlatformAccount = `-- name: GetPlatformAccount :one
+SELECT user_id FROM platform_accounts
+WHERE name = $1
+`
+
+func (q *Queries) GetPlatformAccount(ctx context.Context, name string) (int32, error) {
+	row := q.db.QueryRow(ctx, getPlatformAccount, name)
+	var user_id int32
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
 const getTransactionByID = `-- name: GetTransactionByID :one
 SELECT id, user_id, amount, transaction_status, transaction_type, related_user_id, razorpay_order_id, razorpay_payment_id, metadata, created_at, updated_at
 FROM wallet_transactions
@@ -0,0 +1,122 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: wishlist.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addWishlistItem = `-- name: AddWishlistItem :exec
+INSERT INTO wishlist_items (user_id, product_id, price_at_add)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, product_id) DO NOTHING
+`
+
+type AddWishlistItemParams struct {
+	UserID     int64
+	ProductID  int64
+	PriceAtAdd int32
+}
+
+func (q *Queries) AddWishlistItem(ctx context.Context, arg AddWishlistItemParams) error {
+	_, err := q.db.Exec(ctx, addWishlistItem, arg.UserID, arg.ProductID, arg.PriceAtAdd)
+	return err
+}
+
+const deleteWishlistItem = `-- name: DeleteWishlistItem :execrows
+DELETE FROM wishlist_items
+WHERE user_id = $1 AND product_id = $2
+`
+
+type DeleteWishlistItemParams struct {
+	UserID    int64
+	ProductID int64
+}
+
+func (q *Queries) DeleteWishlistItem(ctx context.Context, arg DeleteWishlistItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWishlistItem, arg.UserID, arg.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getWishlist = `-- name: GetWishlist :many
+SELECT
+    w.product_id,
+    w.price_at_add,
+    w.created_at AS added_at,
+    p.name,
+    p.price,
+    p.stock,
+    p.image_url,
+    p.is_active
+FROM wishlist_items w
+JOIN products p ON p.id = w.product_id
+WHERE w.user_id = $1
+ORDER BY w.created_at DESC
+`
+
+type GetWishlistRow struct {
+	ProductID  int64
+	PriceAtAdd int32
+	AddedAt    pgtype.Timestamptz
+	Name       string
+	Price      int32
+	Stock      int32
+	ImageUrl   pgtype.Text
+	IsActive   bool
+}
+
+func (q *Queries) GetWishlist(ctx context.Context, userID int64) ([]GetWishlistRow, error) {
+	rows, err := q.db.Query(ctx, getWishlist, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWishlistRow
+	for rows.Next() {
+		var i GetWishlistRow
+		if err := rows.Scan(
+			&i.ProductID,
+			&i.PriceAtAdd,
+			&i.AddedAt,
+			&i.Name,
+			&i.Price,
+			&i.Stock,
+			&i.ImageUrl,
+			&i.IsActive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isInWishlist = `-- name: IsInWishlist :one
+SELECT EXISTS (
+    SELECT 1 FROM wishlist_items
+    WHERE user_id = $1 AND product_id = $2
+)
+`
+
+type IsInWishlistParams struct {
+	UserID    int64
+	ProductID int64
+}
+
+func (q *Queries) IsInWishlist(ctx context.Context, arg IsInWishlistParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isInWishlist, arg.UserID, arg.ProductID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
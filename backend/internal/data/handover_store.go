@@ -0,0 +1,147 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type HandoverStore interface {
+	ListCampusLocations(ctx context.Context) ([]db.CampusLocation, error)
+	CreatePickupSlot(ctx context.Context, subOrderID, locationID int64, startsAt, endsAt time.Time) (db.PickupSlot, error)
+	ListPickupSlots(ctx context.Context, subOrderID int64) ([]db.ListPickupSlotsRow, error)
+	DeletePickupSlots(ctx context.Context, subOrderID int64) error
+	GetPickupSlot(ctx context.Context, subOrderID, slotID int64) (db.PickupSlot, error)
+	GetHandover(ctx context.Context, subOrderID int64) (db.Handover, error)
+	UpsertHandover(ctx context.Context, subOrderID, slotID int64, codeHash string, expiresAt time.Time) (db.Handover, error)
+	ClaimHandoverAttempt(ctx context.Context, subOrderID int64, maxAttempts int32) (db.Handover, error)
+	CompleteHandover(ctx context.Context, subOrderID int64, codeHash string) error
+	WithTx(tx pgx.Tx) HandoverStore
+}
+
+type sqlHandoverStore struct {
+	q *db.Queries
+}
+
+func NewHandoverStore(queries *db.Queries) HandoverStore {
+	return &sqlHandoverStore{
+		q: queries,
+	}
+}
+
+func (s *sqlHandoverStore) WithTx(tx pgx.Tx) HandoverStore {
+	return &sqlHandoverStore{
+		q: db.New(tx),
+	}
+}
+
+func (s *sqlHandoverStore) ListCampusLocations(ctx context.Context) ([]db.CampusLocation, error) {
+	return s.q.ListCampusLocations(ctx)
+}
+
+// CreatePickupSlot reports ErrRecordNotFound when the location doesn't exist
+// or is no longer in use.
+func (s *sqlHandoverStore) CreatePickupSlot(ctx context.Context, subOrderID, locationID int64, startsAt, endsAt time.Time) (db.PickupSlot, error) {
+	slot, err := s.q.CreatePickupSlot(ctx, db.CreatePickupSlotParams{
+		SubOrderID: subOrderID,
+		LocationID: locationID,
+		StartsAt:   pgtype.Timestamptz{Time: startsAt, Valid: true},
+		EndsAt:     pgtype.Timestamptz{Time: endsAt, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.PickupSlot{}, ErrRecordNotFound
+		}
+		return db.PickupSlot{}, err
+	}
+	return slot, nil
+}
+
+func (s *sqlHandoverStore) ListPickupSlots(ctx context.Context, subOrderID int64) ([]db.ListPickupSlotsRow, error) {
+	return s.q.ListPickupSlots(ctx, subOrderID)
+}
+
+func (s *sqlHandoverStore) DeletePickupSlots(ctx context.Context, subOrderID int64) error {
+	return s.q.DeletePickupSlots(ctx, subOrderID)
+}
+
+func (s *sqlHandoverStore) GetPickupSlot(ctx context.Context, subOrderID, slotID int64) (db.PickupSlot, error) {
+	slot, err := s.q.GetPickupSlot(ctx, db.GetPickupSlotParams{
+		ID:         slotID,
+		SubOrderID: subOrderID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.PickupSlot{}, ErrRecordNotFound
+		}
+		return db.PickupSlot{}, err
+	}
+	return slot, nil
+}
+
+func (s *sqlHandoverStore) GetHandover(ctx context.Context, subOrderID int64) (db.Handover, error) {
+	handover, err := s.q.GetHandover(ctx, subOrderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Handover{}, ErrRecordNotFound
+		}
+		return db.Handover{}, err
+	}
+	return handover, nil
+}
+
+// UpsertHandover sets the chosen slot and a fresh code, resetting the failed
+// attempts. It reports ErrRecordNotFound once the handover has happened.
+func (s *sqlHandoverStore) UpsertHandover(ctx context.Context, subOrderID, slotID int64, codeHash string, expiresAt time.Time) (db.Handover, error) {
+	handover, err := s.q.UpsertHandover(ctx, db.UpsertHandoverParams{
+		SubOrderID:    subOrderID,
+		SlotID:        slotID,
+		CodeHash:      NewPGText(codeHash),
+		CodeExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Handover{}, ErrRecordNotFound
+		}
+		return db.Handover{}, err
+	}
+	return handover, nil
+}
+
+// ClaimHandoverAttempt counts an attempt at the code before it is compared,
+// so concurrent guesses can't get past maxAttempts. It reports
+// ErrRecordNotFound when there is no live code or no attempts are left.
+func (s *sqlHandoverStore) ClaimHandoverAttempt(ctx context.Context, subOrderID int64, maxAttempts int32) (db.Handover, error) {
+	handover, err := s.q.ClaimHandoverAttempt(ctx, db.ClaimHandoverAttemptParams{
+		SubOrderID:     subOrderID,
+		FailedAttempts: maxAttempts,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Handover{}, ErrRecordNotFound
+		}
+		return db.Handover{}, err
+	}
+	return handover, nil
+}
+
+// CompleteHandover consumes the code and takes back the attempt claimed for
+// it, since it wasn't a failed one. It reports ErrRecordNotFound if the code
+// was replaced or already used in the meantime.
+func (s *sqlHandoverStore) CompleteHandover(ctx context.Context, subOrderID int64, codeHash string) error {
+	rows, err := s.q.CompleteHandover(ctx, db.CompleteHandoverParams{
+		SubOrderID: subOrderID,
+		CodeHash:   NewPGText(codeHash),
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
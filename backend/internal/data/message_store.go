@@ -0,0 +1,182 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrDuplicateReport = errors.New("message already reported")
+
+type MessageStore interface {
+	UpsertProductConversation(ctx context.Context, productID, buyerID, sellerID int64) (db.Conversation, error)
+	UpsertSubOrderConversation(ctx context.Context, subOrderID, buyerID, sellerID int64) (db.Conversation, error)
+	GetConversation(ctx context.Context, id int64) (db.Conversation, error)
+	ListConversationsForUser(ctx context.Context, userID int64) ([]db.ListConversationsForUserRow, error)
+	CountUnreadMessages(ctx context.Context, userID int64) (int64, error)
+	ListMessages(ctx context.Context, conversationID int64, beforeID *int64, limit int32) ([]db.Message, error)
+	CreateMessage(ctx context.Context, conversationID, senderID int64, body string) (db.Message, error)
+	GetMessage(ctx context.Context, id int64) (db.Message, error)
+	TouchConversation(ctx context.Context, id, senderID, messageID int64) error
+	MarkConversationRead(ctx context.Context, id, userID int64) error
+	BlockUser(ctx context.Context, blockerID, blockedID int64) error
+	UnblockUser(ctx context.Context, blockerID, blockedID int64) error
+	ListBlockedUsers(ctx context.Context, blockerID int64) ([]db.ListBlockedUsersRow, error)
+	IsBlockedBetween(ctx context.Context, userA, userB int64) (bool, error)
+	CreateMessageReport(ctx context.Context, arg db.CreateMessageReportParams) (db.MessageReport, error)
+	ListMessageReports(ctx context.Context) ([]db.ListMessageReportsRow, error)
+	WithTx(tx pgx.Tx) MessageStore
+}
+
+type sqlMessageStore struct {
+	q *db.Queries
+}
+
+func NewMessageStore(queries *db.Queries) MessageStore {
+	return &sqlMessageStore{
+		q: queries,
+	}
+}
+
+func (s *sqlMessageStore) WithTx(tx pgx.Tx) MessageStore {
+	return &sqlMessageStore{
+		q: db.New(tx),
+	}
+}
+
+func (s *sqlMessageStore) UpsertProductConversation(ctx context.Context, productID, buyerID, sellerID int64) (db.Conversation, error) {
+	return s.q.UpsertProductConversation(ctx, db.UpsertProductConversationParams{
+		ProductID: NewPGInt64(productID),
+		BuyerID:   buyerID,
+		SellerID:  sellerID,
+	})
+}
+
+func (s *sqlMessageStore) UpsertSubOrderConversation(ctx context.Context, subOrderID, buyerID, sellerID int64) (db.Conversation, error) {
+	return s.q.UpsertSubOrderConversation(ctx, db.UpsertSubOrderConversationParams{
+		SubOrderID: NewPGInt64(subOrderID),
+		BuyerID:    buyerID,
+		SellerID:   sellerID,
+	})
+}
+
+func (s *sqlMessageStore) GetConversation(ctx context.Context, id int64) (db.Conversation, error) {
+	conversation, err := s.q.GetConversation(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Conversation{}, ErrRecordNotFound
+		}
+		return db.Conversation{}, err
+	}
+	return conversation, nil
+}
+
+func (s *sqlMessageStore) ListConversationsForUser(ctx context.Context, userID int64) ([]db.ListConversationsForUserRow, error) {
+	return s.q.ListConversationsForUser(ctx, userID)
+}
+
+func (s *sqlMessageStore) CountUnreadMessages(ctx context.Context, userID int64) (int64, error) {
+	return s.q.CountUnreadMessages(ctx, userID)
+}
+
+// ListMessages returns up to limit messages, newest first. With beforeID it
+// returns the page of messages older than that one.
+func (s *sqlMessageStore) ListMessages(ctx context.Context, conversationID int64, beforeID *int64, limit int32) ([]db.Message, error) {
+	arg := db.ListMessagesParams{
+		ConversationID: conversationID,
+		MaxRows:        limit,
+	}
+	if beforeID != nil {
+		arg.BeforeID = NewPGInt64(*beforeID)
+	}
+	return s.q.ListMessages(ctx, arg)
+}
+
+func (s *sqlMessageStore) CreateMessage(ctx context.Context, conversationID, senderID int64, body string) (db.Message, error) {
+	return s.q.CreateMessage(ctx, db.CreateMessageParams{
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		Body:           body,
+	})
+}
+
+func (s *sqlMessageStore) GetMessage(ctx context.Context, id int64) (db.Message, error) {
+	message, err := s.q.GetMessage(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Message{}, ErrRecordNotFound
+		}
+		return db.Message{}, err
+	}
+	return message, nil
+}
+
+func (s *sqlMessageStore) TouchConversation(ctx context.Context, id, senderID, messageID int64) error {
+	return s.q.TouchConversation(ctx, db.TouchConversationParams{
+		ID:        id,
+		SenderID:  senderID,
+		MessageID: messageID,
+	})
+}
+
+func (s *sqlMessageStore) MarkConversationRead(ctx context.Context, id, userID int64) error {
+	return s.q.MarkConversationRead(ctx, db.MarkConversationReadParams{
+		ID:     id,
+		UserID: userID,
+	})
+}
+
+// BlockUser reports ErrRecordNotFound when the user being blocked doesn't
+// exist.
+func (s *sqlMessageStore) BlockUser(ctx context.Context, blockerID, blockedID int64) error {
+	err := s.q.BlockUser(ctx, db.BlockUserParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if e, ok := pgErr(err); ok && e.Code == "23503" {
+		return ErrRecordNotFound
+	}
+	return err
+}
+
+func (s *sqlMessageStore) UnblockUser(ctx context.Context, blockerID, blockedID int64) error {
+	rows, err := s.q.UnblockUser(ctx, db.UnblockUserParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *sqlMessageStore) ListBlockedUsers(ctx context.Context, blockerID int64) ([]db.ListBlockedUsersRow, error) {
+	return s.q.ListBlockedUsers(ctx, blockerID)
+}
+
+func (s *sqlMessageStore) IsBlockedBetween(ctx context.Context, userA, userB int64) (bool, error) {
+	return s.q.IsBlockedBetween(ctx, db.IsBlockedBetweenParams{
+		UserA: userA,
+		UserB: userB,
+	})
+}
+
+func (s *sqlMessageStore) CreateMessageReport(ctx context.Context, arg db.CreateMessageReportParams) (db.MessageReport, error) {
+	report, err := s.q.CreateMessageReport(ctx, arg)
+	if err != nil {
+		if e, ok := pgErr(err); ok && e.Code == "23505" {
+			return db.MessageReport{}, ErrDuplicateReport
+		}
+		return db.MessageReport{}, err
+	}
+	return report, nil
+}
+
+func (s *sqlMessageStore) ListMessageReports(ctx context.Context) ([]db.ListMessageReportsRow, error) {
+	return s.q.ListMessageReports(ctx)
+}
@@ -0,0 +1,120 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrDuplicateListingReport = errors.New("listing already reported")
+	ErrDuplicateBannedTerm    = errors.New("banned term already exists")
+)
+
+type ModerationStore interface {
+	CreateListingReport(ctx context.Context, arg db.CreateListingReportParams) (db.ListingReport, error)
+	CountOpenListingReports(ctx context.Context, productID int64) (int64, error)
+	ListListingReports(ctx context.Context, productID int64) ([]db.ListListingReportsRow, error)
+	ResolveListingReports(ctx context.Context, productID int64, status string, resolvedBy int64) error
+	SetProductModeration(ctx context.Context, arg db.SetProductModerationParams) (db.Product, error)
+	ListModerationQueue(ctx context.Context) ([]db.ListModerationQueueRow, error)
+	ListBannedTerms(ctx context.Context) ([]db.BannedTerm, error)
+	CreateBannedTerm(ctx context.Context, pattern string, isRegex bool, createdBy int64) (db.BannedTerm, error)
+	DeleteBannedTerm(ctx context.Context, id int64) error
+	WithTx(tx pgx.Tx) ModerationStore
+}
+
+type sqlModerationStore struct {
+	q *db.Queries
+}
+
+func NewModerationStore(queries *db.Queries) ModerationStore {
+	return &sqlModerationStore{
+		q: queries,
+	}
+}
+
+func (s *sqlModerationStore) WithTx(tx pgx.Tx) ModerationStore {
+	return &sqlModerationStore{
+		q: db.New(tx),
+	}
+}
+
+func (s *sqlModerationStore) CreateListingReport(ctx context.Context, arg db.CreateListingReportParams) (db.ListingReport, error) {
+	report, err := s.q.CreateListingReport(ctx, arg)
+	if err != nil {
+		if e, ok := pgErr(err); ok && e.Code == "23505" {
+			return db.ListingReport{}, ErrDuplicateListingReport
+		}
+		return db.ListingReport{}, err
+	}
+	return report, nil
+}
+
+func (s *sqlModerationStore) CountOpenListingReports(ctx context.Context, productID int64) (int64, error) {
+	return s.q.CountOpenListingReports(ctx, productID)
+}
+
+func (s *sqlModerationStore) ListListingReports(ctx context.Context, productID int64) ([]db.ListListingReportsRow, error) {
+	return s.q.ListListingReports(ctx, productID)
+}
+
+// ResolveListingReports closes every open report on a listing. A zero
+// resolvedBy records no moderator, for reports closed automatically.
+func (s *sqlModerationStore) ResolveListingReports(ctx context.Context, productID int64, status string, resolvedBy int64) error {
+	arg := db.ResolveListingReportsParams{
+		ProductID: productID,
+		Status:    status,
+	}
+	if resolvedBy != 0 {
+		arg.ResolvedBy = NewPGInt64(resolvedBy)
+	}
+	return s.q.ResolveListingReports(ctx, arg)
+}
+
+func (s *sqlModerationStore) SetProductModeration(ctx context.Context, arg db.SetProductModerationParams) (db.Product, error) {
+	product, err := s.q.SetProductModeration(ctx, arg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Product{}, ErrRecordNotFound
+		}
+		return db.Product{}, err
+	}
+	return product, nil
+}
+
+func (s *sqlModerationStore) ListModerationQueue(ctx context.Context) ([]db.ListModerationQueueRow, error) {
+	return s.q.ListModerationQueue(ctx)
+}
+
+func (s *sqlModerationStore) ListBannedTerms(ctx context.Context) ([]db.BannedTerm, error) {
+	return s.q.ListBannedTerms(ctx)
+}
+
+func (s *sqlModerationStore) CreateBannedTerm(ctx context.Context, pattern string, isRegex bool, createdBy int64) (db.BannedTerm, error) {
+	term, err := s.q.CreateBannedTerm(ctx, db.CreateBannedTermParams{
+		Pattern:   pattern,
+		IsRegex:   isRegex,
+		CreatedBy: NewPGInt64(createdBy),
+	})
+	if err != nil {
+		if e, ok := pgErr(err); ok && e.Code == "23505" {
+			return db.BannedTerm{}, ErrDuplicateBannedTerm
+		}
+		return db.BannedTerm{}, err
+	}
+	return term, nil
+}
+
+func (s *sqlModerationStore) DeleteBannedTerm(ctx context.Context, id int64) error {
+	rows, err := s.q.DeleteBannedTerm(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
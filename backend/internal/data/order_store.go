@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	db "ecommerce/internal/data/gen"
+	"errors"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -13,6 +14,14 @@ type OrderStore interface {
 	GetOrderByID(ctx context.Context, id int64) (db.Order, error)
 	GetOrderItemsByOrderID(ctx context.Context, orderID int64) ([]db.OrderItem, error)
 	GetOrdersByUserID(ctx context.Context, userID int64) ([]db.Order, error)
+	HasActiveOrderWithSeller(ctx context.Context, buyerID, sellerID int64) (bool, error)
+	CreateSubOrder(ctx context.Context, arg db.CreateSubOrderParams) (db.SubOrder, error)
+	GetSubOrder(ctx context.Context, id int64) (db.GetSubOrderRow, error)
+	GetSubOrderForUpdate(ctx context.Context, id int64) (db.GetSubOrderForUpdateRow, error)
+	GetSubOrdersByOrderID(ctx context.Context, orderID int64) ([]db.SubOrder, error)
+	CompleteSubOrder(ctx context.Context, id int64) (db.SubOrder, error)
+	CancelSubOrder(ctx context.Context, id, cancelledBy int64) (db.SubOrder, error)
+	RefreshOrderStatus(ctx context.Context, orderID int64) error
 	WithTx(tx pgx.Tx) OrderStore
 }
 
@@ -41,7 +50,14 @@ func (s *sqlOrderStore) CreateOrderItem(ctx context.Context, arg db.CreateOrderI
 }
 
 func (s *sqlOrderStore) GetOrderByID(ctx context.Context, id int64) (db.Order, error) {
-	return s.q.GetOrderByID(ctx, id)
+	order, err := s.q.GetOrderByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Order{}, ErrRecordNotFound
+		}
+		return db.Order{}, err
+	}
+	return order, nil
 }
 
 func (s *sqlOrderStore) GetOrderItemsByOrderID(ctx context.Context, orderID int64) ([]db.OrderItem, error) {
@@ -51,3 +67,55 @@ func (s *sqlOrderStore) GetOrderItemsByOrderID(ctx context.Context, orderID int6
 func (s *sqlOrderStore) GetOrdersByUserID(ctx context.Context, userID int64) ([]db.Order, error) {
 	return s.q.GetOrdersByUserID(ctx, userID)
 }
+
+func (s *sqlOrderStore) HasActiveOrderWithSeller(ctx context.Context, buyerID, sellerID int64) (bool, error) {
+	return s.q.HasActiveOrderWithSeller(ctx, db.HasActiveOrderWithSellerParams{
+		BuyerID:  buyerID,
+		SellerID: sellerID,
+	})
+}
+
+func (s *sqlOrderStore) CreateSubOrder(ctx context.Context, arg db.CreateSubOrderParams) (db.SubOrder, error) {
+	return s.q.CreateSubOrder(ctx, arg)
+}
+
+func (s *sqlOrderStore) GetSubOrder(ctx context.Context, id int64) (db.GetSubOrderRow, error) {
+	subOrder, err := s.q.GetSubOrder(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.GetSubOrderRow{}, ErrRecordNotFound
+		}
+		return db.GetSubOrderRow{}, err
+	}
+	return subOrder, nil
+}
+
+func (s *sqlOrderStore) GetSubOrderForUpdate(ctx context.Context, id int64) (db.GetSubOrderForUpdateRow, error) {
+	subOrder, err := s.q.GetSubOrderForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.GetSubOrderForUpdateRow{}, ErrRecordNotFound
+		}
+		return db.GetSubOrderForUpdateRow{}, err
+	}
+	return subOrder, nil
+}
+
+func (s *sqlOrderStore) GetSubOrdersByOrderID(ctx context.Context, orderID int64) ([]db.SubOrder, error) {
+	return s.q.GetSubOrdersByOrderID(ctx, orderID)
+}
+
+func (s *sqlOrderStore) CompleteSubOrder(ctx context.Context, id int64) (db.SubOrder, error) {
+	return s.q.CompleteSubOrder(ctx, id)
+}
+
+func (s *sqlOrderStore) CancelSubOrder(ctx context.Context, id, cancelledBy int64) (db.SubOrder, error) {
+	return s.q.CancelSubOrder(ctx, db.CancelSubOrderParams{
+		ID:          id,
+		CancelledBy: NewPGInt64(cancelledBy),
+	})
+}
+
+func (s *sqlOrderStore) RefreshOrderStatus(ctx context.Context, orderID int64) error {
+	return s.q.RefreshOrderStatus(ctx, orderID)
+}
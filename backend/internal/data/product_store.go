@@ -21,8 +21,12 @@ type ProductStore interface {
 	GetProductByID(ctx context.Context, id int64) (db.Product, error)
 	GetProductImages(ctx context.Context, productID int64) ([]db.ProductImage, error)
 	GetProductsBySeller(ctx context.Context, sellerID int64) ([]db.Product, error)
+	FindSimilarProductImage(ctx context.Context, excludeProductID int64, phash int64, maxDistance int) (int64, error)
+	ListReferencedImageURLs(ctx context.Context) ([]string, error)
+	GetProductForUpdate(ctx context.Context, id int64) (db.Product, error)
+	UpdateProductListing(ctx context.Context, arg db.UpdateProductListingParams) (db.Product, error)
 
-	GetAllProducts(ctx context.Context) ([]db.Product, error)
+	GetAllProducts(ctx context.Context) ([]db.GetAllProductsRow, error)
 	WithTx(tx pgx.Tx) ProductStore
 }
 
@@ -65,7 +69,7 @@ func (s *sqlProductStore) CreateProductImage(ctx context.Context, arg db.CreateP
 	return s.q.CreateProductImage(ctx, arg)
 }
 
-func (s *sqlProductStore) GetAllProducts(ctx context.Context) ([]db.Product, error) {
+func (s *sqlProductStore) GetAllProducts(ctx context.Context) ([]db.GetAllProductsRow, error) {
 	return s.q.GetAllProducts(ctx)
 }
 
@@ -90,3 +94,37 @@ func (s *sqlProductStore) GetProductImages(ctx context.Context, productID int64)
 	}
 	return images, nil
 }
+
+func (s *sqlProductStore) FindSimilarProductImage(ctx context.Context, excludeProductID int64, phash int64, maxDistance int) (int64, error) {
+	productID, err := s.q.FindSimilarProductImage(ctx, db.FindSimilarProductImageParams{
+		ExcludeProductID: excludeProductID,
+		Phash:            phash,
+		MaxDistance:      int32(maxDistance),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrRecordNotFound
+		}
+		return 0, err
+	}
+	return productID, nil
+}
+
+func (s *sqlProductStore) ListReferencedImageURLs(ctx context.Context) ([]string, error) {
+	return s.q.ListReferencedImageURLs(ctx)
+}
+
+func (s *sqlProductStore) GetProductForUpdate(ctx context.Context, id int64) (db.Product, error) {
+	product, err := s.q.GetProductForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Product{}, ErrRecordNotFound
+		}
+		return db.Product{}, err
+	}
+	return product, nil
+}
+
+func (s *sqlProductStore) UpdateProductListing(ctx context.Context, arg db.UpdateProductListingParams) (db.Product, error) {
+	return s.q.UpdateProductListing(ctx, arg)
+}
@@ -0,0 +1,146 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrDuplicateReview = errors.New("order item already reviewed")
+	ErrDuplicateFlag   = errors.New("review already flagged")
+)
+
+type ReviewStore interface {
+	CreateReview(ctx context.Context, arg db.CreateReviewParams) (db.Review, error)
+	GetReviewForUpdate(ctx context.Context, id int64) (db.Review, error)
+	ListProductReviews(ctx context.Context, productID int64) ([]db.ListProductReviewsRow, error)
+	ListSellerReviews(ctx context.Context, sellerID int64) ([]db.ListSellerReviewsRow, error)
+	ListReviewableItems(ctx context.Context, buyerID int64) ([]db.ListReviewableItemsRow, error)
+	GetProductRating(ctx context.Context, productID int64) (db.GetProductRatingRow, error)
+	SetReviewReply(ctx context.Context, id, sellerID int64, reply string) (db.Review, error)
+	CreateReviewFlag(ctx context.Context, reviewID, userID int64, reason string) error
+	IncrementReviewFlagCount(ctx context.Context, id int64) error
+	ListFlaggedReviews(ctx context.Context) ([]db.ListFlaggedReviewsRow, error)
+	SetReviewHidden(ctx context.Context, id int64, hidden bool) (db.Review, error)
+	AdjustSellerRating(ctx context.Context, sellerID int64, totalDelta, countDelta int32) error
+	WithTx(tx pgx.Tx) ReviewStore
+}
+
+type sqlReviewStore struct {
+	q *db.Queries
+}
+
+func NewReviewStore(queries *db.Queries) ReviewStore {
+	return &sqlReviewStore{
+		q: queries,
+	}
+}
+
+func (s *sqlReviewStore) WithTx(tx pgx.Tx) ReviewStore {
+	return &sqlReviewStore{
+		q: db.New(tx),
+	}
+}
+
+// CreateReview reports ErrRecordNotFound when the order item isn't the
+// buyer's or its sub-order isn't completed.
+func (s *sqlReviewStore) CreateReview(ctx context.Context, arg db.CreateReviewParams) (db.Review, error) {
+	review, err := s.q.CreateReview(ctx, arg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Review{}, ErrRecordNotFound
+		}
+		if e, ok := pgErr(err); ok && e.Code == "23505" {
+			return db.Review{}, ErrDuplicateReview
+		}
+		return db.Review{}, err
+	}
+	return review, nil
+}
+
+func (s *sqlReviewStore) GetReviewForUpdate(ctx context.Context, id int64) (db.Review, error) {
+	review, err := s.q.GetReviewForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Review{}, ErrRecordNotFound
+		}
+		return db.Review{}, err
+	}
+	return review, nil
+}
+
+func (s *sqlReviewStore) ListProductReviews(ctx context.Context, productID int64) ([]db.ListProductReviewsRow, error) {
+	return s.q.ListProductReviews(ctx, productID)
+}
+
+func (s *sqlReviewStore) ListSellerReviews(ctx context.Context, sellerID int64) ([]db.ListSellerReviewsRow, error) {
+	return s.q.ListSellerReviews(ctx, sellerID)
+}
+
+func (s *sqlReviewStore) ListReviewableItems(ctx context.Context, buyerID int64) ([]db.ListReviewableItemsRow, error) {
+	return s.q.ListReviewableItems(ctx, buyerID)
+}
+
+func (s *sqlReviewStore) GetProductRating(ctx context.Context, productID int64) (db.GetProductRatingRow, error) {
+	return s.q.GetProductRating(ctx, productID)
+}
+
+func (s *sqlReviewStore) SetReviewReply(ctx context.Context, id, sellerID int64, reply string) (db.Review, error) {
+	review, err := s.q.SetReviewReply(ctx, db.SetReviewReplyParams{
+		ID:       id,
+		SellerID: sellerID,
+		Reply:    NewPGText(reply),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Review{}, ErrRecordNotFound
+		}
+		return db.Review{}, err
+	}
+	return review, nil
+}
+
+func (s *sqlReviewStore) CreateReviewFlag(ctx context.Context, reviewID, userID int64, reason string) error {
+	err := s.q.CreateReviewFlag(ctx, db.CreateReviewFlagParams{
+		ReviewID: reviewID,
+		UserID:   userID,
+		Reason:   reason,
+	})
+	if e, ok := pgErr(err); ok && e.Code == "23505" {
+		return ErrDuplicateFlag
+	}
+	return err
+}
+
+func (s *sqlReviewStore) IncrementReviewFlagCount(ctx context.Context, id int64) error {
+	return s.q.IncrementReviewFlagCount(ctx, id)
+}
+
+func (s *sqlReviewStore) ListFlaggedReviews(ctx context.Context) ([]db.ListFlaggedReviewsRow, error) {
+	return s.q.ListFlaggedReviews(ctx)
+}
+
+func (s *sqlReviewStore) SetReviewHidden(ctx context.Context, id int64, hidden bool) (db.Review, error) {
+	review, err := s.q.SetReviewHidden(ctx, db.SetReviewHiddenParams{
+		ID:       id,
+		IsHidden: hidden,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Review{}, ErrRecordNotFound
+		}
+		return db.Review{}, err
+	}
+	return review, nil
+}
+
+func (s *sqlReviewStore) AdjustSellerRating(ctx context.Context, sellerID int64, totalDelta, countDelta int32) error {
+	return s.q.AdjustSellerRating(ctx, db.AdjustSellerRatingParams{
+		SellerID:   int32(sellerID),
+		TotalDelta: totalDelta,
+		CountDelta: countDelta,
+	})
+}
@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+	"errors"
+)
+
+var ErrDuplicateSignupRule = errors.New("signup rule already exists")
+
+type SignupRuleStore interface {
+	ListSignupEmailRules(ctx context.Context) ([]db.SignupEmailRule, error)
+	MatchSignupEmailRules(ctx context.Context, email, domain string) ([]db.SignupEmailRule, error)
+	CreateSignupEmailRule(ctx context.Context, pattern, rule, note string, createdBy int64) (db.SignupEmailRule, error)
+	DeleteSignupEmailRule(ctx context.Context, id int64) error
+}
+
+type sqlSignupRuleStore struct {
+	q *db.Queries
+}
+
+func NewSignupRuleStore(queries *db.Queries) SignupRuleStore {
+	return &sqlSignupRuleStore{
+		q: queries,
+	}
+}
+
+func (s *sqlSignupRuleStore) ListSignupEmailRules(ctx context.Context) ([]db.SignupEmailRule, error) {
+	return s.q.ListSignupEmailRules(ctx)
+}
+
+// MatchSignupEmailRules returns the rules for an exact address and for its
+// domain.
+func (s *sqlSignupRuleStore) MatchSignupEmailRules(ctx context.Context, email, domain string) ([]db.SignupEmailRule, error) {
+	return s.q.MatchSignupEmailRules(ctx, db.MatchSignupEmailRulesParams{
+		Email:  email,
+		Domain: domain,
+	})
+}
+
+func (s *sqlSignupRuleStore) CreateSignupEmailRule(ctx context.Context, pattern, rule, note string, createdBy int64) (db.SignupEmailRule, error) {
+	created, err := s.q.CreateSignupEmailRule(ctx, db.CreateSignupEmailRuleParams{
+		Pattern:   pattern,
+		Rule:      rule,
+		Note:      note,
+		CreatedBy: NewPGInt64(createdBy),
+	})
+	if err != nil {
+		if e, ok := pgErr(err); ok && e.Code == "23505" {
+			return db.SignupEmailRule{}, ErrDuplicateSignupRule
+		}
+		return db.SignupEmailRule{}, err
+	}
+	return created, nil
+}
+
+func (s *sqlSignupRuleStore) DeleteSignupEmailRule(ctx context.Context, id int64) error {
+	rows, err := s.q.DeleteSignupEmailRule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
@@ -2,9 +2,10 @@ package data
 
 import (
 	"context"
-	"database/sql"
 	db "ecommerce/internal/data/gen"
 	"errors"
+
+	"github.com/jackc/pgx/v5"
 )
 
 var ErrRecordNotFound = errors.New("record not found")
@@ -13,6 +14,8 @@ type TokenStore interface {
 	InsertToken(ctx context.Context, arg db.InsertTokenParams) error
 	DeleteAllForUserAndScope(ctx context.Context, scope string, userID int64) error
 	GetTokenByHash(ctx context.Context, hash []byte) (db.Token, error)
+	ConsumeToken(ctx context.Context, hash []byte, scope string) (db.Token, error)
+	WithTx(tx pgx.Tx) TokenStore
 }
 
 type sqlTokenStore struct {
@@ -25,6 +28,12 @@ func NewTokenStore(queries *db.Queries) TokenStore {
 	}
 }
 
+func (s *sqlTokenStore) WithTx(tx pgx.Tx) TokenStore {
+	return &sqlTokenStore{
+		q: s.q.WithTx(tx),
+	}
+}
+
 func (s *sqlTokenStore) InsertToken(ctx context.Context, arg db.InsertTokenParams) error {
 	return s.q.InsertToken(ctx, arg)
 }
@@ -39,7 +48,20 @@ func (s *sqlTokenStore) DeleteAllForUserAndScope(ctx context.Context, scope stri
 
 func (s *sqlTokenStore) GetTokenByHash(ctx context.Context, hash []byte) (db.Token, error) {
 	token, err := s.q.GetTokenByHash(ctx, hash)
-	if errors.Is(err, sql.ErrNoRows) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Token{}, ErrRecordNotFound
+	}
+	return token, err
+}
+
+// ConsumeToken deletes a token of the given scope and returns it, so it can
+// only ever be used once.
+func (s *sqlTokenStore) ConsumeToken(ctx context.Context, hash []byte, scope string) (db.Token, error) {
+	token, err := s.q.ConsumeToken(ctx, db.ConsumeTokenParams{
+		Hash:  hash,
+		Scope: scope,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
 		return db.Token{}, ErrRecordNotFound
 	}
 	return token, err
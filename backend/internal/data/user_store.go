@@ -3,16 +3,34 @@ package data
 import (
 	"context"
 	db "ecommerce/internal/data/gen"
+	"errors"
 
 	"github.com/jackc/pgx/v5"
 )
 
+var (
+	ErrDuplicateEmail = errors.New("email already in use")
+	ErrDuplicateUpiID = errors.New("upi id already in use")
+	ErrDuplicatePhone = errors.New("phone number already in use")
+	ErrEditConflict   = errors.New("edit conflict")
+)
+
 type UserStore interface {
 	GetUserAuthByEmail(ctx context.Context, email string) (db.GetUserAuthByEmailRow, error)
 	CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error)
 	GetUserByID(ctx context.Context, id int) (db.GetUserByIDRow, error)
+	GetUserByEmail(ctx context.Context, email string) (db.User, error)
 	VerifyUserEmail(ctx context.Context, id int) error
 	UpdateUserEmail(ctx context.Context, id int, updated_email string) error
+	UpdateUserPassword(ctx context.Context, id int, passwordHash string) error
+	ClearUserPassword(ctx context.Context, id int) error
+	GetUserPasswordHash(ctx context.Context, id int) (string, error)
+	UpdateUserProfile(ctx context.Context, arg db.UpdateUserProfileParams) (db.User, error)
+	UpdateUserAvatar(ctx context.Context, id int, avatarURL string) (db.User, error)
+	CreateGoogleUser(ctx context.Context, arg db.CreateGoogleUserParams) (db.User, error)
+	GetUserByGoogleID(ctx context.Context, googleID string) (db.User, error)
+	LinkGoogleAccount(ctx context.Context, id int, googleID string) error
+	GetSellerSummary(ctx context.Context, id int64) (db.GetSellerSummaryRow, error)
 	WithTx(tx pgx.Tx) UserStore
 }
 
@@ -30,18 +48,150 @@ func (s *sqlUserStore) VerifyUserEmail(ctx context.Context, id int) error {
 func (s *sqlUserStore) CreateUser(ctx context.Context, arg db.CreateUserParams) (_ db.User, _ error) {
 	return s.q.CreateUser(ctx, arg)
 }
-func (s *sqlUserStore) GetUserByID(ctx context.Context, id int) (_ db.GetUserByIDRow, _ error) {
-	return s.q.GetUserByID(ctx, int32(id))
+func (s *sqlUserStore) GetUserByID(ctx context.Context, id int) (db.GetUserByIDRow, error) {
+	user, err := s.q.GetUserByID(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.GetUserByIDRow{}, ErrRecordNotFound
+		}
+		return db.GetUserByIDRow{}, err
+	}
+	return user, nil
+}
+
+func (s *sqlUserStore) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	user, err := s.q.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.User{}, ErrRecordNotFound
+		}
+		return db.User{}, err
+	}
+	return user, nil
 }
 
+// UpdateUserEmail swaps in an address the user has already confirmed, so it
+// is stored as verified.
 func (s *sqlUserStore) UpdateUserEmail(ctx context.Context, id int, updated_email string) error {
 	params := db.UpdateUserEmailParams{
 		Email: updated_email,
 		ID:    int32(id),
 	}
 
-	return s.q.UpdateUserEmail(ctx, params)
+	if err := s.q.UpdateUserEmail(ctx, params); err != nil {
+		if e, ok := pgErr(err); ok && e.Code == "23505" {
+			return ErrDuplicateEmail
+		}
+		return err
+	}
+	return nil
+}
+
+// GetUserPasswordHash returns "" for accounts without a password.
+func (s *sqlUserStore) GetUserPasswordHash(ctx context.Context, id int) (string, error) {
+	hash, err := s.q.GetUserPasswordHash(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrRecordNotFound
+		}
+		return "", err
+	}
+	return hash.String, nil
+}
+
+func (s *sqlUserStore) UpdateUserPassword(ctx context.Context, id int, passwordHash string) error {
+	return s.q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		PasswordHash: NewPGText(passwordHash),
+		ID:           int32(id),
+	})
+}
+
+func (s *sqlUserStore) ClearUserPassword(ctx context.Context, id int) error {
+	return s.q.ClearUserPassword(ctx, int32(id))
+}
+
+// UpdateUserProfile only applies when arg.Version is still current, and
+// returns ErrEditConflict otherwise.
+func (s *sqlUserStore) UpdateUserProfile(ctx context.Context, arg db.UpdateUserProfileParams) (db.User, error) {
+	user, err := s.q.UpdateUserProfile(ctx, arg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.User{}, ErrEditConflict
+		}
+		if e, ok := pgErr(err); ok && e.Code == "23505" {
+			switch e.ConstraintName {
+			case "users_upi_id_key":
+				return db.User{}, ErrDuplicateUpiID
+			case "users_phone_number_key":
+				return db.User{}, ErrDuplicatePhone
+			}
+		}
+		return db.User{}, err
+	}
+	return user, nil
+}
+
+func (s *sqlUserStore) UpdateUserAvatar(ctx context.Context, id int, avatarURL string) (db.User, error) {
+	user, err := s.q.UpdateUserAvatar(ctx, db.UpdateUserAvatarParams{
+		AvatarUrl: NewNullPGText(avatarURL),
+		ID:        int32(id),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.User{}, ErrRecordNotFound
+		}
+		return db.User{}, err
+	}
+	return user, nil
+}
+
+func (s *sqlUserStore) CreateGoogleUser(ctx context.Context, arg db.CreateGoogleUserParams) (db.User, error) {
+	user, err := s.q.CreateGoogleUser(ctx, arg)
+	if err != nil {
+		if e, ok := pgErr(err); ok && e.Code == "23505" {
+			return db.User{}, ErrDuplicateEmail
+		}
+		return db.User{}, err
+	}
+	return user, nil
+}
+
+func (s *sqlUserStore) GetUserByGoogleID(ctx context.Context, googleID string) (db.User, error) {
+	user, err := s.q.GetUserByGoogleID(ctx, NewPGText(googleID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.User{}, ErrRecordNotFound
+		}
+		return db.User{}, err
+	}
+	return user, nil
+}
+
+// LinkGoogleAccount attaches a Google account to a user that doesn't have
+// one yet, and returns ErrEditConflict if they already do.
+func (s *sqlUserStore) LinkGoogleAccount(ctx context.Context, id int, googleID string) error {
+	rows, err := s.q.LinkGoogleAccount(ctx, db.LinkGoogleAccountParams{
+		GoogleID: NewPGText(googleID),
+		ID:       int32(id),
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrEditConflict
+	}
+	return nil
+}
 
+func (s *sqlUserStore) GetSellerSummary(ctx context.Context, id int64) (db.GetSellerSummaryRow, error) {
+	seller, err := s.q.GetSellerSummary(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.GetSellerSummaryRow{}, ErrRecordNotFound
+		}
+		return db.GetSellerSummaryRow{}, err
+	}
+	return seller, nil
 }
 
 func (s *sqlUserStore) WithTx(tx pgx.Tx) UserStore {
@@ -24,6 +24,7 @@ type WalletStore interface {
 	GetTransactionByID(ctx context.Context, id int32) (db.WalletTransaction, error)
 
 	CreditWalletBalance(ctx context.Context, userID int32, amount int64) error
+	GetPlatformAccount(ctx context.Context, name string) (int32, error)
 }
 
 type sqlWalletStore struct {
@@ -111,3 +112,16 @@ func (s *sqlWalletStore) UpdateTransactionStatus(ctx context.Context, arg db.Upd
 	}
 	return tx, err
 }
+
+// GetPlatformAccount returns the user that owns a platform wallet such as
+// the promo, revenue or escrow account.
+func (s *sqlWalletStore) GetPlatformAccount(ctx context.Context, name string) (int32, error) {
+	userID, err := s.q.GetPlatformAccount(ctx, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrRecordNotFound
+		}
+		return 0, err
+	}
+	return userID, nil
+}
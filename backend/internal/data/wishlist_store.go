@@ -0,0 +1,56 @@
+package data
+
+import (
+	"context"
+	db "ecommerce/internal/data/gen"
+)
+
+type WishlistStore interface {
+	AddWishlistItem(ctx context.Context, userID, productID int64, price int32) error
+	DeleteWishlistItem(ctx context.Context, userID, productID int64) error
+	GetWishlist(ctx context.Context, userID int64) ([]db.GetWishlistRow, error)
+	IsInWishlist(ctx context.Context, userID, productID int64) (bool, error)
+}
+
+type sqlWishlistStore struct {
+	q *db.Queries
+}
+
+func NewWishlistStore(queries *db.Queries) WishlistStore {
+	return &sqlWishlistStore{
+		q: queries,
+	}
+}
+
+func (s *sqlWishlistStore) AddWishlistItem(ctx context.Context, userID, productID int64, price int32) error {
+	return s.q.AddWishlistItem(ctx, db.AddWishlistItemParams{
+		UserID:     userID,
+		ProductID:  productID,
+		PriceAtAdd: price,
+	})
+}
+
+func (s *sqlWishlistStore) DeleteWishlistItem(ctx context.Context, userID, productID int64) error {
+	rows, err := s.q.DeleteWishlistItem(ctx, db.DeleteWishlistItemParams{
+		UserID:    userID,
+		ProductID: productID,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *sqlWishlistStore) GetWishlist(ctx context.Context, userID int64) ([]db.GetWishlistRow, error) {
+	return s.q.GetWishlist(ctx, userID)
+}
+
+func (s *sqlWishlistStore) IsInWishlist(ctx context.Context, userID, productID int64) (bool, error) {
+	return s.q.IsInWishlist(ctx, db.IsInWishlistParams{
+		UserID:    userID,
+		ProductID: productID,
+	})
+}
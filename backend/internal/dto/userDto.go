@@ -6,10 +6,14 @@ type UserLogin struct {
 }
 
 type UserEmailUpdate struct {
-	ID           int    `json:"id"`
 	UpdatedEmail string `json:"updated_email"`
 }
 
+type UserPasswordUpdate struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
 type UserSignup struct {
 	UserLogin
 	Name  string `json:"name"`
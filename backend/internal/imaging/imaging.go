@@ -0,0 +1,224 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	MaxUploadBytes = 8 << 20
+	MaxPixels      = 40_000_000
+	MinDimension   = 64
+
+	jpegQuality = 85
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format: only JPEG, PNG and WebP are allowed")
+	ErrTooLarge          = fmt.Errorf("image exceeds the %d MB size limit", MaxUploadBytes>>20)
+	ErrDimensions        = errors.New("image dimensions are out of range")
+)
+
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatWebP Format = "webp"
+)
+
+type VariantSpec struct {
+	Name    string
+	MaxEdge int
+}
+
+var Variants = []VariantSpec{
+	{Name: "thumbnail", MaxEdge: 320},
+	{Name: "medium", MaxEdge: 800},
+	{Name: "full", MaxEdge: 1600},
+}
+
+type Variant struct {
+	Name   string
+	Ext    string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+type Result struct {
+	Format   Format
+	PHash    uint64
+	Variants []Variant
+}
+
+func (r *Result) Variant(name string) (Variant, bool) {
+	for _, v := range r.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// Sniff identifies the image format from its magic bytes. It needs at most
+// the first 12 bytes of the file.
+func Sniff(header []byte) (Format, error) {
+	switch {
+	case len(header) >= 3 && bytes.Equal(header[:3], []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG, nil
+	case len(header) >= 8 && bytes.Equal(header[:8], []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}):
+		return FormatPNG, nil
+	case len(header) >= 12 && string(header[:4]) == "RIFF" && string(header[8:12]) == "WEBP":
+		return FormatWebP, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// Process validates an uploaded image and re-encodes it into the configured
+// variants. Re-encoding from decoded pixels drops all metadata, including EXIF
+// GPS coordinates, after the EXIF orientation has been applied.
+func Process(r io.Reader) (*Result, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, MaxUploadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > MaxUploadBytes {
+		return nil, ErrTooLarge
+	}
+
+	format, err := Sniff(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := decodeConfig(format, raw)
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if cfg.Width < MinDimension || cfg.Height < MinDimension || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrDimensions
+	}
+
+	img, err := decode(format, raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	if format == FormatJPEG {
+		img = applyOrientation(img, jpegOrientation(raw))
+	}
+
+	res := &Result{
+		Format: format,
+		PHash:  DHash(img),
+	}
+
+	keepAlpha := format == FormatPNG && !opaque(img)
+	for _, spec := range Variants {
+		v, err := encodeVariant(img, spec, keepAlpha)
+		if err != nil {
+			return nil, err
+		}
+		res.Variants = append(res.Variants, v)
+	}
+
+	return res, nil
+}
+
+func decodeConfig(format Format, raw []byte) (image.Config, error) {
+	switch format {
+	case FormatJPEG:
+		return jpeg.DecodeConfig(bytes.NewReader(raw))
+	case FormatPNG:
+		return png.DecodeConfig(bytes.NewReader(raw))
+	default:
+		return webp.DecodeConfig(bytes.NewReader(raw))
+	}
+}
+
+func decode(format Format, raw []byte) (image.Image, error) {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Decode(bytes.NewReader(raw))
+	case FormatPNG:
+		return png.Decode(bytes.NewReader(raw))
+	default:
+		return webp.Decode(bytes.NewReader(raw))
+	}
+}
+
+func encodeVariant(src image.Image, spec VariantSpec, keepAlpha bool) (Variant, error) {
+	dst := resize(src, spec.MaxEdge)
+
+	var buf bytes.Buffer
+	v := Variant{
+		Name:   spec.Name,
+		Width:  dst.Bounds().Dx(),
+		Height: dst.Bounds().Dy(),
+	}
+
+	if keepAlpha {
+		v.Ext = ".png"
+		if err := png.Encode(&buf, dst); err != nil {
+			return Variant{}, err
+		}
+	} else {
+		v.Ext = ".jpg"
+		if err := jpeg.Encode(&buf, flatten(dst), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return Variant{}, err
+		}
+	}
+
+	v.Data = buf.Bytes()
+	return v, nil
+}
+
+func resize(src image.Image, maxEdge int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxEdge && h <= maxEdge {
+		return src
+	}
+
+	if w >= h {
+		h = max(1, h*maxEdge/w)
+		w = maxEdge
+	} else {
+		w = max(1, w*maxEdge/h)
+		h = maxEdge
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// flatten composites src over white so transparent regions don't turn black
+// when encoded as JPEG.
+func flatten(src image.Image) image.Image {
+	if opaque(src) {
+		return src
+	}
+	dst := image.NewRGBA(src.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Over)
+	return dst
+}
+
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
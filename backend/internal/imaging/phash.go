@@ -0,0 +1,40 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math/bits"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// DuplicateThreshold is the maximum Hamming distance between two hashes for
+// the images to be treated as the same photo.
+const DuplicateThreshold = 6
+
+// DHash computes a 64-bit difference hash: the image is shrunk to 9x8
+// grayscale and each bit records whether a pixel is brighter than its right
+// neighbour. It survives re-encoding and resizing, which is what reposted
+// listing photos usually go through.
+func DHash(img image.Image) uint64 {
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	xdraw.ApproxBiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := range 8 {
+		for x := range 8 {
+			left := small.At(x, y).(color.Gray).Y
+			right := small.At(x+1, y).(color.Gray).Y
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/cache"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"ecommerce/internal/worker"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+const (
+	AlertPriceDrop   = "price_drop"
+	AlertBackInStock = "back_in_stock"
+	AlertLastOne     = "last_one"
+	AlertDelisted    = "delisted"
+
+	AlertModeInstant = "instant"
+	AlertModeDigest  = "digest"
+	AlertModeOff     = "off"
+
+	alertBatchSize  = 100
+	alertRateWindow = time.Hour
+)
+
+// AlertKinds are the alerts a user can subscribe to directly. Wishlisted
+// products get every alert, including last-unit and delisting notices.
+var AlertKinds = []string{AlertPriceDrop, AlertBackInStock}
+
+var AlertModes = []string{AlertModeInstant, AlertModeDigest, AlertModeOff}
+
+var ErrAlertNotFound = errors.New("alert subscription not found")
+
+type Alert struct {
+	Kind        string    `json:"kind"`
+	ProductID   int64     `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	OldPrice    int32     `json:"old_price"`
+	NewPrice    int32     `json:"new_price"`
+	Stock       int32     `json:"stock"`
+	At          time.Time `json:"at"`
+}
+
+// Mail jobs are JSON encoded on the queue, so templates see these by their
+// json names.
+type AlertEmailData struct {
+	Name string `json:"name"`
+	Alert
+}
+
+type AlertDigestData struct {
+	Name   string  `json:"name"`
+	Alerts []Alert `json:"alerts"`
+}
+
+type AlertSubscriptions struct {
+	Mode   string                           `json:"mode"`
+	Alerts []db.ListProductAlertsForUserRow `json:"alerts"`
+}
+
+type digestEntry struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	Alert Alert  `json:"alert"`
+}
+
+type AlertService struct {
+	Store        data.AlertStore
+	ProductStore data.ProductStore
+	Cache        *cache.ValkeyCache
+	RateLimit    int
+	DigestAt     time.Duration
+	Logger       *slog.Logger
+	stopSignal   chan struct{}
+}
+
+// NewAlertService builds the alert service. Digests are sent every day at
+// digestAt past local midnight.
+func NewAlertService(store data.AlertStore, productStore data.ProductStore, cache *cache.ValkeyCache, rateLimit int, digestAt time.Duration, logger *slog.Logger) *AlertService {
+	return &AlertService{
+		Store:        store,
+		ProductStore: productStore,
+		Cache:        cache,
+		RateLimit:    rateLimit,
+		DigestAt:     digestAt,
+		Logger:       logger,
+		stopSignal:   make(chan struct{}),
+	}
+}
+
+func (s *AlertService) Subscribe(ctx context.Context, userID, productID int64, kind string) error {
+	product, err := s.ProductStore.GetProductByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if product.SellerID == userID {
+		return ErrCannotBuyOwnItem
+	}
+
+	s.Logger.Info("Subscribing to product alert", "user_id", userID, "product_id", productID, "kind", kind)
+	return s.Store.CreateProductAlert(ctx, userID, productID, kind)
+}
+
+func (s *AlertService) Unsubscribe(ctx context.Context, userID, productID int64, kind string) error {
+	err := s.Store.DeleteProductAlert(ctx, userID, productID, kind)
+	if errors.Is(err, data.ErrRecordNotFound) {
+		return ErrAlertNotFound
+	}
+	return err
+}
+
+func (s *AlertService) GetSubscriptions(ctx context.Context, userID int64) (AlertSubscriptions, error) {
+	mode, err := s.Store.GetAlertMode(ctx, int32(userID))
+	if err != nil {
+		return AlertSubscriptions{}, err
+	}
+
+	alerts, err := s.Store.ListProductAlertsForUser(ctx, userID)
+	if err != nil {
+		return AlertSubscriptions{}, err
+	}
+
+	return AlertSubscriptions{Mode: mode, Alerts: alerts}, nil
+}
+
+func (s *AlertService) SetMode(ctx context.Context, userID int64, mode string) error {
+	s.Logger.Info("Updating alert mode", "user_id", userID, "mode", mode)
+	return s.Store.SetAlertMode(ctx, int32(userID), mode)
+}
+
+// ProductUpdated turns a listing change into alerts and sends them to every
+// subscriber, honouring each user's mode and the per-user rate limit.
+func (s *AlertService) ProductUpdated(ctx context.Context, before, after db.Product) {
+	for _, alert := range detectAlerts(before, after) {
+		if err := s.dispatch(ctx, alert); err != nil {
+			s.Logger.Error("Failed to dispatch product alert", "product_id", alert.ProductID, "kind", alert.Kind, "error", err)
+		}
+	}
+}
+
+func detectAlerts(before, after db.Product) []Alert {
+	base := Alert{
+		ProductID:   after.ID,
+		ProductName: after.Name,
+		OldPrice:    before.Price,
+		NewPrice:    after.Price,
+		Stock:       after.Stock,
+		At:          time.Now(),
+	}
+
+	if before.IsActive && !after.IsActive {
+		base.Kind = AlertDelisted
+		return []Alert{base}
+	}
+	if !after.IsActive {
+		return nil
+	}
+
+	var alerts []Alert
+	if after.Price < before.Price {
+		a := base
+		a.Kind = AlertPriceDrop
+		alerts = append(alerts, a)
+	}
+	if before.Stock <= 0 && after.Stock > 0 {
+		a := base
+		a.Kind = AlertBackInStock
+		alerts = append(alerts, a)
+	}
+	if before.Stock > 1 && after.Stock == 1 {
+		a := base
+		a.Kind = AlertLastOne
+		alerts = append(alerts, a)
+	}
+	return alerts
+}
+
+func (s *AlertService) dispatch(ctx context.Context, alert Alert) error {
+	logger := s.Logger.With("product_id", alert.ProductID, "kind", alert.Kind)
+
+	subscribers, err := s.Store.ListAlertSubscribers(ctx, alert.ProductID, alert.Kind)
+	if err != nil {
+		return err
+	}
+
+	batch := make([]string, 0, alertBatchSize)
+	var sent, digested int
+
+	for _, sub := range subscribers {
+		userID := int64(sub.ID)
+
+		instant := sub.AlertMode == AlertModeInstant
+		if instant && s.RateLimit > 0 {
+			n, err := s.Cache.IncrAlertCount(ctx, userID, alertRateWindow)
+			if err != nil {
+				logger.Error("Failed to check alert rate limit", "user_id", userID, "error", err)
+			} else if n > int64(s.RateLimit) {
+				instant = false
+			}
+		}
+
+		if !instant {
+			if err := s.addToDigest(ctx, userID, sub.Email, sub.Name, alert); err != nil {
+				logger.Error("Failed to add alert to digest", "user_id", userID, "error", err)
+				continue
+			}
+			digested++
+			continue
+		}
+
+		jobJSON, err := json.Marshal(worker.MailJob{
+			Recipient:    sub.Email,
+			TemplateFile: "product_alert.tmpl",
+			TemplateData: AlertEmailData{Name: sub.Name, Alert: alert},
+		})
+		if err != nil {
+			return err
+		}
+		batch = append(batch, string(jobJSON))
+		sent++
+
+		if len(batch) == alertBatchSize {
+			if err := s.Cache.AddEmailsToQueue(ctx, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := s.Cache.AddEmailsToQueue(ctx, batch); err != nil {
+		return err
+	}
+
+	logger.Info("Product alert dispatched", "subscribers", len(subscribers), "sent", sent, "digested", digested)
+	return nil
+}
+
+func (s *AlertService) addToDigest(ctx context.Context, userID int64, email, name string, alert Alert) error {
+	entryJSON, err := json.Marshal(digestEntry{Email: email, Name: name, Alert: alert})
+	if err != nil {
+		return err
+	}
+	return s.Cache.PushDigestAlert(ctx, userID, string(entryJSON))
+}
+
+// Start sends the digests at the configured time each day. The schedule
+// follows the wall clock, so restarts don't shift it.
+func (s *AlertService) Start() {
+	go func() {
+		for {
+			next := nextDailyRun(time.Now(), s.DigestAt)
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-s.stopSignal:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			sent, err := s.SendDigests(ctx)
+			cancel()
+			if err != nil {
+				s.Logger.Error("Alert digest run failed", "error", err)
+				continue
+			}
+			s.Logger.Info("Alert digest run finished", "emails", sent)
+		}
+	}()
+}
+
+// nextDailyRun returns the first time after now whose offset from midnight,
+// in now's location, is at.
+func nextDailyRun(now time.Time, at time.Duration) time.Time {
+	hour, minute := int(at/time.Hour), int(at%time.Hour/time.Minute)
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, now.Location())
+	}
+	return next
+}
+
+func (s *AlertService) Stop() {
+	select {
+	case <-s.stopSignal:
+	default:
+		close(s.stopSignal)
+	}
+}
+
+// SendDigests drains every pending digest into one summary email per user.
+func (s *AlertService) SendDigests(ctx context.Context) (int, error) {
+	userIDs, err := s.Cache.DigestUsers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	batch := make([]string, 0, alertBatchSize)
+	sent := 0
+
+	for _, userID := range userIDs {
+		var entries []digestEntry
+		for {
+			raw, err := s.Cache.PopDigestAlerts(ctx, userID, alertBatchSize)
+			if err != nil {
+				return sent, err
+			}
+			for _, r := range raw {
+				var e digestEntry
+				if err := json.Unmarshal([]byte(r), &e); err != nil {
+					s.Logger.Warn("Skipping malformed digest entry", "user_id", userID, "error", err)
+					continue
+				}
+				entries = append(entries, e)
+			}
+			if len(raw) < alertBatchSize {
+				break
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		last := entries[len(entries)-1]
+		data := AlertDigestData{Name: last.Name, Alerts: make([]Alert, 0, len(entries))}
+		for _, e := range entries {
+			data.Alerts = append(data.Alerts, e.Alert)
+		}
+
+		jobJSON, err := json.Marshal(worker.MailJob{
+			Recipient:    last.Email,
+			TemplateFile: "alert_digest.tmpl",
+			TemplateData: data,
+		})
+		if err != nil {
+			return sent, err
+		}
+		batch = append(batch, string(jobJSON))
+		sent++
+
+		if len(batch) == alertBatchSize {
+			if err := s.Cache.AddEmailsToQueue(ctx, batch); err != nil {
+				return sent, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := s.Cache.AddEmailsToQueue(ctx, batch); err != nil {
+		return sent, err
+	}
+	return sent, nil
+}
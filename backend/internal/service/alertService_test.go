@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextDailyRun(t *testing.T) {
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+	at := 8 * time.Hour
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"earlier the same day", time.Date(2025, 3, 10, 6, 15, 0, 0, ist), time.Date(2025, 3, 10, 8, 0, 0, 0, ist)},
+		{"exactly at the run time", time.Date(2025, 3, 10, 8, 0, 0, 0, ist), time.Date(2025, 3, 11, 8, 0, 0, 0, ist)},
+		{"later the same day", time.Date(2025, 3, 10, 23, 59, 0, 0, ist), time.Date(2025, 3, 11, 8, 0, 0, 0, ist)},
+		{"across a month end", time.Date(2025, 2, 28, 9, 0, 0, 0, ist), time.Date(2025, 3, 1, 8, 0, 0, 0, ist)},
+		{"in now's location", time.Date(2025, 3, 10, 1, 0, 0, 0, time.UTC).In(ist), time.Date(2025, 3, 10, 8, 0, 0, 0, ist)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDailyRun(tt.now, at); !got.Equal(tt.want) {
+				t.Errorf("nextDailyRun(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("minutes", func(t *testing.T) {
+		now := time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC)
+		want := time.Date(2025, 3, 10, 20, 45, 0, 0, time.UTC)
+		if got := nextDailyRun(now, 20*time.Hour+45*time.Minute); !got.Equal(want) {
+			t.Errorf("nextDailyRun(%v) = %v, want %v", now, got, want)
+		}
+	})
+}
@@ -2,140 +2,607 @@ package service
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"ecommerce/internal/cache"
+	"ecommerce/internal/config"
 	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"encoding/hex"
 	"errors"
-
-	// db "ecommerce/internal/data/gen"
+	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CartWarningPriceChanged    = "price_changed"
+	CartWarningOutOfStock      = "out_of_stock"
+	CartWarningListingRemoved  = "listing_removed"
+	CartWarningQuantityClamped = "quantity_clamped"
+)
 
-	"github.com/jackc/pgx/v5/pgtype"
+var (
+	ErrCartChanged        = errors.New("cart changed since it was last viewed")
+	ErrInvalidQuantity    = errors.New("quantity must be positive")
+	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrProductUnavailable = errors.New("product is no longer available")
+	ErrQuantityLimit      = errors.New("quantity exceeds the per-item limit")
+	ErrCartFull           = errors.New("cart has reached the maximum number of items")
+	ErrCartItemNotFound   = errors.New("item not in cart")
 )
 
+type CartWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// needsConfirm reports whether the buyer has to look at the cart again before
+// paying. Price and quantity changes are saved as soon as they are reported,
+// so they only ever block one checkout attempt.
+func (w CartWarning) needsConfirm() bool {
+	return w.Code == CartWarningPriceChanged || w.Code == CartWarningQuantityClamped
+}
+
 type CartItem struct {
-	ProductID int64  `json:"product_id"`
-	Quantity  int    `json:"quantity"`
-	Name      string `json:"name"`
-	Price     int32  `json:"price"`
-	ImageUrl  string `json:"image_url"`
-	Stock     int32  `json:"stock"`
-	SellerID  int64  `json:"seller_id"`
+	ProductID  int64         `json:"product_id"`
+	Quantity   int           `json:"quantity"`
+	Name       string        `json:"name"`
+	Price      int32         `json:"price"`
+	PriceAtAdd int32         `json:"price_at_add"`
+	LineTotal  int64         `json:"line_total"`
+	ImageUrl   string        `json:"image_url"`
+	Stock      int32         `json:"stock"`
+	SellerID   int64         `json:"seller_id"`
+	CategoryID int64         `json:"category_id"`
+	Available  bool          `json:"available"`
+	Warnings   []CartWarning `json:"warnings,omitempty"`
+}
+
+type Cart struct {
+	Items        []CartItem `json:"items"`
+	ItemCount    int        `json:"item_count"`
+	Subtotal     int64      `json:"subtotal"`
+	HasWarnings  bool       `json:"has_warnings"`
+	NeedsConfirm bool       `json:"needs_confirm"`
+}
+
+// CartOwner identifies whose cart an operation applies to: a signed-in user
+// or an anonymous guest session. Only user carts are written to Postgres.
+type CartOwner struct {
+	UserID    int64
+	SessionID string
+}
+
+func UserCart(userID int64) CartOwner {
+	return CartOwner{UserID: userID}
+}
+
+func GuestCart(sessionID string) CartOwner {
+	return CartOwner{SessionID: sessionID}
+}
+
+func (o CartOwner) IsGuest() bool {
+	return o.UserID == 0
+}
+
+type cartEntry struct {
+	productID  int64
+	quantity   int
+	priceAtAdd int32
 }
 
 type CartService struct {
-	Store  data.ProductStore
-	Cache  *cache.ValkeyCache
-	Logger *slog.Logger
+	Store           data.ProductStore
+	Persist         data.CartStore
+	Cache           *cache.ValkeyCache
+	GuestSecret     []byte
+	GuestTTL        time.Duration
+	SecureCookie    bool
+	MaxItemQuantity int
+	MaxCartItems    int
+	Logger          *slog.Logger
 }
 
-func NewCartService(store data.ProductStore, cache *cache.ValkeyCache, logger *slog.Logger) *CartService {
+// NewCartService builds the cart service. persist may be nil, in which case
+// carts only live in Valkey. The guest secret is required so guest cart
+// cookies keep verifying across restarts and replicas. Outside -env=dev the
+// cookie is only sent over HTTPS.
+func NewCartService(
+	store data.ProductStore,
+	persist data.CartStore,
+	cache *cache.ValkeyCache,
+	cfg *config.Config,
+	logger *slog.Logger,
+) (*CartService, error) {
+	if cfg.CartSessionSecret == "" {
+		return nil, errors.New("config error: CART_SESSION_SECRET is required")
+	}
+
 	return &CartService{
-		Store:  store,
-		Cache:  cache,
-		Logger: logger,
+		Store:           store,
+		Persist:         persist,
+		Cache:           cache,
+		GuestSecret:     []byte(cfg.CartSessionSecret),
+		GuestTTL:        cfg.GuestCartTTL,
+		SecureCookie:    cfg.Env != "dev",
+		MaxItemQuantity: cfg.MaxCartItemQuantity,
+		MaxCartItems:    cfg.MaxCartItems,
+		Logger:          logger,
+	}, nil
+}
+
+// NewGuestSession returns a fresh session ID for an anonymous cart.
+func (s *CartService) NewGuestSession() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// SignGuestSession returns the cookie value for a guest session ID.
+func (s *CartService) SignGuestSession(sessionID string) string {
+	return sessionID + "." + s.signGuestSession(sessionID)
+}
+
+// VerifyGuestSession checks a guest cart cookie and returns the session ID it
+// carries.
+func (s *CartService) VerifyGuestSession(value string) (string, bool) {
+	sessionID, sig, ok := strings.Cut(value, ".")
+	if !ok || sessionID == "" {
+		return "", false
 	}
+	if !hmac.Equal([]byte(sig), []byte(s.signGuestSession(sessionID))) {
+		return "", false
+	}
+	return sessionID, true
 }
 
-func (s *CartService) AddToCart(ctx context.Context, userID int64, productID int64, quantity int) error {
-	logger := s.Logger.With("user_id", userID, "product_id", productID, "quantity", quantity)
+func (s *CartService) signGuestSession(sessionID string) string {
+	mac := hmac.New(sha256.New, s.GuestSecret)
+	mac.Write([]byte(sessionID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
 
-	product, err := s.Store.GetProductByID(ctx, productID)
+func (s *CartService) key(owner CartOwner) string {
+	if owner.IsGuest() {
+		return s.Cache.GuestCartKey(owner.SessionID)
+	}
+	return s.Cache.CartKey(owner.UserID)
+}
 
-	if product.SellerID == userID {
-		logger.Warn("User attempted to add their own item to cart", "product_id", productID, "seller_id", product.SellerID)
-		return ErrCannotBuyOwnItem
+func (s *CartService) persist(owner CartOwner) data.CartStore {
+	if owner.IsGuest() {
+		return nil
 	}
+	return s.Persist
+}
 
+func (s *CartService) logger(owner CartOwner) *slog.Logger {
+	if owner.IsGuest() {
+		return s.Logger.With("guest_session", owner.SessionID)
+	}
+	return s.Logger.With("user_id", owner.UserID)
+}
+
+// touch pushes out the expiry of a guest cart after a write.
+func (s *CartService) touch(ctx context.Context, owner CartOwner) error {
+	if !owner.IsGuest() {
+		return nil
+	}
+	return s.Cache.ExpireCart(ctx, s.key(owner), s.GuestTTL)
+}
+
+// checkLine is the single validation path for cart writes. It checks that
+// owner may hold quantity units of the product in a cart that currently has
+// lines distinct products, and returns the product for pricing.
+func (s *CartService) checkLine(ctx context.Context, owner CartOwner, productID int64, quantity int, lines int, inCart bool) (db.Product, error) {
+	if quantity <= 0 {
+		return db.Product{}, ErrInvalidQuantity
+	}
+
+	// GetProductByID hides inactive listings; look them up too so they are
+	// reported as unavailable rather than missing.
+	products, err := s.Store.GetProductsByIDs(ctx, []int64{productID})
 	if err != nil {
-		logger.Warn("Failed to add to cart, product not found")
-		return data.ErrRecordNotFound
+		return db.Product{}, err
 	}
-	if product.Stock < int32(quantity) {
-		logger.Warn("Failed to add to cart, insufficient stock")
-		return errors.New("insufficient stock")
+	if len(products) == 0 {
+		return db.Product{}, data.ErrRecordNotFound
+	}
+	product := products[0]
+
+	switch {
+	case !product.IsActive:
+		return product, ErrProductUnavailable
+	case !owner.IsGuest() && product.SellerID == owner.UserID:
+		return product, ErrCannotBuyOwnItem
+	case s.MaxItemQuantity > 0 && quantity > s.MaxItemQuantity:
+		return product, ErrQuantityLimit
+	case int32(quantity) > product.Stock:
+		return product, ErrInsufficientStock
+	case !inCart && s.MaxCartItems > 0 && lines >= s.MaxCartItems:
+		return product, ErrCartFull
+	}
+	return product, nil
+}
+
+func (s *CartService) findEntry(entries []cartEntry, productID int64) (cartEntry, bool) {
+	i := slices.IndexFunc(entries, func(e cartEntry) bool { return e.productID == productID })
+	if i < 0 {
+		return cartEntry{}, false
+	}
+	return entries[i], true
+}
+
+func (s *CartService) AddToCart(ctx context.Context, owner CartOwner, productID int64, quantity int) error {
+	logger := s.logger(owner).With("product_id", productID, "quantity", quantity)
+
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	entries, err := s.loadCart(ctx, owner)
+	if err != nil {
+		return err
+	}
+	current, inCart := s.findEntry(entries, productID)
+
+	product, err := s.checkLine(ctx, owner, productID, current.quantity+quantity, len(entries), inCart)
+	if err != nil {
+		logger.Warn("Rejected add to cart", "error", err)
+		return err
 	}
 
 	logger.Info("Adding item to cart")
-	return s.Cache.AddToCart(ctx, userID, productID, quantity)
+	if persist := s.persist(owner); persist != nil {
+		if err := persist.AddCartItem(ctx, owner.UserID, productID, quantity, product.Price); err != nil {
+			logger.Error("Failed to persist cart item", "error", err)
+			return err
+		}
+	}
+	if err := s.Cache.AddToCart(ctx, s.key(owner), productID, quantity, product.Price); err != nil {
+		return err
+	}
+	return s.touch(ctx, owner)
+}
+
+// GetCart loads the cart and reconciles it against the current listings.
+// Lines whose price, stock or listing changed carry warnings. Price changes,
+// clamped quantities and removed listings are written back so they are only
+// reported once; out-of-stock lines stay in the cart, flagged unavailable,
+// until stock returns or the buyer removes them.
+func (s *CartService) GetCart(ctx context.Context, owner CartOwner) (Cart, error) {
+	logger := s.logger(owner)
+	logger.Info("Fetching cart")
+
+	entries, err := s.loadCart(ctx, owner)
+	if err != nil {
+		logger.Error("Failed to load cart", "error", err)
+		return Cart{}, err
+	}
+
+	cart := Cart{Items: []CartItem{}}
+	if len(entries) == 0 {
+		return cart, nil
+	}
+
+	productMap, err := s.productsFor(ctx, entries)
+	if err != nil {
+		logger.Error("Failed to get product details for cart", "error", err)
+		return Cart{}, err
+	}
+
+	for _, e := range entries {
+		item := s.reconcileLine(ctx, owner, e, productMap)
+		for _, w := range item.Warnings {
+			cart.HasWarnings = true
+			if w.needsConfirm() {
+				cart.NeedsConfirm = true
+			}
+		}
+		if item.Available {
+			cart.ItemCount += item.Quantity
+			cart.Subtotal += item.LineTotal
+		}
+		cart.Items = append(cart.Items, item)
+	}
+
+	return cart, nil
 }
 
-func (s *CartService) GetCart(ctx context.Context, userID int64) ([]CartItem, error) {
-	logger := s.Logger.With("user_id", userID)
-	logger.Info("Fetching user cart")
+func (s *CartService) productsFor(ctx context.Context, entries []cartEntry) (map[int64]db.Product, error) {
+	productIDs := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		productIDs = append(productIDs, e.productID)
+	}
 
-	cart, err := s.Cache.GetCart(ctx, userID)
+	products, err := s.Store.GetProductsByIDs(ctx, productIDs)
 	if err != nil {
-		logger.Error("Failed to get simple cart from cache", "error", err)
 		return nil, err
 	}
 
-	if len(cart) == 0 {
-		return []CartItem{}, nil
+	productMap := make(map[int64]db.Product, len(products))
+	for _, p := range products {
+		productMap[p.ID] = p
+	}
+	return productMap, nil
+}
+
+func (s *CartService) reconcileLine(ctx context.Context, owner CartOwner, e cartEntry, products map[int64]db.Product) CartItem {
+	logger := s.logger(owner).With("product_id", e.productID)
+
+	item := CartItem{
+		ProductID:  e.productID,
+		Quantity:   e.quantity,
+		PriceAtAdd: e.priceAtAdd,
 	}
 
-	productIDs := make([]int64, 0, len(cart))
-	quantityMap := make(map[int64]int, len(cart))
+	p, ok := products[e.productID]
+	if !ok || !p.IsActive {
+		item.Warnings = append(item.Warnings, CartWarning{
+			Code:    CartWarningListingRemoved,
+			Message: "this listing is no longer available and was removed from your cart",
+		})
+		if ok {
+			item.Name = p.Name
+			item.SellerID = p.SellerID
+		}
+		if err := s.DeleteCartItem(ctx, owner, e.productID); err != nil {
+			logger.Error("Failed to prune removed listing from cart", "error", err)
+		}
+		return item
+	}
 
-	for idStr, qtyStr := range cart {
+	item.Name = p.Name
+	item.Price = p.Price
+	item.ImageUrl = p.ImageUrl.String
+	item.Stock = p.Stock
+	item.SellerID = p.SellerID
+	item.CategoryID = p.CategoryID
+	item.Available = true
+
+	if item.PriceAtAdd == 0 {
+		item.PriceAtAdd = p.Price
+	}
+
+	dirty := e.priceAtAdd != p.Price
+
+	if item.PriceAtAdd != p.Price {
+		item.Warnings = append(item.Warnings, CartWarning{
+			Code:    CartWarningPriceChanged,
+			Message: fmt.Sprintf("price changed from %d to %d", item.PriceAtAdd, p.Price),
+		})
+	}
+
+	switch {
+	case p.Stock <= 0:
+		item.Available = false
+		item.Warnings = append(item.Warnings, CartWarning{
+			Code:    CartWarningOutOfStock,
+			Message: "this item is out of stock",
+		})
+	case int32(item.Quantity) > p.Stock:
+		item.Warnings = append(item.Warnings, CartWarning{
+			Code:    CartWarningQuantityClamped,
+			Message: fmt.Sprintf("only %d left in stock, quantity reduced from %d", p.Stock, item.Quantity),
+		})
+		item.Quantity = int(p.Stock)
+		dirty = true
+	}
+
+	item.LineTotal = int64(p.Price) * int64(item.Quantity)
+
+	if dirty {
+		if err := s.setCartItem(ctx, owner, e.productID, item.Quantity, p.Price); err != nil {
+			logger.Error("Failed to save reconciled cart line", "error", err)
+		}
+	}
+
+	return item
+}
+
+// MergeGuestCart folds a guest cart into a user's cart after login. Lines
+// already in the user's cart keep the larger of the two quantities, everything
+// is clamped to current stock, and the user's own listings are dropped.
+func (s *CartService) MergeGuestCart(ctx context.Context, sessionID string, userID int64) error {
+	guest := GuestCart(sessionID)
+	user := UserCart(userID)
+	logger := s.logger(user).With("guest_session", sessionID)
+
+	guestEntries, err := s.loadCart(ctx, guest)
+	if err != nil {
+		return err
+	}
+	if len(guestEntries) == 0 {
+		return nil
+	}
+
+	userEntries, err := s.loadCart(ctx, user)
+	if err != nil {
+		return err
+	}
+	existing := make(map[int64]cartEntry, len(userEntries))
+	for _, e := range userEntries {
+		existing[e.productID] = e
+	}
+
+	products, err := s.productsFor(ctx, guestEntries)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Merging guest cart", "items", len(guestEntries))
+	lines := len(userEntries)
+	for _, e := range guestEntries {
+		p, ok := products[e.productID]
+		if !ok {
+			continue
+		}
+
+		quantity, price := e.quantity, e.priceAtAdd
+		cur, inCart := existing[e.productID]
+		if inCart {
+			quantity = max(quantity, cur.quantity)
+			price = cur.priceAtAdd
+		}
+		if price == 0 {
+			price = p.Price
+		}
+		quantity = min(quantity, int(p.Stock))
+		if s.MaxItemQuantity > 0 {
+			quantity = min(quantity, s.MaxItemQuantity)
+		}
+
+		if _, err := s.checkLine(ctx, user, e.productID, quantity, lines, inCart); err != nil {
+			logger.Warn("Dropping guest cart line during merge", "product_id", e.productID, "error", err)
+			continue
+		}
+
+		if err := s.setCartItem(ctx, user, e.productID, quantity, price); err != nil {
+			return err
+		}
+		if !inCart {
+			lines++
+		}
+	}
+
+	return s.ClearCart(ctx, guest)
+}
+
+// loadCart reads the cart from Valkey, falling back to Postgres (and
+// repopulating Valkey) when persistence is enabled and the cache is empty.
+func (s *CartService) loadCart(ctx context.Context, owner CartOwner) ([]cartEntry, error) {
+	key := s.key(owner)
+	quantities, err := s.Cache.GetCart(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(quantities) == 0 && s.persist(owner) != nil {
+		return s.rehydrateCart(ctx, owner)
+	}
+
+	prices, err := s.Cache.GetCartPrices(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]cartEntry, 0, len(quantities))
+	for idStr, qtyStr := range quantities {
 		productID, err := strconv.ParseInt(idStr, 10, 64)
 		if err != nil {
-			logger.Warn("Invalid productID in cart, skipping", "product_id", idStr)
+			s.Logger.Warn("Invalid productID in cart, skipping", "product_id", idStr)
 			continue
 		}
 		quantity, err := strconv.Atoi(qtyStr)
 		if err != nil {
-			logger.Warn("Invalid quantity in cart, skipping", "product_id", idStr, "quantity", qtyStr)
+			s.Logger.Warn("Invalid quantity in cart, skipping", "product_id", idStr, "quantity", qtyStr)
 			continue
 		}
 
-		productIDs = append(productIDs, productID)
-		quantityMap[productID] = quantity
-	}
+		var price int32
+		if priceStr, ok := prices[idStr]; ok {
+			if p, err := strconv.ParseInt(priceStr, 10, 32); err == nil {
+				price = int32(p)
+			}
+		}
 
-	if len(productIDs) == 0 {
-		return []CartItem{}, nil
+		entries = append(entries, cartEntry{productID: productID, quantity: quantity, priceAtAdd: price})
 	}
 
-	pgProductIDs := pgtype.Array[int64]{
-		Elements: productIDs,
-		Valid:    true,
-		Dims:     []pgtype.ArrayDimension{{Length: int32(len(productIDs)), LowerBound: 1}},
-	}
+	slices.SortFunc(entries, func(a, b cartEntry) int {
+		return int(a.productID - b.productID)
+	})
+	return entries, nil
+}
 
-	products, err := s.Store.GetProductsByIDs(ctx, pgProductIDs.Elements)
+func (s *CartService) rehydrateCart(ctx context.Context, owner CartOwner) ([]cartEntry, error) {
+	rows, err := s.Persist.GetCartItems(ctx, owner.UserID)
 	if err != nil {
-		logger.Error("Failed to get product details for cart", "error", err)
 		return nil, err
 	}
+	if len(rows) > 0 {
+		s.logger(owner).Info("Restoring cart from database", "items", len(rows))
+	}
 
-	fullCart := make([]CartItem, 0, len(products))
-	for _, p := range products {
-		fullCart = append(fullCart, CartItem{
-			ProductID: p.ID,
-			Quantity:  quantityMap[p.ID],
-			Name:      p.Name,
-			Price:     p.Price,
-			ImageUrl:  p.ImageUrl.String,
-			Stock:     p.Stock,
-			SellerID:  p.SellerID,
-		})
+	key := s.key(owner)
+	entries := make([]cartEntry, 0, len(rows))
+	for _, r := range rows {
+		if err := s.Cache.SetCartItem(ctx, key, r.ProductID, int(r.Quantity), r.PriceAtAdd); err != nil {
+			return nil, err
+		}
+		entries = append(entries, cartEntry{productID: r.ProductID, quantity: int(r.Quantity), priceAtAdd: r.PriceAtAdd})
 	}
+	return entries, nil
+}
 
-	return fullCart, nil
+func (s *CartService) setCartItem(ctx context.Context, owner CartOwner, productID int64, quantity int, price int32) error {
+	if quantity <= 0 {
+		return s.DeleteCartItem(ctx, owner, productID)
+	}
+	if persist := s.persist(owner); persist != nil {
+		if err := persist.SetCartItem(ctx, owner.UserID, productID, quantity, price); err != nil {
+			return err
+		}
+	}
+	if err := s.Cache.SetCartItem(ctx, s.key(owner), productID, quantity, price); err != nil {
+		return err
+	}
+	return s.touch(ctx, owner)
 }
 
-func (s *CartService) UpdateCartItemQuantity(ctx context.Context, userID, productID int64, quantity int) error {
-	s.Logger.Info("Updating cart item quantity", "user_id", userID, "product_id", productID, "quantity", quantity)
-	return s.Cache.UpdateCartItemQuantity(ctx, userID, productID, quantity)
+// UpdateCartItemQuantity sets the quantity of a line already in the cart. A
+// quantity of zero removes the line.
+func (s *CartService) UpdateCartItemQuantity(ctx context.Context, owner CartOwner, productID int64, quantity int) error {
+	logger := s.logger(owner).With("product_id", productID, "quantity", quantity)
+	logger.Info("Updating cart item quantity")
+
+	if quantity == 0 {
+		return s.DeleteCartItem(ctx, owner, productID)
+	}
+
+	entries, err := s.loadCart(ctx, owner)
+	if err != nil {
+		return err
+	}
+	current, inCart := s.findEntry(entries, productID)
+	if !inCart {
+		return ErrCartItemNotFound
+	}
+
+	product, err := s.checkLine(ctx, owner, productID, quantity, len(entries), true)
+	if err != nil {
+		logger.Warn("Rejected cart quantity update", "error", err)
+		return err
+	}
+
+	price := current.priceAtAdd
+	if price == 0 {
+		price = product.Price
+	}
+	return s.setCartItem(ctx, owner, productID, quantity, price)
 }
 
-func (s *CartService) DeleteCartItem(ctx context.Context, userID, productID int64) error {
-	s.Logger.Info("Deleting item from cart", "user_id", userID, "product_id", productID)
-	return s.Cache.DeleteCartItem(ctx, userID, productID)
+func (s *CartService) DeleteCartItem(ctx context.Context, owner CartOwner, productID int64) error {
+	s.logger(owner).Info("Deleting item from cart", "product_id", productID)
+	if persist := s.persist(owner); persist != nil {
+		if err := persist.DeleteCartItem(ctx, owner.UserID, productID); err != nil {
+			return err
+		}
+	}
+	return s.Cache.DeleteCartItem(ctx, s.key(owner), productID)
 }
 
-func (s *CartService) ClearCart(ctx context.Context, userID int64) error {
-	s.Logger.Info("Clearing user cart", "user_id", userID)
-	return s.Cache.ClearCart(ctx, userID)
+func (s *CartService) ClearCart(ctx context.Context, owner CartOwner) error {
+	s.logger(owner).Info("Clearing cart")
+	if persist := s.persist(owner); persist != nil {
+		if err := persist.ClearCart(ctx, owner.UserID); err != nil {
+			return err
+		}
+	}
+	return s.Cache.ClearCart(ctx, s.key(owner))
 }
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"log/slog"
+	"strings"
+)
+
+const (
+	ConditionNew     = "new"
+	ConditionLikeNew = "like_new"
+	ConditionGood    = "good"
+	ConditionFair    = "fair"
+	ConditionPoor    = "poor"
+)
+
+var ProductConditions = []string{ConditionNew, ConditionLikeNew, ConditionGood, ConditionFair, ConditionPoor}
+
+// conditionAliases covers the free-text labels older clients send; it mirrors
+// the mapping the categories migration applied to existing listings.
+var conditionAliases = map[string]string{
+	"brand new": ConditionNew,
+	"sealed":    ConditionNew,
+	"like new":  ConditionLikeNew,
+	"like-new":  ConditionLikeNew,
+	"mint":      ConditionLikeNew,
+	"used":      ConditionGood,
+	"ok":        ConditionFair,
+	"okay":      ConditionFair,
+	"damaged":   ConditionPoor,
+	"for parts": ConditionPoor,
+}
+
+// NormalizeCondition maps a display label such as "Like New" onto the
+// condition enum. Unknown values are returned lower-cased so validation can
+// still reject them.
+func NormalizeCondition(condition string) string {
+	c := strings.ToLower(strings.TrimSpace(condition))
+	if alias, ok := conditionAliases[c]; ok {
+		return alias
+	}
+	return c
+}
+
+type CategoryService struct {
+	Store  data.CategoryStore
+	Logger *slog.Logger
+}
+
+type CategoryNode struct {
+	ID           int64           `json:"id"`
+	Name         string          `json:"name"`
+	Slug         string          `json:"slug"`
+	Icon         string          `json:"icon,omitempty"`
+	ProductCount int64           `json:"product_count"`
+	Children     []*CategoryNode `json:"children"`
+}
+
+func NewCategoryService(store data.CategoryStore, logger *slog.Logger) *CategoryService {
+	return &CategoryService{
+		Store:  store,
+		Logger: logger,
+	}
+}
+
+// ListCategoryTree returns the taxonomy as a tree. A node's product count
+// includes every product filed under its descendants.
+func (s *CategoryService) ListCategoryTree(ctx context.Context) ([]*CategoryNode, error) {
+	rows, err := s.Store.ListCategoriesWithCounts(ctx)
+	if err != nil {
+		s.Logger.Error("Failed to list categories", "error", err)
+		return nil, err
+	}
+
+	nodes := make(map[int64]*CategoryNode, len(rows))
+	for _, r := range rows {
+		nodes[r.ID] = &CategoryNode{
+			ID:           r.ID,
+			Name:         r.Name,
+			Slug:         r.Slug,
+			Icon:         r.Icon.String,
+			ProductCount: r.ProductCount,
+			Children:     []*CategoryNode{},
+		}
+	}
+
+	roots := make([]*CategoryNode, 0)
+	for _, r := range rows {
+		node := nodes[r.ID]
+		parent, ok := nodes[r.ParentID.Int64]
+		if !r.ParentID.Valid || !ok {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	for _, root := range roots {
+		sumProductCounts(root)
+	}
+
+	return roots, nil
+}
+
+func sumProductCounts(node *CategoryNode) int64 {
+	for _, child := range node.Children {
+		node.ProductCount += sumProductCounts(child)
+	}
+	return node.ProductCount
+}
+
+// LookupCategory resolves a category by slug or, failing that, by display
+// name, so both "books-engineering" and "Engineering" are accepted.
+func (s *CategoryService) LookupCategory(ctx context.Context, term string) (db.Category, error) {
+	return s.Store.GetCategoryBySlugOrName(ctx, strings.TrimSpace(term))
+}
@@ -3,15 +3,32 @@ package service
 import (
 	"context"
 	"ecommerce/internal/config"
+	"errors"
 	"io"
 	"log/slog"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 )
 
 type CloudService interface {
 	UploadImage(ctx context.Context, file io.Reader, filename string) (string, error)
+	DeleteImage(ctx context.Context, imageURL string) error
+	ListImages(ctx context.Context) ([]StoredImage, error)
+}
+
+// StoredImage is an object in the storage backend. URL is identical to what
+// UploadImage returned for it, so it can be compared against product rows.
+type StoredImage struct {
+	URL       string
+	CreatedAt time.Time
 }
 
 var _ CloudService = (*CloudinaryService)(nil)
@@ -39,7 +56,7 @@ func (s *CloudinaryService) UploadImage(ctx context.Context, file io.Reader, fil
 	s.Logger.Info("Uploading image to Cloudinary", "filename", filename)
 
 	uploadParams := uploader.UploadParams{
-		Folder: "ecommerce_products",
+		Folder: storageFolder,
 
 		// PublicID: filename,
 	}
@@ -53,3 +70,73 @@ func (s *CloudinaryService) UploadImage(ctx context.Context, file io.Reader, fil
 	s.Logger.Info("Image uploaded successfully", "url", result.SecureURL)
 	return result.SecureURL, nil
 }
+
+func (s *CloudinaryService) DeleteImage(ctx context.Context, imageURL string) error {
+	publicID, err := cloudinaryPublicID(imageURL)
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Info("Deleting image from Cloudinary", "public_id", publicID)
+	res, err := s.Client.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: publicID})
+	if err != nil {
+		s.Logger.Error("Cloudinary delete failed", "public_id", publicID, "error", err)
+		return err
+	}
+	if res.Error.Message != "" {
+		s.Logger.Error("Cloudinary delete failed", "public_id", publicID, "error", res.Error.Message)
+		return errors.New(res.Error.Message)
+	}
+	return nil
+}
+
+func (s *CloudinaryService) ListImages(ctx context.Context) ([]StoredImage, error) {
+	var images []StoredImage
+	params := admin.AssetsParams{
+		AssetType:    api.Image,
+		DeliveryType: api.Upload.String(),
+		Prefix:       storageFolder + "/",
+		MaxResults:   500,
+	}
+
+	for {
+		res, err := s.Client.Admin.Assets(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		if res.Error.Message != "" {
+			return nil, errors.New(res.Error.Message)
+		}
+
+		for _, a := range res.Assets {
+			images = append(images, StoredImage{URL: a.SecureURL, CreatedAt: a.CreatedAt})
+		}
+
+		if res.NextCursor == "" {
+			return images, nil
+		}
+		params.NextCursor = res.NextCursor
+	}
+}
+
+// cloudinaryPublicID extracts the public ID from a delivery URL such as
+// https://res.cloudinary.com/<cloud>/image/upload/v1712/ecommerce_products/abc.jpg
+func cloudinaryPublicID(imageURL string) (string, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return "", err
+	}
+
+	_, rest, ok := strings.Cut(u.Path, "/upload/")
+	if !ok {
+		return "", errors.New("not a cloudinary upload URL: " + imageURL)
+	}
+
+	if version, after, ok := strings.Cut(rest, "/"); ok && strings.HasPrefix(version, "v") {
+		if _, err := strconv.Atoi(version[1:]); err == nil {
+			rest = after
+		}
+	}
+
+	return strings.TrimSuffix(rest, path.Ext(rest)), nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	"log/slog"
+	"time"
+)
+
+// RevenueAccount is the platform wallet that commission is paid into.
+const RevenueAccount = "revenue"
+
+// LineFee is the commission taken from one order line. Amounts are in paise.
+type LineFee struct {
+	ProductID  int64
+	SellerID   int64
+	Gross      int64
+	Bps        int32
+	Commission int64
+	Payout     int64
+}
+
+type Sale struct {
+	OrderItemID     int64     `json:"order_item_id"`
+	OrderID         int64     `json:"order_id"`
+	ProductID       int64     `json:"product_id"`
+	ProductName     string    `json:"product_name"`
+	Quantity        int32     `json:"quantity"`
+	PriceAtPurchase int32     `json:"price_at_purchase"`
+	Gross           int64     `json:"gross"`
+	CommissionBps   int32     `json:"commission_bps"`
+	Commission      int64     `json:"commission"`
+	Payout          int64     `json:"payout"`
+	Status          string    `json:"status"`
+	SoldAt          time.Time `json:"sold_at"`
+}
+
+// SalesSummary is a seller's sales with totals. Amounts are in paise.
+type SalesSummary struct {
+	Sales      []Sale `json:"sales"`
+	Gross      int64  `json:"gross"`
+	Commission int64  `json:"commission"`
+	Payout     int64  `json:"payout"`
+}
+
+type CommissionService struct {
+	Store      data.CommissionStore
+	DefaultBps int32
+	MinFee     int64
+	Logger     *slog.Logger
+}
+
+func NewCommissionService(store data.CommissionStore, defaultBps int, minFee int, logger *slog.Logger) *CommissionService {
+	return &CommissionService{
+		Store:      store,
+		DefaultBps: int32(defaultBps),
+		MinFee:     int64(minFee),
+		Logger:     logger,
+	}
+}
+
+// Fees works out the commission on each line. The rate comes from the
+// product's category, falling back to the default; any non-zero commission
+// is at least MinFee but never more than the line itself. Verified clubs
+// pay nothing.
+func (s *CommissionService) Fees(ctx context.Context, items []CartItem) ([]LineFee, error) {
+	rates, err := s.Store.ListCategoryCommissionRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categoryBps := make(map[int64]int32, len(rates))
+	for _, r := range rates {
+		if r.CommissionBps.Valid {
+			categoryBps[r.ID] = r.CommissionBps.Int32
+		}
+	}
+
+	sellerIDs := make([]int32, 0, len(items))
+	for _, item := range items {
+		sellerIDs = append(sellerIDs, int32(item.SellerID))
+	}
+	clubIDs, err := s.Store.ListVerifiedClubs(ctx, sellerIDs)
+	if err != nil {
+		return nil, err
+	}
+	clubs := make(map[int64]bool, len(clubIDs))
+	for _, id := range clubIDs {
+		clubs[int64(id)] = true
+	}
+
+	fees := make([]LineFee, 0, len(items))
+	for _, item := range items {
+		fee := LineFee{
+			ProductID: item.ProductID,
+			SellerID:  item.SellerID,
+			Gross:     int64(item.Price) * int64(item.Quantity) * 100,
+		}
+
+		if !clubs[item.SellerID] {
+			bps, ok := categoryBps[item.CategoryID]
+			if !ok {
+				bps = s.DefaultBps
+			}
+			fee.Bps = bps
+
+			if bps > 0 {
+				fee.Commission = max(fee.Gross*int64(bps)/10000, s.MinFee)
+				fee.Commission = min(fee.Commission, fee.Gross)
+			}
+		}
+
+		fee.Payout = fee.Gross - fee.Commission
+		fees = append(fees, fee)
+	}
+
+	return fees, nil
+}
+
+func (s *CommissionService) GetSales(ctx context.Context, sellerID int64) (SalesSummary, error) {
+	rows, err := s.Store.ListSalesBySeller(ctx, sellerID)
+	if err != nil {
+		return SalesSummary{}, err
+	}
+
+	summary := SalesSummary{Sales: make([]Sale, 0, len(rows))}
+	for _, r := range rows {
+		sale := Sale{
+			OrderItemID:     r.ID,
+			OrderID:         r.OrderID,
+			ProductID:       r.ProductID,
+			ProductName:     r.ProductName,
+			Quantity:        r.Quantity,
+			PriceAtPurchase: r.PriceAtPurchase,
+			Gross:           int64(r.PriceAtPurchase) * int64(r.Quantity) * 100,
+			CommissionBps:   r.CommissionBps,
+			Commission:      r.CommissionAmount,
+			Payout:          r.SellerPayout,
+			Status:          r.Status,
+			SoldAt:          r.CreatedAt.Time,
+		}
+		summary.Sales = append(summary.Sales, sale)
+		summary.Gross += sale.Gross
+		summary.Commission += sale.Commission
+		summary.Payout += sale.Payout
+	}
+
+	return summary, nil
+}
+
+// SetCategoryRate sets a category's commission. A nil rate clears it so the
+// category inherits from its parent again.
+func (s *CommissionService) SetCategoryRate(ctx context.Context, categoryID int64, bps *int32) error {
+	if err := s.Store.SetCategoryCommission(ctx, categoryID, bps); err != nil {
+		return err
+	}
+	if bps == nil {
+		s.Logger.Info("Category commission cleared", "category_id", categoryID)
+	} else {
+		s.Logger.Info("Category commission updated", "category_id", categoryID, "bps", *bps)
+	}
+	return nil
+}
+
+func (s *CommissionService) SetClubVerified(ctx context.Context, userID int32, verified bool) error {
+	if err := s.Store.SetClubVerified(ctx, userID, verified); err != nil {
+		return err
+	}
+	s.Logger.Info("Club verification updated", "user_id", userID, "verified", verified)
+	return nil
+}
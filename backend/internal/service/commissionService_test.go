@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeCommissionStore serves category rates and verified clubs to Fees.
+type fakeCommissionStore struct {
+	data.CommissionStore
+	rates []db.ListCategoryCommissionRatesRow
+	clubs []int32
+}
+
+func (s fakeCommissionStore) ListCategoryCommissionRates(ctx context.Context) ([]db.ListCategoryCommissionRatesRow, error) {
+	return s.rates, nil
+}
+
+func (s fakeCommissionStore) ListVerifiedClubs(ctx context.Context, ids []int32) ([]int32, error) {
+	var found []int32
+	for _, id := range ids {
+		if slices.Contains(s.clubs, id) && !slices.Contains(found, id) {
+			found = append(found, id)
+		}
+	}
+	return found, nil
+}
+
+func TestCommissionFees(t *testing.T) {
+	const seller, club = 7, 9
+	const books, electronics, unrated, free, uncategorised = 10, 20, 30, 40, 99
+
+	store := fakeCommissionStore{
+		rates: []db.ListCategoryCommissionRatesRow{
+			{ID: books, CommissionBps: pgtype.Int4{Int32: 250, Valid: true}},
+			{ID: electronics, CommissionBps: pgtype.Int4{Int32: 1000, Valid: true}},
+			{ID: unrated},
+			{ID: free, CommissionBps: pgtype.Int4{Int32: 0, Valid: true}},
+		},
+		clubs: []int32{club},
+	}
+
+	tests := []struct {
+		name           string
+		defaultBps     int
+		minFee         int
+		item           CartItem
+		wantBps        int32
+		wantCommission int64
+	}{
+		{"category rate", 500, 0, cartLine(seller, electronics, 1000, 1), 1000, 10000},
+		{"category rate on every unit", 500, 0, cartLine(seller, electronics, 1000, 3), 1000, 30000},
+		{"category without a rate uses the default", 500, 0, cartLine(seller, unrated, 1000, 1), 500, 5000},
+		{"unknown category uses the default", 500, 0, cartLine(seller, uncategorised, 1000, 1), 500, 5000},
+		{"zero category rate beats the default", 500, 500, cartLine(seller, free, 1000, 1), 0, 0},
+		{"zero default rate", 0, 500, cartLine(seller, unrated, 1000, 1), 0, 0},
+		{"exactly one paisa", 100, 0, cartLine(seller, uncategorised, 1, 1), 100, 1},
+		{"just under two paise rounds down", 199, 0, cartLine(seller, uncategorised, 1, 1), 199, 1},
+		{"half a paisa rounds down", 500, 0, cartLine(seller, books, 3, 1), 250, 7},
+		{"just under a paisa rounds to zero", 99, 0, cartLine(seller, uncategorised, 1, 1), 99, 0},
+		{"odd rate rounds down", 333, 0, cartLine(seller, uncategorised, 199, 1), 333, 662},
+		{"below the minimum fee", 500, 500, cartLine(seller, books, 100, 1), 250, 500},
+		{"exactly the minimum fee", 500, 250, cartLine(seller, books, 100, 1), 250, 250},
+		{"minimum fee capped at the line", 500, 500, cartLine(seller, books, 3, 1), 250, 300},
+		{"verified club pays nothing", 500, 500, cartLine(club, electronics, 1000, 1), 0, 0},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewCommissionService(store, tt.defaultBps, tt.minFee, logger)
+
+			fees, err := svc.Fees(context.Background(), []CartItem{tt.item})
+			if err != nil {
+				t.Fatalf("Fees: %v", err)
+			}
+			if len(fees) != 1 {
+				t.Fatalf("got %d fees, want 1", len(fees))
+			}
+
+			fee := fees[0]
+			gross := int64(tt.item.Price) * int64(tt.item.Quantity) * 100
+			if fee.Gross != gross {
+				t.Errorf("gross = %d, want %d", fee.Gross, gross)
+			}
+			if fee.Bps != tt.wantBps {
+				t.Errorf("bps = %d, want %d", fee.Bps, tt.wantBps)
+			}
+			if fee.Commission != tt.wantCommission {
+				t.Errorf("commission = %d, want %d", fee.Commission, tt.wantCommission)
+			}
+			if fee.Payout != fee.Gross-fee.Commission {
+				t.Errorf("payout = %d, want gross - commission = %d", fee.Payout, fee.Gross-fee.Commission)
+			}
+		})
+	}
+}
+
+func TestCommissionFeesPerLine(t *testing.T) {
+	store := fakeCommissionStore{
+		rates: []db.ListCategoryCommissionRatesRow{
+			{ID: 10, CommissionBps: pgtype.Int4{Int32: 1000, Valid: true}},
+		},
+		clubs: []int32{9},
+	}
+	svc := NewCommissionService(store, 500, 0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	items := []CartItem{
+		{ProductID: 1, SellerID: 7, CategoryID: 10, Price: 100, Quantity: 1},
+		{ProductID: 2, SellerID: 9, CategoryID: 10, Price: 100, Quantity: 1},
+		{ProductID: 3, SellerID: 7, CategoryID: 20, Price: 100, Quantity: 1},
+	}
+	fees, err := svc.Fees(context.Background(), items)
+	if err != nil {
+		t.Fatalf("Fees: %v", err)
+	}
+
+	want := []LineFee{
+		{ProductID: 1, SellerID: 7, Gross: 10000, Bps: 1000, Commission: 1000, Payout: 9000},
+		{ProductID: 2, SellerID: 9, Gross: 10000, Bps: 0, Commission: 0, Payout: 10000},
+		{ProductID: 3, SellerID: 7, Gross: 10000, Bps: 500, Commission: 500, Payout: 9500},
+	}
+	if !slices.Equal(fees, want) {
+		t.Errorf("fees = %+v, want %+v", fees, want)
+	}
+}
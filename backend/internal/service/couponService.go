@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	CouponPercent = "percent"
+	CouponFlat    = "flat"
+
+	// PromoAccount is the platform wallet that funds coupon discounts.
+	PromoAccount = "promo"
+)
+
+var CouponTypes = []string{CouponPercent, CouponFlat}
+
+var (
+	ErrCouponNotFound       = errors.New("coupon not found")
+	ErrCouponInactive       = errors.New("coupon is not active")
+	ErrCouponExpired        = errors.New("coupon has expired")
+	ErrCouponUsageLimit     = errors.New("coupon has been fully redeemed")
+	ErrCouponUserLimit      = errors.New("you have already used this coupon")
+	ErrCouponMinCartValue   = errors.New("cart total is below the coupon minimum")
+	ErrCouponNotApplicable  = errors.New("coupon does not apply to any item in your cart")
+	ErrPromoBudgetExhausted = errors.New("promotion budget exhausted")
+)
+
+type CreateCouponParams struct {
+	Code          string
+	Description   string
+	DiscountType  string
+	DiscountValue int32
+	MaxDiscount   *int32
+	MinCartValue  int32
+	UsageLimit    *int32
+	PerUserLimit  int32
+	CategoryID    *int64
+	SellerID      *int64
+	StartsAt      *time.Time
+	EndsAt        *time.Time
+}
+
+// CouponQuote is what a coupon takes off a cart. Amounts are in paise, the
+// same unit as wallet balances and order totals.
+type CouponQuote struct {
+	Code     string `json:"code"`
+	Subtotal int64  `json:"subtotal"`
+	Eligible int64  `json:"eligible"`
+	Discount int64  `json:"discount"`
+	Total    int64  `json:"total"`
+
+	// EligibleBySeller splits Eligible by seller so the discount can be
+	// shared across an order's sub-orders.
+	EligibleBySeller map[int64]int64 `json:"-"`
+}
+
+type CouponService struct {
+	Store   data.CouponStore
+	Carts   *CartService
+	Wallets *WalletService
+	Logger  *slog.Logger
+}
+
+func NewCouponService(store data.CouponStore, carts *CartService, wallets *WalletService, logger *slog.Logger) *CouponService {
+	return &CouponService{
+		Store:   store,
+		Carts:   carts,
+		Wallets: wallets,
+		Logger:  logger,
+	}
+}
+
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *CouponService) CreateCoupon(ctx context.Context, adminID int64, p CreateCouponParams) (db.Coupon, error) {
+	startsAt := time.Now()
+	if p.StartsAt != nil {
+		startsAt = *p.StartsAt
+	}
+
+	arg := db.CreateCouponParams{
+		Code:          NormalizeCouponCode(p.Code),
+		Description:   p.Description,
+		DiscountType:  p.DiscountType,
+		DiscountValue: p.DiscountValue,
+		MinCartValue:  p.MinCartValue,
+		PerUserLimit:  p.PerUserLimit,
+		StartsAt:      pgtype.Timestamptz{Time: startsAt, Valid: true},
+		CreatedBy:     data.NewPGInt64(adminID),
+	}
+	if p.MaxDiscount != nil {
+		arg.MaxDiscount = data.NewPGInt32(*p.MaxDiscount)
+	}
+	if p.UsageLimit != nil {
+		arg.UsageLimit = data.NewPGInt32(*p.UsageLimit)
+	}
+	if p.CategoryID != nil {
+		arg.CategoryID = data.NewPGInt64(*p.CategoryID)
+	}
+	if p.SellerID != nil {
+		arg.SellerID = data.NewPGInt64(*p.SellerID)
+	}
+	if p.EndsAt != nil {
+		arg.EndsAt = pgtype.Timestamptz{Time: *p.EndsAt, Valid: true}
+	}
+
+	coupon, err := s.Store.CreateCoupon(ctx, arg)
+	if err != nil {
+		return db.Coupon{}, err
+	}
+
+	s.Logger.Info("Coupon created", "coupon_id", coupon.ID, "code", coupon.Code, "admin_id", adminID)
+	return coupon, nil
+}
+
+func (s *CouponService) ListCoupons(ctx context.Context) ([]db.Coupon, error) {
+	return s.Store.ListCoupons(ctx)
+}
+
+func (s *CouponService) SetActive(ctx context.Context, couponID int64, active bool) (db.Coupon, error) {
+	coupon, err := s.Store.SetCouponActive(ctx, couponID, active)
+	if errors.Is(err, data.ErrRecordNotFound) {
+		return db.Coupon{}, ErrCouponNotFound
+	}
+	return coupon, err
+}
+
+// PromoBudget returns the promo wallet that discounts are drawn from.
+func (s *CouponService) PromoBudget(ctx context.Context) (Wallet, error) {
+	promoID, err := s.Wallets.BaseStore.GetPlatformAccount(ctx, PromoAccount)
+	if err != nil {
+		return Wallet{}, err
+	}
+	return s.Wallets.GetWalletByUserID(ctx, int64(promoID))
+}
+
+// FundPromoBudget tops up the promo wallet. Amount is in paise.
+func (s *CouponService) FundPromoBudget(ctx context.Context, adminID int64, amount int64) (Wallet, error) {
+	promoID, err := s.Wallets.BaseStore.GetPlatformAccount(ctx, PromoAccount)
+	if err != nil {
+		return Wallet{}, err
+	}
+
+	wallet, err := s.Wallets.Credit(ctx, promoID, amount)
+	if err != nil {
+		return Wallet{}, err
+	}
+
+	s.Logger.Info("Promo budget funded", "admin_id", adminID, "amount", amount, "balance", wallet.Balance)
+	return wallet, nil
+}
+
+// Preview quotes a coupon against the user's current cart without
+// redeeming it.
+func (s *CouponService) Preview(ctx context.Context, userID int64, code string) (CouponQuote, error) {
+	cart, err := s.Carts.GetCart(ctx, UserCart(userID))
+	if err != nil {
+		return CouponQuote{}, err
+	}
+
+	items := make([]CartItem, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		if item.Available {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return CouponQuote{}, ErrCartEmpty
+	}
+
+	coupon, err := s.Store.GetCouponByCode(ctx, NormalizeCouponCode(code))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return CouponQuote{}, ErrCouponNotFound
+		}
+		return CouponQuote{}, err
+	}
+
+	return s.quote(ctx, s.Store, coupon, userID, items)
+}
+
+// Apply locks the coupon row and quotes it against the items being ordered.
+// It must be called with a store bound to the checkout transaction so the
+// usage counters cannot race with another checkout.
+func (s *CouponService) Apply(ctx context.Context, txStore data.CouponStore, code string, userID int64, items []CartItem) (db.Coupon, CouponQuote, error) {
+	coupon, err := txStore.GetCouponByCodeForUpdate(ctx, NormalizeCouponCode(code))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return db.Coupon{}, CouponQuote{}, ErrCouponNotFound
+		}
+		return db.Coupon{}, CouponQuote{}, err
+	}
+
+	quote, err := s.quote(ctx, txStore, coupon, userID, items)
+	if err != nil {
+		return db.Coupon{}, CouponQuote{}, err
+	}
+	return coupon, quote, nil
+}
+
+// Redeem records a coupon use against an order. Like Apply it must run
+// inside the checkout transaction.
+func (s *CouponService) Redeem(ctx context.Context, txStore data.CouponStore, couponID, userID, orderID, discount int64) error {
+	err := txStore.CreateCouponRedemption(ctx, db.CreateCouponRedemptionParams{
+		CouponID:       couponID,
+		UserID:         userID,
+		OrderID:        orderID,
+		DiscountAmount: discount,
+	})
+	if err != nil {
+		return err
+	}
+	return txStore.IncrementCouponUsage(ctx, couponID)
+}
+
+func (s *CouponService) quote(ctx context.Context, store data.CouponStore, coupon db.Coupon, userID int64, items []CartItem) (CouponQuote, error) {
+	now := time.Now()
+	if !coupon.IsActive || now.Before(coupon.StartsAt.Time) {
+		return CouponQuote{}, ErrCouponInactive
+	}
+	if coupon.EndsAt.Valid && !now.Before(coupon.EndsAt.Time) {
+		return CouponQuote{}, ErrCouponExpired
+	}
+	if coupon.UsageLimit.Valid && coupon.TimesUsed >= coupon.UsageLimit.Int32 {
+		return CouponQuote{}, ErrCouponUsageLimit
+	}
+
+	used, err := store.CountCouponRedemptionsByUser(ctx, coupon.ID, userID)
+	if err != nil {
+		return CouponQuote{}, err
+	}
+	if used >= int64(coupon.PerUserLimit) {
+		return CouponQuote{}, ErrCouponUserLimit
+	}
+
+	var categories map[int64]bool
+	if coupon.CategoryID.Valid {
+		ids, err := store.GetCategoryDescendantIDs(ctx, coupon.CategoryID.Int64)
+		if err != nil {
+			return CouponQuote{}, err
+		}
+		categories = make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			categories[id] = true
+		}
+	}
+
+	quote := CouponQuote{Code: coupon.Code, EligibleBySeller: make(map[int64]int64)}
+	for _, item := range items {
+		line := int64(item.Price) * int64(item.Quantity) * 100
+		quote.Subtotal += line
+
+		if coupon.SellerID.Valid && item.SellerID != coupon.SellerID.Int64 {
+			continue
+		}
+		if categories != nil && !categories[item.CategoryID] {
+			continue
+		}
+		quote.Eligible += line
+		quote.EligibleBySeller[item.SellerID] += line
+	}
+
+	if quote.Eligible == 0 {
+		return CouponQuote{}, ErrCouponNotApplicable
+	}
+	// The minimum applies to what the coupon covers, so a seller's coupon
+	// can't be unlocked by padding the cart with someone else's items.
+	if quote.Eligible < int64(coupon.MinCartValue)*100 {
+		return CouponQuote{}, ErrCouponMinCartValue
+	}
+
+	switch coupon.DiscountType {
+	case CouponPercent:
+		quote.Discount = quote.Eligible * int64(coupon.DiscountValue) / 100
+	case CouponFlat:
+		quote.Discount = int64(coupon.DiscountValue) * 100
+	}
+	if coupon.MaxDiscount.Valid && quote.Discount > int64(coupon.MaxDiscount.Int32)*100 {
+		quote.Discount = int64(coupon.MaxDiscount.Int32) * 100
+	}
+	if quote.Discount > quote.Eligible {
+		quote.Discount = quote.Eligible
+	}
+
+	quote.Total = quote.Subtotal - quote.Discount
+	return quote, nil
+}
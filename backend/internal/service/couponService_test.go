@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeCouponStore answers the lookups quote makes. Anything else panics on
+// the nil embedded interface.
+type fakeCouponStore struct {
+	data.CouponStore
+	used        int64
+	descendants map[int64][]int64
+}
+
+func (s fakeCouponStore) CountCouponRedemptionsByUser(ctx context.Context, couponID, userID int64) (int64, error) {
+	return s.used, nil
+}
+
+func (s fakeCouponStore) GetCategoryDescendantIDs(ctx context.Context, categoryID int64) ([]int64, error) {
+	return append([]int64{categoryID}, s.descendants[categoryID]...), nil
+}
+
+// testCoupon is a live coupon anyone may use once; edit adjusts it per case.
+func testCoupon(edit func(c *db.Coupon)) db.Coupon {
+	c := db.Coupon{
+		ID:           1,
+		Code:         "TEST",
+		DiscountType: CouponPercent,
+		PerUserLimit: 1,
+		StartsAt:     pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true},
+		IsActive:     true,
+	}
+	if edit != nil {
+		edit(&c)
+	}
+	return c
+}
+
+// cartLine is a cart line priced in rupees, as cart items are.
+func cartLine(sellerID, categoryID int64, price int32, quantity int) CartItem {
+	return CartItem{SellerID: sellerID, CategoryID: categoryID, Price: price, Quantity: quantity}
+}
+
+func TestCouponQuote(t *testing.T) {
+	const seller, otherSeller = 7, 8
+	const books, textbooks, electronics = 10, 11, 20
+
+	tests := []struct {
+		name    string
+		coupon  db.Coupon
+		items   []CartItem
+		used    int64
+		want    CouponQuote
+		wantErr error
+	}{
+		{
+			name:   "percentage",
+			coupon: testCoupon(func(c *db.Coupon) { c.DiscountValue = 10 }),
+			items:  []CartItem{cartLine(seller, books, 100, 2), cartLine(otherSeller, books, 50, 1)},
+			want:   CouponQuote{Subtotal: 25000, Eligible: 25000, Discount: 2500, Total: 22500},
+		},
+		{
+			name:   "percentage of an odd amount",
+			coupon: testCoupon(func(c *db.Coupon) { c.DiscountValue = 15 }),
+			items:  []CartItem{cartLine(seller, books, 333, 1)},
+			want:   CouponQuote{Subtotal: 33300, Eligible: 33300, Discount: 4995, Total: 28305},
+		},
+		{
+			name:   "fixed",
+			coupon: testCoupon(func(c *db.Coupon) { c.DiscountType = CouponFlat; c.DiscountValue = 50 }),
+			items:  []CartItem{cartLine(seller, books, 250, 1)},
+			want:   CouponQuote{Subtotal: 25000, Eligible: 25000, Discount: 5000, Total: 20000},
+		},
+		{
+			name:   "fixed larger than the subtotal",
+			coupon: testCoupon(func(c *db.Coupon) { c.DiscountType = CouponFlat; c.DiscountValue = 500 }),
+			items:  []CartItem{cartLine(seller, books, 120, 1), cartLine(otherSeller, books, 80, 1)},
+			want:   CouponQuote{Subtotal: 20000, Eligible: 20000, Discount: 20000, Total: 0},
+		},
+		{
+			name: "fixed larger than the eligible items",
+			coupon: testCoupon(func(c *db.Coupon) {
+				c.DiscountType = CouponFlat
+				c.DiscountValue = 150
+				c.SellerID = pgtype.Int8{Int64: seller, Valid: true}
+			}),
+			items: []CartItem{cartLine(seller, books, 100, 1), cartLine(otherSeller, books, 300, 1)},
+			want:  CouponQuote{Subtotal: 40000, Eligible: 10000, Discount: 10000, Total: 30000},
+		},
+		{
+			name: "percentage capped at the max discount",
+			coupon: testCoupon(func(c *db.Coupon) {
+				c.DiscountValue = 50
+				c.MaxDiscount = pgtype.Int4{Int32: 200, Valid: true}
+			}),
+			items: []CartItem{cartLine(seller, books, 1000, 1)},
+			want:  CouponQuote{Subtotal: 100000, Eligible: 100000, Discount: 20000, Total: 80000},
+		},
+		{
+			name: "percentage under the max discount",
+			coupon: testCoupon(func(c *db.Coupon) {
+				c.DiscountValue = 10
+				c.MaxDiscount = pgtype.Int4{Int32: 200, Valid: true}
+			}),
+			items: []CartItem{cartLine(seller, books, 1000, 1)},
+			want:  CouponQuote{Subtotal: 100000, Eligible: 100000, Discount: 10000, Total: 90000},
+		},
+		{
+			name: "fixed capped at the max discount",
+			coupon: testCoupon(func(c *db.Coupon) {
+				c.DiscountType = CouponFlat
+				c.DiscountValue = 300
+				c.MaxDiscount = pgtype.Int4{Int32: 100, Valid: true}
+			}),
+			items: []CartItem{cartLine(seller, books, 1000, 1)},
+			want:  CouponQuote{Subtotal: 100000, Eligible: 100000, Discount: 10000, Total: 90000},
+		},
+		{
+			name:   "min spend met exactly",
+			coupon: testCoupon(func(c *db.Coupon) { c.DiscountValue = 10; c.MinCartValue = 500 }),
+			items:  []CartItem{cartLine(seller, books, 250, 2)},
+			want:   CouponQuote{Subtotal: 50000, Eligible: 50000, Discount: 5000, Total: 45000},
+		},
+		{
+			name:    "min spend missed by a rupee",
+			coupon:  testCoupon(func(c *db.Coupon) { c.DiscountValue = 10; c.MinCartValue = 500 }),
+			items:   []CartItem{cartLine(seller, books, 499, 1)},
+			wantErr: ErrCouponMinCartValue,
+		},
+		{
+			name: "min spend counts only eligible items",
+			coupon: testCoupon(func(c *db.Coupon) {
+				c.DiscountValue = 10
+				c.MinCartValue = 500
+				c.SellerID = pgtype.Int8{Int64: seller, Valid: true}
+			}),
+			items:   []CartItem{cartLine(seller, books, 400, 1), cartLine(otherSeller, books, 200, 1)},
+			wantErr: ErrCouponMinCartValue,
+		},
+		{
+			name: "category includes its subcategories",
+			coupon: testCoupon(func(c *db.Coupon) {
+				c.DiscountValue = 10
+				c.CategoryID = pgtype.Int8{Int64: books, Valid: true}
+			}),
+			items: []CartItem{cartLine(seller, textbooks, 200, 1), cartLine(seller, electronics, 800, 1)},
+			want:  CouponQuote{Subtotal: 100000, Eligible: 20000, Discount: 2000, Total: 98000},
+		},
+		{
+			name: "nothing eligible",
+			coupon: testCoupon(func(c *db.Coupon) {
+				c.DiscountValue = 10
+				c.SellerID = pgtype.Int8{Int64: seller, Valid: true}
+			}),
+			items:   []CartItem{cartLine(otherSeller, books, 200, 1)},
+			wantErr: ErrCouponNotApplicable,
+		},
+		{
+			name:    "already used",
+			coupon:  testCoupon(func(c *db.Coupon) { c.DiscountValue = 10 }),
+			items:   []CartItem{cartLine(seller, books, 200, 1)},
+			used:    1,
+			wantErr: ErrCouponUserLimit,
+		},
+		{
+			name: "fully redeemed",
+			coupon: testCoupon(func(c *db.Coupon) {
+				c.DiscountValue = 10
+				c.UsageLimit = pgtype.Int4{Int32: 3, Valid: true}
+				c.TimesUsed = 3
+			}),
+			items:   []CartItem{cartLine(seller, books, 200, 1)},
+			wantErr: ErrCouponUsageLimit,
+		},
+		{
+			name:    "not started",
+			coupon:  testCoupon(func(c *db.Coupon) { c.StartsAt.Time = time.Now().Add(time.Hour) }),
+			items:   []CartItem{cartLine(seller, books, 200, 1)},
+			wantErr: ErrCouponInactive,
+		},
+		{
+			name:    "expired",
+			coupon:  testCoupon(func(c *db.Coupon) { c.EndsAt = pgtype.Timestamptz{Time: time.Now(), Valid: true} }),
+			items:   []CartItem{cartLine(seller, books, 200, 1)},
+			wantErr: ErrCouponExpired,
+		},
+	}
+
+	svc := NewCouponService(nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := fakeCouponStore{used: tt.used, descendants: map[int64][]int64{books: {textbooks}}}
+
+			got, err := svc.quote(context.Background(), store, tt.coupon, 1, tt.items)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Subtotal != tt.want.Subtotal || got.Eligible != tt.want.Eligible || got.Discount != tt.want.Discount || got.Total != tt.want.Total {
+				t.Errorf("quote = subtotal %d, eligible %d, discount %d, total %d; want %d, %d, %d, %d",
+					got.Subtotal, got.Eligible, got.Discount, got.Total,
+					tt.want.Subtotal, tt.want.Eligible, tt.want.Discount, tt.want.Total)
+			}
+
+			var bySeller int64
+			for v := range maps.Values(got.EligibleBySeller) {
+				bySeller += v
+			}
+			if bySeller != got.Eligible {
+				t.Errorf("eligible by seller sums to %d, want %d", bySeller, got.Eligible)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/cache"
+	"ecommerce/internal/data"
+	"ecommerce/internal/token"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"regexp"
+	"time"
+)
+
+const (
+	EventWalletBalance    = "wallet.balance"
+	EventWalletTransferIn = "wallet.transfer_in"
+	EventOrderStatus      = "order.status"
+	EventMessageCreated   = "message.created"
+	EventListingModerated = "listing.moderated"
+
+	// eventLogLength and eventLogTTL bound how far back a reconnecting
+	// client can resume from.
+	eventLogLength = 200
+	eventLogTTL    = 24 * time.Hour
+
+	eventBatchSize    = 100
+	eventPingInterval = 15 * time.Second
+
+	// StreamTokenTTL only has to cover the gap between fetching a token and
+	// opening the stream; EventSource clients fetch a fresh one to reconnect.
+	StreamTokenTTL = time.Minute
+)
+
+var ErrInvalidEventID = errors.New("invalid event ID")
+
+var eventIDRX = regexp.MustCompile(`^\d+-\d+$`)
+
+// Event is one realtime update for a user.
+type Event struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type TransferReceived struct {
+	FromUserID int32 `json:"from_user_id"`
+	Amount     int64 `json:"amount"`
+}
+
+type OrderStatusChanged struct {
+	OrderID    int64  `json:"order_id"`
+	SubOrderID int64  `json:"sub_order_id"`
+	Status     string `json:"status"`
+}
+
+// EventWriter is a client connection events are streamed to.
+type EventWriter interface {
+	WriteEvent(e Event) error
+	Ping() error
+}
+
+// EventService logs each user's events in Valkey and fans them out over
+// pub/sub, so a client connected to any API instance gets them and can
+// resume after a reconnect.
+type EventService struct {
+	Cache   *cache.ValkeyCache
+	Wallets data.WalletStore
+	Logger  *slog.Logger
+}
+
+func NewEventService(cache *cache.ValkeyCache, wallets data.WalletStore, logger *slog.Logger) *EventService {
+	return &EventService{
+		Cache:   cache,
+		Wallets: wallets,
+		Logger:  logger,
+	}
+}
+
+// NewStreamToken mints a token that lets a browser EventSource, which cannot
+// send an Authorization header, open the user's event stream.
+func (s *EventService) NewStreamToken(userID int64) (*token.Token, error) {
+	return token.GenerateAccessToken(userID, StreamTokenTTL, token.ScopeEventStream)
+}
+
+// Publish sends an event to a user. It is best effort: the change the event
+// describes has already been committed, so failures are only logged.
+func (s *EventService) Publish(ctx context.Context, userID int64, eventType string, data any) {
+	logger := s.Logger.With("user_id", userID, "type", eventType)
+
+	payload, err := json.Marshal(struct {
+		Type string `json:"type"`
+		Data any    `json:"data"`
+	}{eventType, data})
+	if err != nil {
+		logger.Error("Failed to encode event", "error", err)
+		return
+	}
+
+	id, err := s.Cache.AppendUserEvent(ctx, userID, string(payload), eventLogLength, eventLogTTL)
+	if err != nil {
+		logger.Warn("Failed to log event", "error", err)
+		return
+	}
+	if err := s.Cache.PublishUserEvent(ctx, userID, id); err != nil {
+		logger.Warn("Failed to publish event", "error", err)
+	}
+}
+
+// PublishBalance sends each user their current wallet balance.
+func (s *EventService) PublishBalance(ctx context.Context, userIDs ...int64) {
+	for _, userID := range userIDs {
+		w, err := s.Wallets.GetWalletByUserID(ctx, userID)
+		if err != nil {
+			s.Logger.Warn("Failed to load wallet for balance event", "user_id", userID, "error", err)
+			continue
+		}
+		s.Publish(ctx, userID, EventWalletBalance, Wallet{
+			UserID:         w.UserID,
+			Balance:        w.Balance,
+			LifetimeSpent:  w.LifetimeSpent,
+			LifetimeEarned: w.LifetimeEarned,
+		})
+	}
+}
+
+// Stream writes the user's events to w until ctx is cancelled or a write
+// fails. With a lastEventID it first replays everything logged since then;
+// without one it starts from now.
+func (s *EventService) Stream(ctx context.Context, userID int64, lastEventID string, w EventWriter) error {
+	if lastEventID != "" && !eventIDRX.MatchString(lastEventID) {
+		return ErrInvalidEventID
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Pub/sub is only a wake-up; the log is read from the cursor every time,
+	// so nothing is sent twice or out of order.
+	wake := make(chan struct{}, 1)
+	subErr := make(chan error, 1)
+	go func() {
+		subErr <- s.Cache.SubscribeUserEvents(ctx, userID, func(string) {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	cursor := lastEventID
+	if cursor == "" {
+		latest, err := s.Cache.LatestUserEventID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		cursor = latest
+	}
+
+	ticker := time.NewTicker(eventPingInterval)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		if cursor, err = s.sendEventsAfter(ctx, userID, cursor, w); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-subErr:
+			return err
+		case <-wake:
+		case <-ticker.C:
+			// The ping finds dropped clients; reading the log again on the
+			// way round picks up anything published before the
+			// subscription was ready.
+			if err := w.Ping(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *EventService) sendEventsAfter(ctx context.Context, userID int64, cursor string, w EventWriter) (string, error) {
+	for {
+		entries, err := s.Cache.UserEventsAfter(ctx, userID, cursor, eventBatchSize)
+		if err != nil {
+			return cursor, err
+		}
+
+		for _, entry := range entries {
+			var e Event
+			if err := json.Unmarshal([]byte(entry.Payload), &e); err != nil {
+				s.Logger.Error("Skipping malformed event", "user_id", userID, "event_id", entry.ID, "error", err)
+			} else {
+				e.ID = entry.ID
+				if err := w.WriteEvent(e); err != nil {
+					return cursor, err
+				}
+			}
+			cursor = entry.ID
+		}
+
+		if len(entries) < eventBatchSize {
+			return cursor, nil
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"ecommerce/internal/token"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// MaxHandoverAttempts is how many wrong codes a seller may enter before
+	// the buyer has to generate a new one.
+	MaxHandoverAttempts = 5
+
+	// HandoverGracePeriod is how long after the chosen slot ends the code
+	// still works, for meetups that run late.
+	HandoverGracePeriod = 2 * time.Hour
+)
+
+var (
+	ErrPickupLocationNotFound = errors.New("pickup location not found")
+	ErrPickupSlotNotFound     = errors.New("pickup slot not found")
+	ErrPickupSlotPassed       = errors.New("pickup slot has already passed")
+	ErrPickupSlotChosen       = errors.New("the buyer has already chosen a pickup slot")
+	ErrNoPickupSlotChosen     = errors.New("no pickup slot has been chosen yet")
+	ErrHandoverCodeInvalid    = errors.New("invalid handover code")
+	ErrHandoverCodeExpired    = errors.New("handover code has expired")
+	ErrHandoverLocked         = errors.New("too many wrong codes, ask the buyer to generate a new one")
+)
+
+type ProposedSlot struct {
+	LocationID int64
+	StartsAt   time.Time
+	EndsAt     time.Time
+}
+
+type PickupSlot struct {
+	ID         int64     `json:"id"`
+	LocationID int64     `json:"location_id"`
+	Location   string    `json:"location"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+}
+
+// HandoverDetails is what both sides of a sub-order see about its pickup.
+// The code itself is only ever returned to the buyer when it is generated.
+type HandoverDetails struct {
+	SubOrderID     int64        `json:"sub_order_id"`
+	Status         string       `json:"status"`
+	Slots          []PickupSlot `json:"slots"`
+	ChosenSlotID   *int64       `json:"chosen_slot_id"`
+	CodeExpiresAt  *time.Time   `json:"code_expires_at"`
+	FailedAttempts int32        `json:"failed_attempts"`
+	HandedOverAt   *time.Time   `json:"handed_over_at"`
+}
+
+type HandoverCode struct {
+	Code      string     `json:"code"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	Slot      PickupSlot `json:"slot"`
+}
+
+type HandoverService struct {
+	Store  data.HandoverStore
+	Orders *OrderService
+	Logger *slog.Logger
+}
+
+func NewHandoverService(store data.HandoverStore, orders *OrderService, logger *slog.Logger) *HandoverService {
+	return &HandoverService{
+		Store:  store,
+		Orders: orders,
+		Logger: logger,
+	}
+}
+
+func (s *HandoverService) ListLocations(ctx context.Context) ([]db.CampusLocation, error) {
+	return s.Store.ListCampusLocations(ctx)
+}
+
+// ProposeSlots replaces the seller's pickup slots for a sub-order. Once the
+// buyer has chosen a slot it can only be replaced after that slot has passed.
+func (s *HandoverService) ProposeSlots(ctx context.Context, sellerID, subOrderID int64, slots []ProposedSlot) ([]PickupSlot, error) {
+	logger := s.Logger.With("sub_order_id", subOrderID, "seller_id", sellerID)
+
+	tx, err := s.Orders.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	so, err := s.lockPendingSubOrder(ctx, tx, subOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if so.SellerID != sellerID {
+		return nil, ErrNotOrderParticipant
+	}
+
+	handover, err := txStore.GetHandover(ctx, subOrderID)
+	if err == nil {
+		chosen, err := txStore.GetPickupSlot(ctx, subOrderID, handover.SlotID)
+		if err != nil {
+			return nil, err
+		}
+		if chosen.EndsAt.Time.Add(HandoverGracePeriod).After(time.Now()) {
+			return nil, ErrPickupSlotChosen
+		}
+	} else if !errors.Is(err, data.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Dropping the old slots also drops a handover whose slot has passed.
+	if err := txStore.DeletePickupSlots(ctx, subOrderID); err != nil {
+		return nil, err
+	}
+	for _, slot := range slots {
+		if _, err := txStore.CreatePickupSlot(ctx, subOrderID, slot.LocationID, slot.StartsAt, slot.EndsAt); err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				return nil, ErrPickupLocationNotFound
+			}
+			return nil, err
+		}
+	}
+
+	rows, err := txStore.ListPickupSlots(ctx, subOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return nil, err
+	}
+
+	logger.Info("Pickup slots proposed", "count", len(rows))
+	return pickupSlots(rows), nil
+}
+
+// ChooseSlot records the buyer's pick and gives them the code to show the
+// seller at the meetup.
+func (s *HandoverService) ChooseSlot(ctx context.Context, buyerID, subOrderID, slotID int64) (HandoverCode, error) {
+	return s.issueCode(ctx, buyerID, subOrderID, &slotID)
+}
+
+// RegenerateCode replaces the buyer's code for the slot they already chose,
+// e.g. when they've lost it or the seller got locked out.
+func (s *HandoverService) RegenerateCode(ctx context.Context, buyerID, subOrderID int64) (HandoverCode, error) {
+	return s.issueCode(ctx, buyerID, subOrderID, nil)
+}
+
+func (s *HandoverService) issueCode(ctx context.Context, buyerID, subOrderID int64, slotID *int64) (HandoverCode, error) {
+	logger := s.Logger.With("sub_order_id", subOrderID, "buyer_id", buyerID)
+
+	tx, err := s.Orders.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return HandoverCode{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	so, err := s.lockPendingSubOrder(ctx, tx, subOrderID)
+	if err != nil {
+		return HandoverCode{}, err
+	}
+	if so.BuyerID != buyerID {
+		return HandoverCode{}, ErrNotOrderParticipant
+	}
+
+	if slotID == nil {
+		handover, err := txStore.GetHandover(ctx, subOrderID)
+		if err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				return HandoverCode{}, ErrNoPickupSlotChosen
+			}
+			return HandoverCode{}, err
+		}
+		slotID = &handover.SlotID
+	}
+
+	slot, err := txStore.GetPickupSlot(ctx, subOrderID, *slotID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return HandoverCode{}, ErrPickupSlotNotFound
+		}
+		return HandoverCode{}, err
+	}
+
+	expiresAt := slot.EndsAt.Time.Add(HandoverGracePeriod)
+	if !expiresAt.After(time.Now()) {
+		return HandoverCode{}, ErrPickupSlotPassed
+	}
+
+	code, err := token.GenerateVerificationToken(buyerID, time.Until(expiresAt), token.ScopeHandover)
+	if err != nil {
+		return HandoverCode{}, err
+	}
+
+	if _, err := txStore.UpsertHandover(ctx, subOrderID, slot.ID, code.Hash, expiresAt); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return HandoverCode{}, ErrSubOrderNotPending
+		}
+		return HandoverCode{}, err
+	}
+
+	rows, err := txStore.ListPickupSlots(ctx, subOrderID)
+	if err != nil {
+		return HandoverCode{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return HandoverCode{}, err
+	}
+
+	result := HandoverCode{Code: code.Plaintext, ExpiresAt: expiresAt}
+	for _, p := range pickupSlots(rows) {
+		if p.ID == slot.ID {
+			result.Slot = p
+		}
+	}
+
+	logger.Info("Handover code issued", "slot_id", slot.ID)
+	return result, nil
+}
+
+func (s *HandoverService) GetHandover(ctx context.Context, userID, subOrderID int64) (HandoverDetails, error) {
+	so, err := s.Orders.OrderStore.GetSubOrder(ctx, subOrderID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return HandoverDetails{}, ErrOrderNotFound
+		}
+		return HandoverDetails{}, err
+	}
+	if so.BuyerID != userID && so.SellerID != userID {
+		return HandoverDetails{}, ErrNotOrderParticipant
+	}
+
+	rows, err := s.Store.ListPickupSlots(ctx, subOrderID)
+	if err != nil {
+		return HandoverDetails{}, err
+	}
+
+	details := HandoverDetails{
+		SubOrderID: subOrderID,
+		Status:     so.Status,
+		Slots:      pickupSlots(rows),
+	}
+
+	handover, err := s.Store.GetHandover(ctx, subOrderID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return details, nil
+		}
+		return HandoverDetails{}, err
+	}
+
+	details.ChosenSlotID = &handover.SlotID
+	details.FailedAttempts = handover.FailedAttempts
+	if handover.CodeHash.Valid {
+		details.CodeExpiresAt = &handover.CodeExpiresAt.Time
+	}
+	if handover.HandedOverAt.Valid {
+		details.HandedOverAt = &handover.HandedOverAt.Time
+	}
+
+	return details, nil
+}
+
+// CompleteHandover is called by the seller with the code the buyer showed
+// them. A matching code marks the items handed over and releases the
+// sub-order's escrow; wrong codes count towards MaxHandoverAttempts.
+func (s *HandoverService) CompleteHandover(ctx context.Context, sellerID, subOrderID int64, code string) (db.SubOrder, error) {
+	logger := s.Logger.With("sub_order_id", subOrderID, "seller_id", sellerID)
+
+	tx, err := s.Orders.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return db.SubOrder{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	so, err := s.lockPendingSubOrder(ctx, tx, subOrderID)
+	if err != nil {
+		return db.SubOrder{}, err
+	}
+	if so.SellerID != sellerID {
+		return db.SubOrder{}, ErrNotOrderParticipant
+	}
+
+	// The attempt is counted before the code is compared, so guesses made
+	// at the same time can't all slip in under the limit.
+	handover, err := txStore.ClaimHandoverAttempt(ctx, subOrderID, MaxHandoverAttempts)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return db.SubOrder{}, s.noAttemptReason(ctx, txStore, subOrderID)
+		}
+		return db.SubOrder{}, err
+	}
+	if time.Now().After(handover.CodeExpiresAt.Time) {
+		return db.SubOrder{}, ErrHandoverCodeExpired
+	}
+
+	match, err := token.MatchToken(code, handover.CodeHash.String)
+	if err != nil {
+		return db.SubOrder{}, err
+	}
+	if !match {
+		if err := tx.Commit(ctx); err != nil {
+			logger.Error("Failed to commit transaction", "error", err)
+			return db.SubOrder{}, err
+		}
+		logger.Warn("Wrong handover code entered", "failed_attempts", handover.FailedAttempts)
+		if handover.FailedAttempts >= MaxHandoverAttempts {
+			return db.SubOrder{}, ErrHandoverLocked
+		}
+		return db.SubOrder{}, ErrHandoverCodeInvalid
+	}
+
+	return s.Orders.commitSettlement(ctx, tx, so, func(ctx context.Context, tx pgx.Tx, so db.GetSubOrderForUpdateRow) (db.SubOrder, error) {
+		if err := s.Store.WithTx(tx).CompleteHandover(ctx, so.ID, handover.CodeHash.String); err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				return db.SubOrder{}, ErrHandoverCodeInvalid
+			}
+			return db.SubOrder{}, err
+		}
+		return s.Orders.releaseSubOrder(ctx, tx, so)
+	})
+}
+
+// noAttemptReason explains why ClaimHandoverAttempt found nothing to claim.
+func (s *HandoverService) noAttemptReason(ctx context.Context, store data.HandoverStore, subOrderID int64) error {
+	handover, err := store.GetHandover(ctx, subOrderID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return ErrNoPickupSlotChosen
+		}
+		return err
+	}
+	if handover.CodeHash.Valid && handover.FailedAttempts >= MaxHandoverAttempts {
+		return ErrHandoverLocked
+	}
+	return ErrHandoverCodeInvalid
+}
+
+func (s *HandoverService) lockPendingSubOrder(ctx context.Context, tx pgx.Tx, subOrderID int64) (db.GetSubOrderForUpdateRow, error) {
+	so, err := s.Orders.OrderStore.WithTx(tx).GetSubOrderForUpdate(ctx, subOrderID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return db.GetSubOrderForUpdateRow{}, ErrOrderNotFound
+		}
+		return db.GetSubOrderForUpdateRow{}, err
+	}
+	if so.Status != SubOrderPending {
+		return db.GetSubOrderForUpdateRow{}, ErrSubOrderNotPending
+	}
+	return so, nil
+}
+
+func pickupSlots(rows []db.ListPickupSlotsRow) []PickupSlot {
+	slots := make([]PickupSlot, 0, len(rows))
+	for _, r := range rows {
+		slots = append(slots, PickupSlot{
+			ID:         r.ID,
+			LocationID: r.LocationID,
+			Location:   r.Location,
+			StartsAt:   r.StartsAt.Time,
+			EndsAt:     r.EndsAt.Time,
+		})
+	}
+	return slots
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"ecommerce/internal/config"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const LocalStorageRoute = "/uploads"
+
+var _ CloudService = (*LocalStorageService)(nil)
+
+// LocalStorageService keeps images on disk and serves them under
+// LocalStorageRoute. The URLs it persists are permanent public links: their
+// signature only proves the path was issued by this server, so arbitrary files
+// under Dir cannot be fetched, and never expires. Use SignedURL with a ttl for
+// links that should stop working.
+type LocalStorageService struct {
+	Dir        string
+	BaseURL    string
+	SigningKey []byte
+	Logger     *slog.Logger
+}
+
+func NewLocalStorageService(cfg *config.Config, logger *slog.Logger) (*LocalStorageService, error) {
+	if cfg.StorageSigningKey == "" {
+		return nil, errors.New("config error: STORAGE_SIGNING_KEY is required for local storage")
+	}
+
+	if err := os.MkdirAll(filepath.Join(cfg.LocalStorageDir, storageFolder), 0o755); err != nil {
+		logger.Error("Failed to create local storage directory", "dir", cfg.LocalStorageDir, "error", err)
+		return nil, err
+	}
+
+	logger.Info("Local storage service initialized successfully", "dir", cfg.LocalStorageDir)
+	return &LocalStorageService{
+		Dir:        cfg.LocalStorageDir,
+		BaseURL:    strings.TrimRight(cfg.PublicBaseURL, "/"),
+		SigningKey: []byte(cfg.StorageSigningKey),
+		Logger:     logger,
+	}, nil
+}
+
+func (s *LocalStorageService) UploadImage(ctx context.Context, file io.Reader, filename string) (string, error) {
+	s.Logger.Info("Uploading image to local storage", "filename", filename)
+
+	key := newObjectKey(filename)
+
+	dst, err := os.OpenFile(filepath.Join(s.Dir, filepath.FromSlash(key)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		s.Logger.Error("Failed to create local file", "key", key, "error", err)
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, file); err != nil {
+		s.Logger.Error("Failed to write local file", "key", key, "error", err)
+		return "", err
+	}
+
+	signedURL := s.SignedURL(key, 0)
+	s.Logger.Info("Image uploaded successfully", "url", signedURL)
+	return signedURL, nil
+}
+
+func (s *LocalStorageService) DeleteImage(ctx context.Context, imageURL string) error {
+	key, err := s.keyFromURL(imageURL)
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Info("Deleting image from local storage", "key", key)
+	err = os.Remove(filepath.Join(s.Dir, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		s.Logger.Error("Failed to delete local file", "key", key, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorageService) ListImages(ctx context.Context) ([]StoredImage, error) {
+	var images []StoredImage
+
+	root := filepath.Join(s.Dir, storageFolder)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return ctx.Err()
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.Dir, p)
+		if err != nil {
+			return err
+		}
+
+		images = append(images, StoredImage{
+			URL:       s.SignedURL(filepath.ToSlash(rel), 0),
+			CreatedAt: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (s *LocalStorageService) keyFromURL(imageURL string) (string, error) {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return "", err
+	}
+
+	key := path.Clean(strings.TrimPrefix(u.Path, LocalStorageRoute+"/"))
+	if !strings.HasPrefix(key, storageFolder+"/") {
+		return "", errors.New("not a local storage URL: " + imageURL)
+	}
+	return key, nil
+}
+
+// SignedURL returns the public URL for key. A zero ttl produces a permanent
+// link, which is what gets persisted alongside products; anyone holding it can
+// fetch the image for as long as the file exists.
+func (s *LocalStorageService) SignedURL(key string, ttl time.Duration) string {
+	q := url.Values{}
+	var expires string
+	if ttl > 0 {
+		expires = strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+		q.Set("expires", expires)
+	}
+	q.Set("sig", s.sign(key, expires))
+
+	return fmt.Sprintf("%s%s/%s?%s", s.BaseURL, LocalStorageRoute, key, q.Encode())
+}
+
+func (s *LocalStorageService) VerifySignature(key, expires, sig string) bool {
+	if expires != "" {
+		exp, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > exp {
+			return false
+		}
+	}
+
+	expected := s.sign(key, expires)
+	return hmac.Equal([]byte(expected), []byte(sig))
+}
+
+func (s *LocalStorageService) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.SigningKey)
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	DefaultMessagePageSize = 30
+	MaxMessagePageSize     = 100
+)
+
+var ReportReasons = []string{"spam", "harassment", "scam", "other"}
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrMessageNotFound      = errors.New("message not found")
+	ErrCannotMessageSelf    = errors.New("you cannot message yourself")
+	ErrUserBlocked          = errors.New("you can't message this user")
+	ErrCannotBlockSelf      = errors.New("you cannot block yourself")
+	ErrBlockNotFound        = errors.New("user is not blocked")
+	ErrAlreadyReported      = errors.New("you have already reported this message")
+)
+
+type MessagePage struct {
+	Messages []db.Message `json:"messages"`
+	// NextBefore is passed back as ?before= to get the next, older page. It
+	// is nil on the last page.
+	NextBefore *int64 `json:"next_before"`
+}
+
+type MessageService struct {
+	Store    data.MessageStore
+	Products data.ProductStore
+	Orders   data.OrderStore
+	Events   *EventService
+	Pool     *pgxpool.Pool
+	Logger   *slog.Logger
+}
+
+func NewMessageService(store data.MessageStore, products data.ProductStore, orders data.OrderStore, events *EventService, pool *pgxpool.Pool, logger *slog.Logger) *MessageService {
+	return &MessageService{
+		Store:    store,
+		Products: products,
+		Orders:   orders,
+		Events:   events,
+		Pool:     pool,
+		Logger:   logger,
+	}
+}
+
+// StartConversation opens a conversation about either a product or a
+// sub-order, whichever is given.
+func (s *MessageService) StartConversation(ctx context.Context, userID int64, productID, subOrderID *int64) (db.Conversation, error) {
+	if productID != nil {
+		return s.StartProductConversation(ctx, userID, *productID)
+	}
+	return s.StartSubOrderConversation(ctx, userID, *subOrderID)
+}
+
+// StartProductConversation opens, or returns the existing, conversation
+// between a prospective buyer and the seller of a listing.
+func (s *MessageService) StartProductConversation(ctx context.Context, buyerID, productID int64) (db.Conversation, error) {
+	product, err := s.Products.GetProductByID(ctx, productID)
+	if err != nil {
+		return db.Conversation{}, err
+	}
+	if product.SellerID == buyerID {
+		return db.Conversation{}, ErrCannotMessageSelf
+	}
+
+	return s.Store.UpsertProductConversation(ctx, productID, buyerID, product.SellerID)
+}
+
+// StartSubOrderConversation opens, or returns the existing, conversation
+// about a sub-order. Either the buyer or the seller may start it.
+func (s *MessageService) StartSubOrderConversation(ctx context.Context, userID, subOrderID int64) (db.Conversation, error) {
+	so, err := s.Orders.GetSubOrder(ctx, subOrderID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return db.Conversation{}, ErrOrderNotFound
+		}
+		return db.Conversation{}, err
+	}
+	if so.BuyerID != userID && so.SellerID != userID {
+		return db.Conversation{}, ErrNotOrderParticipant
+	}
+
+	return s.Store.UpsertSubOrderConversation(ctx, subOrderID, so.BuyerID, so.SellerID)
+}
+
+func (s *MessageService) ListConversations(ctx context.Context, userID int64) ([]db.ListConversationsForUserRow, error) {
+	return s.Store.ListConversationsForUser(ctx, userID)
+}
+
+func (s *MessageService) UnreadCount(ctx context.Context, userID int64) (int64, error) {
+	return s.Store.CountUnreadMessages(ctx, userID)
+}
+
+func (s *MessageService) GetMessages(ctx context.Context, userID, conversationID int64, beforeID *int64, limit int32) (MessagePage, error) {
+	if _, err := s.conversationFor(ctx, userID, conversationID); err != nil {
+		return MessagePage{}, err
+	}
+
+	messages, err := s.Store.ListMessages(ctx, conversationID, beforeID, limit)
+	if err != nil {
+		return MessagePage{}, err
+	}
+
+	if messages == nil {
+		messages = []db.Message{}
+	}
+	page := MessagePage{Messages: messages}
+	if len(messages) == int(limit) {
+		page.NextBefore = &messages[len(messages)-1].ID
+	}
+	return page, nil
+}
+
+func (s *MessageService) SendMessage(ctx context.Context, senderID, conversationID int64, body string) (db.Message, error) {
+	logger := s.Logger.With("conversation_id", conversationID, "sender_id", senderID)
+
+	conversation, err := s.conversationFor(ctx, senderID, conversationID)
+	if err != nil {
+		return db.Message{}, err
+	}
+	recipientID := conversation.SellerID
+	if recipientID == senderID {
+		recipientID = conversation.BuyerID
+	}
+
+	blocked, err := s.Store.IsBlockedBetween(ctx, senderID, recipientID)
+	if err != nil {
+		return db.Message{}, err
+	}
+	if blocked {
+		return db.Message{}, ErrUserBlocked
+	}
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return db.Message{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	message, err := txStore.CreateMessage(ctx, conversationID, senderID, body)
+	if err != nil {
+		return db.Message{}, err
+	}
+	if err := txStore.TouchConversation(ctx, conversationID, senderID, message.ID); err != nil {
+		return db.Message{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return db.Message{}, err
+	}
+
+	s.Events.Publish(ctx, recipientID, EventMessageCreated, message)
+	s.Events.Publish(ctx, senderID, EventMessageCreated, message)
+
+	return message, nil
+}
+
+func (s *MessageService) MarkRead(ctx context.Context, userID, conversationID int64) error {
+	if _, err := s.conversationFor(ctx, userID, conversationID); err != nil {
+		return err
+	}
+	return s.Store.MarkConversationRead(ctx, conversationID, userID)
+}
+
+func (s *MessageService) BlockUser(ctx context.Context, blockerID, blockedID int64) error {
+	if blockerID == blockedID {
+		return ErrCannotBlockSelf
+	}
+	if err := s.Store.BlockUser(ctx, blockerID, blockedID); err != nil {
+		return err
+	}
+	s.Logger.Info("User blocked", "blocker_id", blockerID, "blocked_id", blockedID)
+	return nil
+}
+
+func (s *MessageService) UnblockUser(ctx context.Context, blockerID, blockedID int64) error {
+	err := s.Store.UnblockUser(ctx, blockerID, blockedID)
+	if errors.Is(err, data.ErrRecordNotFound) {
+		return ErrBlockNotFound
+	}
+	return err
+}
+
+func (s *MessageService) ListBlockedUsers(ctx context.Context, blockerID int64) ([]db.ListBlockedUsersRow, error) {
+	return s.Store.ListBlockedUsers(ctx, blockerID)
+}
+
+// ReportMessage flags a message the reporter received for the admins to
+// review. People can't report their own messages.
+func (s *MessageService) ReportMessage(ctx context.Context, reporterID, messageID int64, reason, details string) (db.MessageReport, error) {
+	message, err := s.Store.GetMessage(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return db.MessageReport{}, ErrMessageNotFound
+		}
+		return db.MessageReport{}, err
+	}
+	if message.SenderID == reporterID {
+		return db.MessageReport{}, ErrMessageNotFound
+	}
+	if _, err := s.conversationFor(ctx, reporterID, message.ConversationID); err != nil {
+		if errors.Is(err, ErrConversationNotFound) {
+			return db.MessageReport{}, ErrMessageNotFound
+		}
+		return db.MessageReport{}, err
+	}
+
+	report, err := s.Store.CreateMessageReport(ctx, db.CreateMessageReportParams{
+		MessageID:  messageID,
+		ReporterID: reporterID,
+		Reason:     reason,
+		Details:    details,
+	})
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateReport) {
+			return db.MessageReport{}, ErrAlreadyReported
+		}
+		return db.MessageReport{}, err
+	}
+
+	s.Logger.Warn("Message reported", "message_id", messageID, "reporter_id", reporterID, "reason", reason)
+	return report, nil
+}
+
+func (s *MessageService) ListReports(ctx context.Context) ([]db.ListMessageReportsRow, error) {
+	return s.Store.ListMessageReports(ctx)
+}
+
+// conversationFor loads a conversation the user is part of. Other people's
+// conversations are reported as not found.
+func (s *MessageService) conversationFor(ctx context.Context, userID, conversationID int64) (db.Conversation, error) {
+	conversation, err := s.Store.GetConversation(ctx, conversationID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return db.Conversation{}, ErrConversationNotFound
+		}
+		return db.Conversation{}, err
+	}
+	if conversation.BuyerID != userID && conversation.SellerID != userID {
+		return db.Conversation{}, ErrConversationNotFound
+	}
+	return conversation, nil
+}
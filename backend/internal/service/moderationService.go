@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/cache"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"ecommerce/internal/validator"
+	"ecommerce/internal/worker"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	ModerationActive  = "active"
+	ModerationHeld    = "held"
+	ModerationRemoved = "removed"
+
+	reportStatusDismissed = "dismissed"
+	reportStatusActioned  = "actioned"
+)
+
+var ListingReportReasons = []string{"prohibited", "counterfeit", "misleading", "offensive", "spam", "scam", "other"}
+
+var (
+	ErrListingNotFound          = errors.New("listing not found")
+	ErrCannotReportOwnListing   = errors.New("you cannot report your own listing")
+	ErrAlreadyReportedListing   = errors.New("you have already reported this listing")
+	ErrBannedTermNotFound       = errors.New("banned term not found")
+	ErrBannedTermExists         = errors.New("banned term already exists")
+	ErrInvalidBannedTermPattern = errors.New("invalid regular expression")
+)
+
+type ListingModerated struct {
+	ProductID int64  `json:"product_id"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Mail jobs are JSON encoded on the queue, so the template sees these by
+// their json names.
+type ListingModerationEmailData struct {
+	Name        string `json:"name"`
+	ProductName string `json:"product_name"`
+	Status      string `json:"status"`
+	Reason      string `json:"reason"`
+}
+
+type ModerationQueueItem struct {
+	ProductID        int64      `json:"product_id"`
+	SellerID         int64      `json:"seller_id"`
+	Name             string     `json:"name"`
+	ModerationStatus string     `json:"moderation_status"`
+	ModerationReason *string    `json:"moderation_reason"`
+	OpenReports      int64      `json:"open_reports"`
+	Reasons          []string   `json:"reasons"`
+	LastReportedAt   *time.Time `json:"last_reported_at"`
+}
+
+type ModerationService struct {
+	Store         data.ModerationStore
+	Products      *ProductService
+	Users         data.UserStore
+	Events        *EventService
+	Cache         *cache.ValkeyCache
+	Pool          *pgxpool.Pool
+	HoldThreshold int
+	Logger        *slog.Logger
+}
+
+func NewModerationService(
+	store data.ModerationStore,
+	products *ProductService,
+	users data.UserStore,
+	events *EventService,
+	cache *cache.ValkeyCache,
+	pool *pgxpool.Pool,
+	holdThreshold int,
+	logger *slog.Logger,
+) *ModerationService {
+	return &ModerationService{
+		Store:         store,
+		Products:      products,
+		Users:         users,
+		Events:        events,
+		Cache:         cache,
+		Pool:          pool,
+		HoldThreshold: holdThreshold,
+		Logger:        logger,
+	}
+}
+
+// CheckListingText rejects a listing whose name or description matches a
+// banned term. Plain terms match whole words, ignoring case; regex terms are
+// matched as written, also ignoring case.
+func (s *ModerationService) CheckListingText(ctx context.Context, name, description string) error {
+	terms, err := s.Store.ListBannedTerms(ctx)
+	if err != nil {
+		s.Logger.Error("Failed to load banned terms", "error", err)
+		return err
+	}
+
+	v := validator.New()
+	for _, term := range terms {
+		rx, err := compileBannedTerm(term.Pattern, term.IsRegex)
+		if err != nil {
+			s.Logger.Error("Skipping invalid banned term", "term_id", term.ID, "error", err)
+			continue
+		}
+		v.Check(!rx.MatchString(name), "name", "contains language that isn't allowed")
+		v.Check(!rx.MatchString(description), "description", "contains language that isn't allowed")
+	}
+
+	if v.Valid() {
+		return nil
+	}
+	s.Logger.Warn("Listing rejected by banned term filter", "name", name)
+	return v
+}
+
+// ReportListing records a user's report against a live listing. Once a
+// listing has HoldThreshold open reports it is hidden until an admin reviews
+// it.
+func (s *ModerationService) ReportListing(ctx context.Context, reporterID, productID int64, reason, details string) (db.ListingReport, error) {
+	logger := s.Logger.With("product_id", productID, "reporter_id", reporterID)
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return db.ListingReport{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	product, err := s.Products.Store.WithTx(tx).GetProductForUpdate(ctx, productID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return db.ListingReport{}, ErrListingNotFound
+		}
+		return db.ListingReport{}, err
+	}
+	if !product.IsActive {
+		return db.ListingReport{}, ErrListingNotFound
+	}
+	if product.SellerID == reporterID {
+		return db.ListingReport{}, ErrCannotReportOwnListing
+	}
+
+	report, err := txStore.CreateListingReport(ctx, db.CreateListingReportParams{
+		ProductID:  productID,
+		ReporterID: reporterID,
+		Reason:     reason,
+		Details:    details,
+	})
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateListingReport) {
+			return db.ListingReport{}, ErrAlreadyReportedListing
+		}
+		logger.Error("Failed to create listing report", "error", err)
+		return db.ListingReport{}, err
+	}
+
+	open, err := txStore.CountOpenListingReports(ctx, productID)
+	if err != nil {
+		logger.Error("Failed to count listing reports", "error", err)
+		return db.ListingReport{}, err
+	}
+
+	var held *db.Product
+	if s.HoldThreshold > 0 && open >= int64(s.HoldThreshold) && product.ModerationStatus == ModerationActive {
+		after, err := txStore.SetProductModeration(ctx, db.SetProductModerationParams{
+			ID:               productID,
+			ModerationStatus: ModerationHeld,
+			ModerationReason: data.NewPGText(fmt.Sprintf("held automatically after %d reports", open)),
+			IsActive:         false,
+		})
+		if err != nil {
+			logger.Error("Failed to hold listing", "error", err)
+			return db.ListingReport{}, err
+		}
+		held = &after
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return db.ListingReport{}, err
+	}
+
+	logger.Warn("Listing reported", "reason", reason, "open_reports", open)
+	if held != nil {
+		logger.Warn("Listing held for review")
+		s.Products.productChanged(product, *held)
+		s.notifySeller(ctx, *held)
+	}
+
+	return report, nil
+}
+
+func (s *ModerationService) Queue(ctx context.Context) ([]ModerationQueueItem, error) {
+	rows, err := s.Store.ListModerationQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ModerationQueueItem, 0, len(rows))
+	for _, r := range rows {
+		item := ModerationQueueItem{
+			ProductID:        r.ID,
+			SellerID:         r.SellerID,
+			Name:             r.Name,
+			ModerationStatus: r.ModerationStatus,
+			OpenReports:      r.OpenReports,
+			Reasons:          []string{},
+		}
+		if r.ModerationReason.Valid {
+			item.ModerationReason = &r.ModerationReason.String
+		}
+		if r.Reasons != "" {
+			item.Reasons = strings.Split(r.Reasons, ",")
+		}
+		if r.LastReportedAt.Valid {
+			item.LastReportedAt = &r.LastReportedAt.Time
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *ModerationService) ListReports(ctx context.Context, productID int64) ([]db.ListListingReportsRow, error) {
+	return s.Store.ListListingReports(ctx, productID)
+}
+
+// RemoveListing takes a listing down and closes its open reports as
+// actioned. The seller can't relist it.
+func (s *ModerationService) RemoveListing(ctx context.Context, adminID, productID int64, reason string) (db.Product, error) {
+	return s.moderate(ctx, adminID, productID, ModerationRemoved, reason)
+}
+
+// RestoreListing puts a held or removed listing back on sale and dismisses
+// its open reports. For a listing that was never hidden it only dismisses
+// the reports.
+func (s *ModerationService) RestoreListing(ctx context.Context, adminID, productID int64) (db.Product, error) {
+	return s.moderate(ctx, adminID, productID, ModerationActive, "")
+}
+
+func (s *ModerationService) moderate(ctx context.Context, adminID, productID int64, status, reason string) (db.Product, error) {
+	logger := s.Logger.With("product_id", productID, "admin_id", adminID, "status", status)
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return db.Product{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	before, err := s.Products.Store.WithTx(tx).GetProductForUpdate(ctx, productID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return db.Product{}, ErrListingNotFound
+		}
+		return db.Product{}, err
+	}
+
+	after := before
+	if before.ModerationStatus != status {
+		arg := db.SetProductModerationParams{
+			ID:               productID,
+			ModerationStatus: status,
+			IsActive:         status == ModerationActive,
+		}
+		if reason != "" {
+			arg.ModerationReason = data.NewPGText(reason)
+		}
+		after, err = txStore.SetProductModeration(ctx, arg)
+		if err != nil {
+			logger.Error("Failed to update listing moderation", "error", err)
+			return db.Product{}, err
+		}
+	}
+
+	reportStatus := reportStatusDismissed
+	if status == ModerationRemoved {
+		reportStatus = reportStatusActioned
+	}
+	if err := txStore.ResolveListingReports(ctx, productID, reportStatus, adminID); err != nil {
+		logger.Error("Failed to resolve listing reports", "error", err)
+		return db.Product{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return db.Product{}, err
+	}
+
+	logger.Info("Listing moderated", "previous_status", before.ModerationStatus)
+	if before.ModerationStatus != status {
+		s.Products.productChanged(before, after)
+		s.notifySeller(ctx, after)
+	}
+
+	return after, nil
+}
+
+func (s *ModerationService) ListBannedTerms(ctx context.Context) ([]db.BannedTerm, error) {
+	return s.Store.ListBannedTerms(ctx)
+}
+
+func (s *ModerationService) AddBannedTerm(ctx context.Context, adminID int64, pattern string, isRegex bool) (db.BannedTerm, error) {
+	if _, err := compileBannedTerm(pattern, isRegex); err != nil {
+		return db.BannedTerm{}, ErrInvalidBannedTermPattern
+	}
+
+	term, err := s.Store.CreateBannedTerm(ctx, pattern, isRegex, adminID)
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateBannedTerm) {
+			return db.BannedTerm{}, ErrBannedTermExists
+		}
+		return db.BannedTerm{}, err
+	}
+
+	s.Logger.Info("Banned term added", "term_id", term.ID, "admin_id", adminID, "is_regex", isRegex)
+	return term, nil
+}
+
+func (s *ModerationService) DeleteBannedTerm(ctx context.Context, id int64) error {
+	err := s.Store.DeleteBannedTerm(ctx, id)
+	if errors.Is(err, data.ErrRecordNotFound) {
+		return ErrBannedTermNotFound
+	}
+	return err
+}
+
+// notifySeller tells the seller their listing's moderation status changed,
+// both in the app and by email. Like other notifications it is best effort.
+func (s *ModerationService) notifySeller(ctx context.Context, product db.Product) {
+	logger := s.Logger.With("product_id", product.ID, "seller_id", product.SellerID)
+
+	s.Events.Publish(ctx, product.SellerID, EventListingModerated, ListingModerated{
+		ProductID: product.ID,
+		Status:    product.ModerationStatus,
+		Reason:    product.ModerationReason.String,
+	})
+
+	seller, err := s.Users.GetUserByID(ctx, int(product.SellerID))
+	if err != nil {
+		logger.Error("Failed to load seller for moderation email", "error", err)
+		return
+	}
+
+	jobJSON, err := json.Marshal(worker.MailJob{
+		Recipient:    seller.Email,
+		TemplateFile: "listing_moderation.tmpl",
+		TemplateData: ListingModerationEmailData{
+			Name:        seller.Name,
+			ProductName: product.Name,
+			Status:      product.ModerationStatus,
+			Reason:      product.ModerationReason.String,
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to encode moderation email", "error", err)
+		return
+	}
+	if err := s.Cache.AddEmailsToQueue(ctx, []string{string(jobJSON)}); err != nil {
+		logger.Error("Failed to queue moderation email", "error", err)
+	}
+}
+
+func compileBannedTerm(pattern string, isRegex bool) (*regexp.Regexp, error) {
+	if !isRegex {
+		pattern = `\b` + regexp.QuoteMeta(pattern) + `\b`
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
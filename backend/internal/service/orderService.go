@@ -1,25 +1,53 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"ecommerce/internal/data"
 	db "ecommerce/internal/data/gen"
 	"errors"
 	"log/slog"
+	"slices"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	SubOrderPending   = "pending"
+	SubOrderCompleted = "completed"
+	SubOrderCancelled = "cancelled"
+
+	// EscrowAccount holds the buyer's money for each sub-order until it is
+	// handed over or cancelled.
+	EscrowAccount = "escrow"
+)
+
 var (
-	ErrCartEmpty        = errors.New("cart is empty")
-	ErrCannotBuyOwnItem = errors.New("user cannot buy their own item")
+	ErrCartEmpty           = errors.New("cart is empty")
+	ErrCannotBuyOwnItem    = errors.New("user cannot buy their own item")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrSubOrderNotPending  = errors.New("sub-order is no longer pending")
+	ErrNotOrderParticipant = errors.New("you are not part of this order")
 )
 
+type SubOrderDetails struct {
+	SubOrder db.SubOrder    `json:"sub_order"`
+	Items    []db.OrderItem `json:"items"`
+}
+
+type OrderDetails struct {
+	Order     db.Order          `json:"order"`
+	SubOrders []SubOrderDetails `json:"sub_orders"`
+}
+
 type OrderService struct {
 	OrderStore    data.OrderStore
 	ProductStore  data.ProductStore
 	WalletService *WalletService
 	CartService   *CartService
+	CouponService *CouponService
+	Commissions   *CommissionService
 	Pool          *pgxpool.Pool
 	Logger        *slog.Logger
 }
@@ -29,6 +57,8 @@ func NewOrderService(
 	ps data.ProductStore,
 	ws *WalletService,
 	cs *CartService,
+	coupons *CouponService,
+	commissions *CommissionService,
 	pool *pgxpool.Pool,
 	logger *slog.Logger,
 ) *OrderService {
@@ -37,39 +67,74 @@ func NewOrderService(
 		ProductStore:  ps,
 		WalletService: ws,
 		CartService:   cs,
+		CouponService: coupons,
+		Commissions:   commissions,
 		Pool:          pool,
 		Logger:        logger,
 	}
 }
 
-func (s *OrderService) CreateOrderFromCart(ctx context.Context, buyerID int64) (db.Order, error) {
+// CreateOrderFromCart checks out the buyer's cart as one order with a
+// sub-order per seller. The buyer is debited once and the money is held in
+// escrow until each sub-order is handed over or cancelled. When a coupon is
+// given the buyer pays the discounted total and the promo wallet pays the
+// rest into escrow, so sellers are always paid on full price.
+func (s *OrderService) CreateOrderFromCart(ctx context.Context, buyerID int64, couponCode string) (db.Order, error) {
 	logger := s.Logger.With("buyer_id", buyerID)
 	logger.Info("Attempting to create order from cart")
 
-	cartItems, err := s.CartService.GetCart(ctx, buyerID)
+	cart, err := s.CartService.GetCart(ctx, UserCart(buyerID))
 	if err != nil {
 		logger.Error("Failed to get cart", "error", err)
 		return db.Order{}, err
 	}
+	if cart.NeedsConfirm {
+		logger.Warn("Cart changed since last viewed, refusing to check out")
+		return db.Order{}, ErrCartChanged
+	}
+
+	cartItems := make([]CartItem, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		if item.Available {
+			cartItems = append(cartItems, item)
+		}
+	}
 	if len(cartItems) == 0 {
 		return db.Order{}, ErrCartEmpty
 	}
 
-	sellerPayments := make(map[int64]int64)
-	var grandTotal int64 = 0
-
 	for _, item := range cartItems {
 		if item.Stock < int32(item.Quantity) {
 			logger.Warn("Insufficient stock for product", "product_id", item.ProductID, "stock", item.Stock, "wanted", item.Quantity)
 			return db.Order{}, errors.New("insufficient stock for " + item.Name)
 		}
-		itemTotal := int64(item.Price) * int64(item.Quantity)
-		sellerPayments[item.SellerID] += itemTotal
-		grandTotal += itemTotal
 	}
-	grandTotal *= 100
 
-	logger.Info("Calculated payment totals", "grand_total", grandTotal, "seller_count", len(sellerPayments))
+	fees, err := s.Commissions.Fees(ctx, cartItems)
+	if err != nil {
+		logger.Error("Failed to calculate commission", "error", err)
+		return db.Order{}, err
+	}
+
+	// Sellers in the order they first appear, so sub-orders are created
+	// deterministically.
+	var sellers []int64
+	subOrders := make(map[int64]*db.CreateSubOrderParams)
+	var grandTotal int64
+	for _, fee := range fees {
+		so, ok := subOrders[fee.SellerID]
+		if !ok {
+			so = &db.CreateSubOrderParams{SellerID: fee.SellerID}
+			subOrders[fee.SellerID] = so
+			sellers = append(sellers, fee.SellerID)
+		}
+		so.Gross += fee.Gross
+		so.Commission += fee.Commission
+		so.Payout += fee.Payout
+		grandTotal += fee.Gross
+	}
+
+	logger.Info("Calculated payment totals", "grand_total", grandTotal, "seller_count", len(sellers))
 
 	tx, err := s.Pool.Begin(ctx)
 	if err != nil {
@@ -80,33 +145,71 @@ func (s *OrderService) CreateOrderFromCart(ctx context.Context, buyerID int64) (
 
 	txWalletStore := data.NewWalletStore(db.New(tx))
 	txOrderStore := data.NewOrderStore(db.New(tx))
+	txCouponStore := s.CouponService.Store.WithTx(tx)
+
+	var coupon db.Coupon
+	var discount int64
+	if couponCode != "" {
+		var quote CouponQuote
+		coupon, quote, err = s.CouponService.Apply(ctx, txCouponStore, couponCode, buyerID, cartItems)
+		if err != nil {
+			logger.Warn("Coupon rejected", "code", couponCode, "error", err)
+			return db.Order{}, err
+		}
+		discount = quote.Discount
+		allocateDiscount(discount, quote, sellers, subOrders)
+		logger.Info("Coupon applied", "coupon_id", coupon.ID, "discount", discount)
+	}
 
 	buyerID32 := int32(buyerID)
-	_, err = s.WalletService.Debit(ctx, buyerID32, grandTotal)
-	if err != nil {
-		if errors.Is(err, ErrInsufficientFunds) {
-			logger.Warn("Buyer has insufficient funds")
-			return db.Order{}, ErrInsufficientFunds
+	if charge := grandTotal - discount; charge > 0 {
+		if _, err := s.WalletService.debitWalletInTx(ctx, txWalletStore, buyerID32, charge, "debit", nil); err != nil {
+			if errors.Is(err, ErrInsufficientFunds) {
+				logger.Warn("Buyer has insufficient funds")
+				return db.Order{}, ErrInsufficientFunds
+			}
+			logger.Error("Failed to debit buyer", "error", err)
+			return db.Order{}, err
 		}
-		logger.Error("Failed to debit buyer", "error", err)
-		return db.Order{}, err
+		logger.Info("Buyer debited successfully")
 	}
-	logger.Info("Buyer debited successfully")
 
-	for sellerID, amount := range sellerPayments {
-		sellerID32 := int32(sellerID)
-		_, err = s.WalletService.creditWalletInternal(ctx, txWalletStore, sellerID32, amount, "order_payout", "completed", &buyerID32)
+	if discount > 0 {
+		promoID, err := txWalletStore.GetPlatformAccount(ctx, PromoAccount)
 		if err != nil {
-			logger.Error("Failed to credit seller", "seller_id", sellerID, "error", err)
-			return db.Order{}, errors.New("failed to pay seller")
+			logger.Error("Failed to look up promo account", "error", err)
+			return db.Order{}, err
 		}
+		if _, err := s.WalletService.debitWalletInTx(ctx, txWalletStore, promoID, discount, "promo_discount", &buyerID32); err != nil {
+			if errors.Is(err, ErrInsufficientFunds) {
+				logger.Error("Promo account cannot cover discount", "discount", discount)
+				return db.Order{}, ErrPromoBudgetExhausted
+			}
+			logger.Error("Failed to debit promo account", "error", err)
+			return db.Order{}, err
+		}
+		logger.Info("Promo account debited", "discount", discount)
 	}
-	logger.Info("Sellers credited successfully")
+
+	escrowID, err := txWalletStore.GetPlatformAccount(ctx, EscrowAccount)
+	if err != nil {
+		logger.Error("Failed to look up escrow account", "error", err)
+		return db.Order{}, err
+	}
+	if _, err := s.WalletService.creditWalletInternal(ctx, txWalletStore, escrowID, grandTotal, "escrow_hold", "completed", &buyerID32); err != nil {
+		logger.Error("Failed to move payment into escrow", "error", err)
+		return db.Order{}, err
+	}
+	logger.Info("Payment held in escrow", "amount", grandTotal)
 
 	orderParams := db.CreateOrderParams{
-		UserID:      buyerID,
-		TotalAmount: grandTotal,
-		Status:      "completed",
+		UserID:         buyerID,
+		TotalAmount:    grandTotal - discount,
+		Status:         SubOrderPending,
+		DiscountAmount: discount,
+	}
+	if discount > 0 {
+		orderParams.CouponID = data.NewPGInt64(coupon.ID)
 	}
 	order, err := txOrderStore.CreateOrder(ctx, orderParams)
 	if err != nil {
@@ -115,13 +218,37 @@ func (s *OrderService) CreateOrderFromCart(ctx context.Context, buyerID int64) (
 	}
 	logger.Info("Order record created", "order_id", order.ID)
 
-	for _, item := range cartItems {
+	if discount > 0 {
+		if err := s.CouponService.Redeem(ctx, txCouponStore, coupon.ID, buyerID, order.ID, discount); err != nil {
+			logger.Error("Failed to record coupon redemption", "coupon_id", coupon.ID, "error", err)
+			return db.Order{}, err
+		}
+	}
+
+	subOrderIDs := make(map[int64]int64, len(sellers))
+	for _, sellerID := range sellers {
+		params := subOrders[sellerID]
+		params.OrderID = order.ID
+		so, err := txOrderStore.CreateSubOrder(ctx, *params)
+		if err != nil {
+			logger.Error("Failed to create sub-order", "seller_id", sellerID, "error", err)
+			return db.Order{}, err
+		}
+		subOrderIDs[sellerID] = so.ID
+	}
+	logger.Info("Sub-orders created", "count", len(sellers))
+
+	for i, item := range cartItems {
 		itemParams := db.CreateOrderItemParams{
-			OrderID:         order.ID,
-			ProductID:       item.ProductID,
-			SellerID:        item.SellerID,
-			Quantity:        int32(item.Quantity),
-			PriceAtPurchase: item.Price,
+			OrderID:          order.ID,
+			SubOrderID:       data.NewPGInt64(subOrderIDs[item.SellerID]),
+			ProductID:        item.ProductID,
+			SellerID:         item.SellerID,
+			Quantity:         int32(item.Quantity),
+			PriceAtPurchase:  item.Price,
+			CommissionBps:    fees[i].Bps,
+			CommissionAmount: fees[i].Commission,
+			SellerPayout:     fees[i].Payout,
 		}
 		if err := txOrderStore.CreateOrderItem(ctx, itemParams); err != nil {
 			logger.Error("Failed to create order item record", "product_id", item.ProductID, "error", err)
@@ -136,11 +263,254 @@ func (s *OrderService) CreateOrderFromCart(ctx context.Context, buyerID int64) (
 		return db.Order{}, err
 	}
 
-	if err := s.CartService.ClearCart(ctx, buyerID); err != nil {
+	if err := s.CartService.ClearCart(ctx, UserCart(buyerID)); err != nil {
 
 		logger.Error("Failed to clear cart after successful order", "error", err)
 	}
 
+	s.WalletService.Events.PublishBalance(ctx, buyerID)
+	for _, sellerID := range sellers {
+		s.WalletService.Events.Publish(ctx, sellerID, EventOrderStatus, OrderStatusChanged{
+			OrderID:    order.ID,
+			SubOrderID: subOrderIDs[sellerID],
+			Status:     SubOrderPending,
+		})
+	}
+
 	logger.Info("Order processing complete", "order_id", order.ID)
 	return order, nil
 }
+
+// allocateDiscount shares a coupon discount across sub-orders in proportion
+// to what the coupon covered from each seller. Shares are rounded down and
+// the paise left over go one each to the largest remainders, earlier sellers
+// first on ties, so the shares add up and none is more than the seller's
+// eligible amount.
+func allocateDiscount(discount int64, quote CouponQuote, sellers []int64, subOrders map[int64]*db.CreateSubOrderParams) {
+	type share struct {
+		sellerID  int64
+		remainder int64
+	}
+
+	var shares []share
+	remaining := discount
+	for _, sellerID := range sellers {
+		eligible := quote.EligibleBySeller[sellerID]
+		if eligible <= 0 {
+			continue
+		}
+		amount := discount * eligible / quote.Eligible
+		subOrders[sellerID].Discount = amount
+		remaining -= amount
+		shares = append(shares, share{sellerID: sellerID, remainder: discount * eligible % quote.Eligible})
+	}
+
+	slices.SortStableFunc(shares, func(a, b share) int { return cmp.Compare(b.remainder, a.remainder) })
+	for i := range remaining {
+		subOrders[shares[i].sellerID].Discount++
+	}
+}
+
+func (s *OrderService) GetOrdersForBuyer(ctx context.Context, buyerID int64) ([]db.Order, error) {
+	return s.OrderStore.GetOrdersByUserID(ctx, buyerID)
+}
+
+// GetOrderDetails returns an order with its sub-orders and items. Only the
+// buyer may see the whole order.
+func (s *OrderService) GetOrderDetails(ctx context.Context, buyerID, orderID int64) (OrderDetails, error) {
+	order, err := s.OrderStore.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return OrderDetails{}, ErrOrderNotFound
+		}
+		return OrderDetails{}, err
+	}
+	if order.UserID != buyerID {
+		return OrderDetails{}, ErrOrderNotFound
+	}
+
+	subOrders, err := s.OrderStore.GetSubOrdersByOrderID(ctx, orderID)
+	if err != nil {
+		return OrderDetails{}, err
+	}
+	items, err := s.OrderStore.GetOrderItemsByOrderID(ctx, orderID)
+	if err != nil {
+		return OrderDetails{}, err
+	}
+
+	details := OrderDetails{Order: order, SubOrders: make([]SubOrderDetails, 0, len(subOrders))}
+	for _, so := range subOrders {
+		d := SubOrderDetails{SubOrder: so, Items: []db.OrderItem{}}
+		for _, item := range items {
+			if item.SubOrderID.Int64 == so.ID {
+				d.Items = append(d.Items, item)
+			}
+		}
+		details.SubOrders = append(details.SubOrders, d)
+	}
+
+	return details, nil
+}
+
+// ConfirmSubOrder is called by the buyer once they have the items. It
+// releases the sub-order's escrow to the seller and the platform.
+func (s *OrderService) ConfirmSubOrder(ctx context.Context, buyerID, subOrderID int64) (db.SubOrder, error) {
+	return s.settleSubOrder(ctx, subOrderID, func(so db.GetSubOrderForUpdateRow) error {
+		if so.BuyerID != buyerID {
+			return ErrNotOrderParticipant
+		}
+		return nil
+	}, s.releaseSubOrder)
+}
+
+// CancelSubOrder lets either the buyer or the seller call off a pending
+// sub-order. The buyer gets back what they paid for it and any coupon share
+// goes back to the promo wallet; the rest of the order is unaffected.
+func (s *OrderService) CancelSubOrder(ctx context.Context, userID, subOrderID int64) (db.SubOrder, error) {
+	return s.settleSubOrder(ctx, subOrderID, func(so db.GetSubOrderForUpdateRow) error {
+		if so.BuyerID != userID && so.SellerID != userID {
+			return ErrNotOrderParticipant
+		}
+		return nil
+	}, func(ctx context.Context, tx pgx.Tx, so db.GetSubOrderForUpdateRow) (db.SubOrder, error) {
+		return s.refundSubOrder(ctx, tx, so, userID)
+	})
+}
+
+// settleFunc moves a locked sub-order's escrow inside the caller's
+// transaction and returns the sub-order in its final state.
+type settleFunc func(ctx context.Context, tx pgx.Tx, so db.GetSubOrderForUpdateRow) (db.SubOrder, error)
+
+// settleSubOrder locks a pending sub-order, checks the caller may act on
+// it, moves its escrow with settle and refreshes the parent order's status,
+// all in one transaction.
+func (s *OrderService) settleSubOrder(ctx context.Context, subOrderID int64, authorize func(db.GetSubOrderForUpdateRow) error, settle settleFunc) (db.SubOrder, error) {
+	logger := s.Logger.With("sub_order_id", subOrderID)
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return db.SubOrder{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	txOrderStore := data.NewOrderStore(db.New(tx))
+
+	so, err := txOrderStore.GetSubOrderForUpdate(ctx, subOrderID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return db.SubOrder{}, ErrOrderNotFound
+		}
+		return db.SubOrder{}, err
+	}
+	if err := authorize(so); err != nil {
+		return db.SubOrder{}, err
+	}
+	if so.Status != SubOrderPending {
+		return db.SubOrder{}, ErrSubOrderNotPending
+	}
+
+	return s.commitSettlement(ctx, tx, so, settle)
+}
+
+// commitSettlement runs settle on a sub-order already locked in tx,
+// refreshes the parent order's status, commits and tells both sides.
+func (s *OrderService) commitSettlement(ctx context.Context, tx pgx.Tx, so db.GetSubOrderForUpdateRow, settle settleFunc) (db.SubOrder, error) {
+	logger := s.Logger.With("sub_order_id", so.ID)
+	txOrderStore := data.NewOrderStore(db.New(tx))
+
+	settled, err := settle(ctx, tx, so)
+	if err != nil {
+		logger.Error("Failed to settle sub-order", "error", err)
+		return db.SubOrder{}, err
+	}
+
+	if err := txOrderStore.RefreshOrderStatus(ctx, so.OrderID); err != nil {
+		logger.Error("Failed to refresh order status", "order_id", so.OrderID, "error", err)
+		return db.SubOrder{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return db.SubOrder{}, err
+	}
+
+	logger.Info("Sub-order settled", "status", settled.Status)
+
+	change := OrderStatusChanged{OrderID: settled.OrderID, SubOrderID: settled.ID, Status: settled.Status}
+	s.WalletService.Events.Publish(ctx, so.BuyerID, EventOrderStatus, change)
+	s.WalletService.Events.Publish(ctx, so.SellerID, EventOrderStatus, change)
+	s.WalletService.Events.PublishBalance(ctx, so.BuyerID, so.SellerID)
+
+	return settled, nil
+}
+
+// releaseSubOrder pays a sub-order out of escrow: the payout to the seller
+// and the commission to the revenue wallet.
+func (s *OrderService) releaseSubOrder(ctx context.Context, tx pgx.Tx, so db.GetSubOrderForUpdateRow) (db.SubOrder, error) {
+	txOrderStore := data.NewOrderStore(db.New(tx))
+	txWalletStore := data.NewWalletStore(db.New(tx))
+	buyerID32 := int32(so.BuyerID)
+	sellerID32 := int32(so.SellerID)
+
+	escrowID, err := txWalletStore.GetPlatformAccount(ctx, EscrowAccount)
+	if err != nil {
+		return db.SubOrder{}, err
+	}
+	if _, err := s.WalletService.debitWalletInTx(ctx, txWalletStore, escrowID, so.Gross, "escrow_release", &sellerID32); err != nil {
+		return db.SubOrder{}, err
+	}
+
+	if so.Payout > 0 {
+		if _, err := s.WalletService.creditWalletInternal(ctx, txWalletStore, sellerID32, so.Payout, "order_payout", "completed", &buyerID32); err != nil {
+			return db.SubOrder{}, err
+		}
+	}
+
+	if so.Commission > 0 {
+		revenueID, err := txWalletStore.GetPlatformAccount(ctx, RevenueAccount)
+		if err != nil {
+			return db.SubOrder{}, err
+		}
+		if _, err := s.WalletService.creditWalletInternal(ctx, txWalletStore, revenueID, so.Commission, "commission", "completed", &sellerID32); err != nil {
+			return db.SubOrder{}, err
+		}
+	}
+
+	return txOrderStore.CompleteSubOrder(ctx, so.ID)
+}
+
+// refundSubOrder returns a sub-order's escrow: what the buyer paid goes back
+// to them and the coupon share goes back to the promo wallet.
+func (s *OrderService) refundSubOrder(ctx context.Context, tx pgx.Tx, so db.GetSubOrderForUpdateRow, cancelledBy int64) (db.SubOrder, error) {
+	txOrderStore := data.NewOrderStore(db.New(tx))
+	txWalletStore := data.NewWalletStore(db.New(tx))
+	buyerID32 := int32(so.BuyerID)
+	sellerID32 := int32(so.SellerID)
+
+	escrowID, err := txWalletStore.GetPlatformAccount(ctx, EscrowAccount)
+	if err != nil {
+		return db.SubOrder{}, err
+	}
+	if _, err := s.WalletService.debitWalletInTx(ctx, txWalletStore, escrowID, so.Gross, "escrow_refund", &buyerID32); err != nil {
+		return db.SubOrder{}, err
+	}
+
+	if paid := so.Gross - so.Discount; paid > 0 {
+		if _, err := s.WalletService.creditWalletInternal(ctx, txWalletStore, buyerID32, paid, "refund", "completed", &sellerID32); err != nil {
+			return db.SubOrder{}, err
+		}
+	}
+
+	if so.Discount > 0 {
+		promoID, err := txWalletStore.GetPlatformAccount(ctx, PromoAccount)
+		if err != nil {
+			return db.SubOrder{}, err
+		}
+		if _, err := s.WalletService.creditWalletInternal(ctx, txWalletStore, promoID, so.Discount, "promo_refund", "completed", &buyerID32); err != nil {
+			return db.SubOrder{}, err
+		}
+	}
+
+	return txOrderStore.CancelSubOrder(ctx, so.ID, cancelledBy)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	db "ecommerce/internal/data/gen"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// allocate runs allocateDiscount over sub-orders whose whole gross is what
+// the coupon covered, and returns the shares in seller order.
+func allocate(discount int64, sellers []int64, eligible map[int64]int64) []int64 {
+	quote := CouponQuote{Discount: discount, EligibleBySeller: eligible}
+	subOrders := make(map[int64]*db.CreateSubOrderParams, len(sellers))
+	for _, sellerID := range sellers {
+		quote.Eligible += eligible[sellerID]
+		subOrders[sellerID] = &db.CreateSubOrderParams{SellerID: sellerID, Gross: eligible[sellerID]}
+	}
+
+	allocateDiscount(discount, quote, sellers, subOrders)
+
+	shares := make([]int64, 0, len(sellers))
+	for _, sellerID := range sellers {
+		shares = append(shares, subOrders[sellerID].Discount)
+	}
+	return shares
+}
+
+func TestAllocateDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		discount int64
+		sellers  []int64
+		eligible map[int64]int64
+		want     []int64
+	}{
+		{"one seller takes it all", 2500, []int64{7}, map[int64]int64{7: 25000}, []int64{2500}},
+		{"even split", 3000, []int64{7, 8, 9}, map[int64]int64{7: 10000, 8: 10000, 9: 10000}, []int64{1000, 1000, 1000}},
+		{"proportional", 3000, []int64{7, 8}, map[int64]int64{7: 10000, 8: 20000}, []int64{1000, 2000}},
+		{"leftover to the largest remainder", 1000, []int64{7, 8}, map[int64]int64{7: 10000, 8: 20000}, []int64{333, 667}},
+		{"leftover to the first seller on a tie", 1000, []int64{7, 8, 9}, map[int64]int64{7: 10000, 8: 10000, 9: 10000}, []int64{334, 333, 333}},
+		{"leftover follows seller order, not ID", 1000, []int64{9, 8, 7}, map[int64]int64{7: 10000, 8: 10000, 9: 10000}, []int64{334, 333, 333}},
+		{"nearly everything", 29999, []int64{7, 8, 9}, map[int64]int64{7: 10000, 8: 10000, 9: 10000}, []int64{10000, 10000, 9999}},
+		{"everything", 30000, []int64{7, 8}, map[int64]int64{7: 10000, 8: 20000}, []int64{10000, 20000}},
+		{"seller outside the coupon gets nothing", 1000, []int64{7, 8, 9}, map[int64]int64{7: 10000, 9: 5000}, []int64{667, 0, 333}},
+		{"no discount", 0, []int64{7, 8}, map[int64]int64{7: 10000, 8: 20000}, []int64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allocate(tt.discount, tt.sellers, tt.eligible); !slices.Equal(got, tt.want) {
+				t.Errorf("shares = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Whatever the split, the shares must add up to the discount, no sub-order
+// may be discounted below zero, and the same order must split the same way.
+func TestAllocateDiscountInvariants(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+
+	for range 2000 {
+		n := 1 + r.IntN(6)
+		sellers := make([]int64, n)
+		eligible := make(map[int64]int64, n)
+		var total int64
+		for i := range sellers {
+			sellers[i] = int64(100 + i)
+			if r.IntN(4) > 0 {
+				eligible[sellers[i]] = 100 * (1 + r.Int64N(5000))
+				total += eligible[sellers[i]]
+			}
+		}
+		if total == 0 {
+			continue
+		}
+		discount := r.Int64N(total + 1)
+
+		shares := allocate(discount, sellers, eligible)
+
+		var sum int64
+		for i, share := range shares {
+			if share < 0 || share > eligible[sellers[i]] {
+				t.Fatalf("discount %d over %v: seller %d got %d of %d eligible", discount, eligible, sellers[i], share, eligible[sellers[i]])
+			}
+			sum += share
+		}
+		if sum != discount {
+			t.Fatalf("discount %d over %v: shares %v add up to %d", discount, eligible, shares, sum)
+		}
+		if again := allocate(discount, sellers, eligible); !slices.Equal(again, shares) {
+			t.Fatalf("discount %d over %v: split %v then %v", discount, eligible, shares, again)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	"log/slog"
+	"time"
+)
+
+type OrphanSweeper struct {
+	Store       data.ProductStore
+	CloudSvc    CloudService
+	Logger      *slog.Logger
+	Interval    time.Duration
+	GracePeriod time.Duration
+	stopSignal  chan struct{}
+}
+
+func NewOrphanSweeper(store data.ProductStore, cloud CloudService, interval, gracePeriod time.Duration, logger *slog.Logger) *OrphanSweeper {
+	return &OrphanSweeper{
+		Store:       store,
+		CloudSvc:    cloud,
+		Logger:      logger,
+		Interval:    interval,
+		GracePeriod: gracePeriod,
+		stopSignal:  make(chan struct{}),
+	}
+}
+
+func (s *OrphanSweeper) Start() {
+	go func() {
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopSignal:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), s.Interval)
+				removed, err := s.Sweep(ctx)
+				cancel()
+				if err != nil {
+					s.Logger.Error("Orphaned upload sweep failed", "error", err)
+					continue
+				}
+				s.Logger.Info("Orphaned upload sweep finished", "removed", removed)
+			}
+		}
+	}()
+}
+
+func (s *OrphanSweeper) Stop() {
+	select {
+	case <-s.stopSignal:
+	default:
+		close(s.stopSignal)
+	}
+}
+
+// Sweep deletes stored images that no product references. Objects younger
+// than the grace period are skipped so uploads for a product that is still
+// being inserted aren't removed from under it.
+func (s *OrphanSweeper) Sweep(ctx context.Context) (int, error) {
+	stored, err := s.CloudSvc.ListImages(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	referenced, err := s.Store.ListReferencedImageURLs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	inUse := make(map[string]struct{}, len(referenced))
+	for _, url := range referenced {
+		inUse[url] = struct{}{}
+	}
+
+	cutoff := time.Now().Add(-s.GracePeriod)
+	removed := 0
+	for _, img := range stored {
+		if _, ok := inUse[img.URL]; ok || img.CreatedAt.After(cutoff) {
+			continue
+		}
+
+		if err := s.CloudSvc.DeleteImage(ctx, img.URL); err != nil {
+			s.Logger.Warn("Failed to delete orphaned upload", "url", img.URL, "error", err)
+			continue
+		}
+		removed++
+	}
+
+	return removed, nil
+}
@@ -29,9 +29,10 @@ type WalletPaymentService struct {
 	WebhookSecret string
 	Client        *http.Client
 	Pool          *pgxpool.Pool
+	Events        *EventService
 }
 
-func NewWalletPaymentService(pool *pgxpool.Pool, store data.WalletStore, logger *slog.Logger) *WalletPaymentService {
+func NewWalletPaymentService(pool *pgxpool.Pool, store data.WalletStore, events *EventService, logger *slog.Logger) *WalletPaymentService {
 	return &WalletPaymentService{
 		Store:         store,
 		Logger:        logger,
@@ -40,6 +41,7 @@ func NewWalletPaymentService(pool *pgxpool.Pool, store data.WalletStore, logger
 		WebhookSecret: os.Getenv("RAZORPAY_WEBHOOK_SECRET"),
 		Client:        &http.Client{Timeout: 10 * time.Second},
 		Pool:          pool,
+		Events:        events,
 	}
 }
 
@@ -221,5 +223,6 @@ func (s *WalletPaymentService) HandleWebhook(ctx context.Context, payload []byte
 	}
 
 	s.Logger.Info("wallet topup successful and committed", "order_id", p.OrderID)
+	s.Events.PublishBalance(ctx, int64(txRow.UserID))
 	return nil
 }
@@ -5,14 +5,22 @@ import (
 	"context"
 	"ecommerce/internal/data"
 	db "ecommerce/internal/data/gen"
+	"ecommerce/internal/imaging"
+	"ecommerce/internal/validator"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"mime/multipart"
 	"net/http"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -20,46 +28,97 @@ import (
 
 const defaultUploadWorkerCap = 5
 
+var (
+	ErrNotProductOwner        = errors.New("product belongs to another seller")
+	ErrListingUnderModeration = errors.New("listing is under moderation and can't be relisted")
+)
+
 type ProductService struct {
-	Store    data.ProductStore
-	Pool     *pgxpool.Pool
-	Logger   *slog.Logger
-	CloudSvc CloudService
+	Store     data.ProductStore
+	Pool      *pgxpool.Pool
+	Logger    *slog.Logger
+	CloudSvc  CloudService
+	SellerSvc *SellerService
+	Listeners []ProductListener
+	Filter    ListingFilter
+}
+
+// ProductListener is told about a listing change after it has been committed.
+type ProductListener interface {
+	ProductUpdated(ctx context.Context, before, after db.Product)
+}
+
+// ListingFilter screens listing text before it is saved. It returns a
+// *validator.ValidationError for text that isn't allowed.
+type ListingFilter interface {
+	CheckListingText(ctx context.Context, name, description string) error
+}
+
+type UpdateProductParams struct {
+	Price    *int32
+	Stock    *int32
+	IsActive *bool
 }
 
 type CreateProductParams struct {
 	SellerID     int64
-	SellerName   string
-	SellerPhone  string
 	Name         string
 	Description  string
 	Condition    string
 	Price        int32
 	Stock        int32
 	Category     string
+	CategoryID   int64
+	ThumbnailURL string
+	Images       []UploadedImage
+}
+
+type UploadedImage struct {
+	FullURL      string
+	MediumURL    string
 	ThumbnailURL string
-	ImageURLs    []string
+	PHash        uint64
+}
+
+func (u UploadedImage) URLs() []string {
+	urls := make([]string, 0, 3)
+	for _, url := range []string{u.FullURL, u.MediumURL, u.ThumbnailURL} {
+		if url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
 }
 
 type ProductDetails struct {
 	db.Product
+	Seller SellerSummary     `json:"seller"`
 	Images []db.ProductImage `json:"images"`
 }
 
-func NewProductService(store data.ProductStore, cloud CloudService, pool *pgxpool.Pool, logger *slog.Logger) *ProductService {
+func NewProductService(store data.ProductStore, sellerSvc *SellerService, cloud CloudService, pool *pgxpool.Pool, logger *slog.Logger) *ProductService {
 	return &ProductService{
-		Store:    store,
-		Pool:     pool,
-		Logger:   logger,
-		CloudSvc: cloud,
+		Store:     store,
+		Pool:      pool,
+		Logger:    logger,
+		CloudSvc:  cloud,
+		SellerSvc: sellerSvc,
 	}
 }
 
+func (s *ProductService) AddListener(l ProductListener) {
+	s.Listeners = append(s.Listeners, l)
+}
+
+func (s *ProductService) SetFilter(f ListingFilter) {
+	s.Filter = f
+}
+
 func (s *ProductService) UploadImagesConcurrent(
 	ctx context.Context,
 	files []*multipart.FileHeader,
 	maxConcurrency int,
-) ([]string, error) {
+) ([]UploadedImage, error) {
 	if len(files) == 0 {
 		return nil, nil
 	}
@@ -75,9 +134,9 @@ func (s *ProductService) UploadImagesConcurrent(
 		file *multipart.FileHeader
 	}
 	type result struct {
-		idx int
-		url string
-		err error
+		idx   int
+		image UploadedImage
+		err   error
 	}
 
 	jobs := make(chan job)
@@ -108,13 +167,13 @@ func (s *ProductService) UploadImagesConcurrent(
 							s.Logger.Error("Error closing file", "error", err)
 						}
 					}()
-					url, err := s.CloudSvc.UploadImage(ctx, f, j.file.Filename)
+					uploaded, err := s.processAndUpload(ctx, f, j.file.Filename)
 					if err != nil {
 						results <- result{idx: j.idx, err: err}
 						cancel()
 						return
 					}
-					results <- result{idx: j.idx, url: url, err: nil}
+					results <- result{idx: j.idx, image: uploaded, err: nil}
 				}()
 			}
 		}()
@@ -137,7 +196,7 @@ func (s *ProductService) UploadImagesConcurrent(
 		close(results)
 	}()
 
-	imageURLs := make([]string, len(files))
+	images := make([]UploadedImage, len(files))
 	var firstErr error
 	received := 0
 	for r := range results {
@@ -148,20 +207,79 @@ func (s *ProductService) UploadImagesConcurrent(
 			}
 			continue
 		}
-		imageURLs[r.idx] = r.url
+		images[r.idx] = r.image
 	}
 
 	if firstErr != nil {
+		s.deleteUploadedImages(images)
 		return nil, firstErr
 	}
 
-	for i, u := range imageURLs {
-		if u == "" {
+	for i, img := range images {
+		if img.FullURL == "" {
 			return nil, errors.New("missing upload result for image index " + strconv.Itoa(i))
 		}
 	}
 
-	return imageURLs, nil
+	return images, nil
+}
+
+func (s *ProductService) processAndUpload(ctx context.Context, file io.Reader, filename string) (UploadedImage, error) {
+	processed, err := imaging.Process(file)
+	if err != nil {
+		s.Logger.Warn("Rejected product image", "filename", filename, "error", err)
+		return UploadedImage{}, err
+	}
+
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	urls := make(map[string]string, len(processed.Variants))
+
+	for _, v := range processed.Variants {
+		url, err := s.CloudSvc.UploadImage(ctx, bytes.NewReader(v.Data), base+"_"+v.Name+v.Ext)
+		if err != nil {
+			s.deleteUploads(slices.Collect(maps.Values(urls)))
+			return UploadedImage{}, err
+		}
+		urls[v.Name] = url
+	}
+
+	return UploadedImage{
+		FullURL:      urls["full"],
+		MediumURL:    urls["medium"],
+		ThumbnailURL: urls["thumbnail"],
+		PHash:        processed.PHash,
+	}, nil
+}
+
+func (s *ProductService) ValidateImageFiles(files []*multipart.FileHeader) error {
+	v := validator.New()
+
+	for i, fh := range files {
+		key := fmt.Sprintf("images[%d]", i)
+
+		if fh.Size > imaging.MaxUploadBytes {
+			v.AddError(key, imaging.ErrTooLarge.Error())
+			continue
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			v.AddError(key, "could not read file")
+			continue
+		}
+		header := make([]byte, 12)
+		n, _ := io.ReadFull(f, header)
+		f.Close()
+
+		if _, err := imaging.Sniff(header[:n]); err != nil {
+			v.AddError(key, err.Error())
+		}
+	}
+
+	if v.Valid() {
+		return nil
+	}
+	return v
 }
 
 func (s *ProductService) CreateProductWithFiles(ctx context.Context,
@@ -170,20 +288,58 @@ func (s *ProductService) CreateProductWithFiles(ctx context.Context,
 	maxUploadConcurrency int,
 ) (db.Product, error) {
 
+	if s.Filter != nil {
+		if err := s.Filter.CheckListingText(ctx, params.Name, params.Description); err != nil {
+			return db.Product{}, err
+		}
+	}
+
 	s.Logger.Info("Uploading product images concurrently", "count", len(files))
-	imageURLs, err := s.UploadImagesConcurrent(ctx, files, maxUploadConcurrency)
+	images, err := s.UploadImagesConcurrent(ctx, files, maxUploadConcurrency)
 	if err != nil {
 		s.Logger.Error("Image uploads failed", "error", err)
 		return db.Product{}, err
 	}
-	if len(imageURLs) == 0 {
+	if len(images) == 0 {
 		return db.Product{}, errors.New("no images uploaded")
 	}
 
-	params.ThumbnailURL = imageURLs[0]
-	params.ImageURLs = imageURLs
+	params.ThumbnailURL = images[0].ThumbnailURL
+	params.Images = images
+
+	product, err := s.CreateProductWithTransaction(ctx, params)
+	if err != nil {
+		s.Logger.Warn("Product insert failed, removing uploaded images", "count", len(images))
+		s.deleteUploadedImages(images)
+		return db.Product{}, err
+	}
+	return product, nil
+}
+
+func (s *ProductService) deleteUploadedImages(images []UploadedImage) {
+	var urls []string
+	for _, img := range images {
+		urls = append(urls, img.URLs()...)
+	}
+	s.deleteUploads(urls)
+}
+
+// deleteUploads runs on its own context because the request context has
+// usually been cancelled by the time we need to compensate. Anything that
+// still fails here is picked up later by the orphan sweeper.
+func (s *ProductService) deleteUploads(urls []string) {
+	if len(urls) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	return s.CreateProductWithTransaction(ctx, params)
+	for _, url := range urls {
+		if err := s.CloudSvc.DeleteImage(ctx, url); err != nil {
+			s.Logger.Error("Failed to delete orphaned upload", "url", url, "error", err)
+		}
+	}
 }
 
 func (s *ProductService) indexProductInTypesense(ctx context.Context, p db.Product) error {
@@ -195,6 +351,7 @@ func (s *ProductService) indexProductInTypesense(ctx context.Context, p db.Produ
 		"price":       p.Price,
 		"stock":       p.Stock,
 		"category":    p.Category,
+		"category_id": p.CategoryID,
 		"image_url":   p.ImageUrl.String,
 		"is_active":   p.IsActive,
 		"created_at":  p.CreatedAt.Time.Unix(),
@@ -204,7 +361,7 @@ func (s *ProductService) indexProductInTypesense(ctx context.Context, p db.Produ
 	b, _ := json.Marshal(doc)
 
 	req, err := http.NewRequest("POST",
-		"http://localhost:8108/collections/products/documents",
+		"http://localhost:8108/collections/products/documents?action=upsert",
 		bytes.NewReader(b),
 	)
 	if err != nil {
@@ -274,14 +431,13 @@ func (s *ProductService) createProduct(
 
 	productParams := db.CreateProductParams{
 		SellerID:    params.SellerID,
-		SellerName:  params.SellerName,
-		SellerPhone: params.SellerPhone,
 		Name:        params.Name,
 		Description: data.NewPGText(params.Description),
 		Condition:   params.Condition,
 		Price:       params.Price,
 		Stock:       params.Stock,
 		Category:    params.Category,
+		CategoryID:  params.CategoryID,
 		ImageUrl:    data.NewPGText(params.ThumbnailURL),
 	}
 
@@ -297,21 +453,36 @@ func (s *ProductService) createProductImages(
 	ctx context.Context,
 	txStore data.ProductStore,
 	productID int64,
-	imageURLs []string,
+	images []UploadedImage,
 ) error {
-	s.Logger.Info("Creating product images records", "product_id", productID, "count", len(imageURLs))
+	s.Logger.Info("Creating product images records", "product_id", productID, "count", len(images))
+
+	for i, img := range images {
+		phash := int64(img.PHash)
 
-	for i, imgURL := range imageURLs {
 		imgParams := db.CreateProductImageParams{
 			ProductID:    productID,
-			ImageUrl:     imgURL,
+			ImageUrl:     img.FullURL,
 			DisplayOrder: int32(i),
+			ThumbnailUrl: data.NewPGText(img.ThumbnailURL),
+			MediumUrl:    data.NewPGText(img.MediumURL),
+			Phash:        data.NewPGInt64(phash),
 		}
 
-		err := txStore.CreateProductImage(ctx, imgParams)
+		duplicateOf, err := txStore.FindSimilarProductImage(ctx, productID, phash, imaging.DuplicateThreshold)
+		switch {
+		case err == nil:
+			s.Logger.Warn("Product image looks like a duplicate of an existing listing", "product_id", productID, "duplicate_of", duplicateOf, "image_url", img.FullURL)
+			imgParams.DuplicateOfProductID = data.NewPGInt64(duplicateOf)
+		case !errors.Is(err, data.ErrRecordNotFound):
+			s.Logger.Error("Failed to check for duplicate image", "error", err, "image_url", img.FullURL)
+			return fmt.Errorf("failed to check image %s for duplicates: %w", img.FullURL, err)
+		}
+
+		err = txStore.CreateProductImage(ctx, imgParams)
 		if err != nil {
-			s.Logger.Error("Failed to create product image record", "error", err, "image_url", imgURL)
-			return fmt.Errorf("failed to insert image %s: %w", imgURL, err)
+			s.Logger.Error("Failed to create product image record", "error", err, "image_url", img.FullURL)
+			return fmt.Errorf("failed to insert image %s: %w", img.FullURL, err)
 		}
 	}
 	return nil
@@ -329,7 +500,7 @@ func (s *ProductService) CreateProductWithImages(
 		return db.Product{}, err
 	}
 
-	err = s.createProductImages(ctx, txStore, newProduct.ID, params.ImageURLs)
+	err = s.createProductImages(ctx, txStore, newProduct.ID, params.Images)
 	if err != nil {
 		return db.Product{}, err
 	}
@@ -338,7 +509,7 @@ func (s *ProductService) CreateProductWithImages(
 	return newProduct, nil
 }
 
-func (s *ProductService) GetAllProducts(ctx context.Context) ([]db.Product, error) {
+func (s *ProductService) GetAllProducts(ctx context.Context) ([]db.GetAllProductsRow, error) {
 	s.Logger.Info("Fetching all products")
 	products, err := s.Store.GetAllProducts(ctx)
 	if err != nil {
@@ -348,7 +519,7 @@ func (s *ProductService) GetAllProducts(ctx context.Context) ([]db.Product, erro
 	return products, nil
 }
 
-func (s *ProductService) GetProductDetails(ctx context.Context, productID int64) (ProductDetails, error) {
+func (s *ProductService) GetProductDetails(ctx context.Context, productID int64, viewerID int64) (ProductDetails, error) {
 	s.Logger.Info("Fetching product details", "product_id", productID)
 
 	var details ProductDetails
@@ -370,7 +541,13 @@ func (s *ProductService) GetProductDetails(ctx context.Context, productID int64)
 		}
 	}
 
+	seller, err := s.SellerSvc.GetSellerSummary(ctx, product.SellerID, viewerID)
+	if err != nil {
+		return details, err
+	}
+
 	details.Product = product
+	details.Seller = seller
 	details.Images = images
 
 	return details, nil
@@ -387,3 +564,76 @@ func (s *ProductService) GetProductsBySeller(ctx context.Context, sellerID int64
 
 	return products, nil
 }
+
+// UpdateProduct changes the price, stock or visibility of a seller's listing.
+// Listeners and the search index are updated in the background once the
+// change has committed.
+func (s *ProductService) UpdateProduct(ctx context.Context, sellerID, productID int64, params UpdateProductParams) (db.Product, error) {
+	logger := s.Logger.With("seller_id", sellerID, "product_id", productID)
+	logger.Info("Updating product listing")
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return db.Product{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	before, err := txStore.GetProductForUpdate(ctx, productID)
+	if err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			logger.Error("Failed to load product for update", "error", err)
+		}
+		return db.Product{}, err
+	}
+	if before.SellerID != sellerID {
+		logger.Warn("Seller attempted to update another seller's product", "owner_id", before.SellerID)
+		return db.Product{}, ErrNotProductOwner
+	}
+	if params.IsActive != nil && *params.IsActive && before.ModerationStatus != ModerationActive {
+		return db.Product{}, ErrListingUnderModeration
+	}
+
+	arg := db.UpdateProductListingParams{ID: productID}
+	if params.Price != nil {
+		arg.Price = data.NewPGInt32(*params.Price)
+	}
+	if params.Stock != nil {
+		arg.Stock = data.NewPGInt32(*params.Stock)
+	}
+	if params.IsActive != nil {
+		arg.IsActive = data.NewPGBool(*params.IsActive)
+	}
+
+	after, err := txStore.UpdateProductListing(ctx, arg)
+	if err != nil {
+		logger.Error("Failed to update product", "error", err)
+		return db.Product{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit product update", "error", err)
+		return db.Product{}, err
+	}
+
+	s.productChanged(before, after)
+
+	logger.Info("Product listing updated", "price", after.Price, "stock", after.Stock, "is_active", after.IsActive)
+	return after, nil
+}
+
+// productChanged reindexes a committed listing change and tells the
+// listeners about it in the background.
+func (s *ProductService) productChanged(before, after db.Product) {
+	go func() {
+		bgCtx := context.Background()
+		if err := s.indexProductInTypesense(bgCtx, after); err != nil {
+			s.Logger.Error("Failed to reindex product in Typesense", "product_id", after.ID, "error", err)
+		}
+		for _, l := range s.Listeners {
+			l.ProductUpdated(bgCtx, before, after)
+		}
+	}()
+}
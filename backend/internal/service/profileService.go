@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"ecommerce/internal/imaging"
+	"ecommerce/internal/validator"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// avatarVariant is the processed size kept for profile pictures.
+const avatarVariant = "thumbnail"
+
+var (
+	ErrProfileNotFound     = errors.New("profile not found")
+	ErrProfileEditConflict = errors.New("profile was changed by another request, reload it and try again")
+	ErrUpiIDTaken          = errors.New("this UPI ID is already linked to another account")
+	ErrPhoneTaken          = errors.New("this phone number is already linked to another account")
+)
+
+// Profile is the owner's view of their account.
+type Profile struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Phone         string    `json:"phone"`
+	UpiID         string    `json:"upi_id"`
+	AvatarURL     string    `json:"avatar_url"`
+	Hostel        string    `json:"hostel"`
+	RollNumber    string    `json:"roll_number,omitempty"`
+	Batch         string    `json:"batch,omitempty"`
+	Branch        string    `json:"branch,omitempty"`
+	UserType      string    `json:"user_type"`
+	CreatedAt     time.Time `json:"created_at"`
+	Version       int32     `json:"version"`
+}
+
+// ProfileUpdate changes the fields that are set. Version must be the one the
+// client last read; an empty UpiID or Hostel clears it.
+type ProfileUpdate struct {
+	Name    *string
+	UpiID   *string
+	Phone   *string
+	Hostel  *string
+	Version int32
+}
+
+type ProfileService struct {
+	Users    data.UserStore
+	Sellers  *SellerService
+	CloudSvc CloudService
+	Logger   *slog.Logger
+}
+
+func NewProfileService(users data.UserStore, sellers *SellerService, cloudSvc CloudService, logger *slog.Logger) *ProfileService {
+	return &ProfileService{
+		Users:    users,
+		Sellers:  sellers,
+		CloudSvc: cloudSvc,
+		Logger:   logger,
+	}
+}
+
+func (s *ProfileService) GetProfile(ctx context.Context, userID int64) (Profile, error) {
+	user, err := s.Users.GetUserByID(ctx, int(userID))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return Profile{}, ErrProfileNotFound
+		}
+		s.Logger.Error("Failed to get profile", "user_id", userID, "error", err)
+		return Profile{}, err
+	}
+	return toProfile(user), nil
+}
+
+// UpdateProfile applies the update on top of the stored profile. It fails
+// with ErrProfileEditConflict if the profile changed since the client read
+// upd.Version.
+func (s *ProfileService) UpdateProfile(ctx context.Context, userID int64, upd ProfileUpdate) (Profile, error) {
+	current, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	name, upiID, phone, hostel := current.Name, current.UpiID, current.Phone, current.Hostel
+	if upd.Name != nil {
+		name = strings.TrimSpace(*upd.Name)
+	}
+	if upd.UpiID != nil {
+		upiID = strings.TrimSpace(*upd.UpiID)
+	}
+	if upd.Phone != nil {
+		phone = strings.TrimSpace(*upd.Phone)
+	}
+	if upd.Hostel != nil {
+		hostel = strings.TrimSpace(*upd.Hostel)
+	}
+
+	v := validator.New()
+	v.Check(name != "", "name", "must be provided")
+	v.Check(utf8.RuneCountInString(name) <= 100, "name", "must not be more than 100 characters")
+	if upiID != "" {
+		v.Check(v.Matches(upiID, validator.UpiIDRX), "upi_id", "must be a valid UPI ID, e.g. name@bank")
+	}
+	if upd.Phone != nil {
+		validatePhone(phone, v)
+	}
+	v.Check(utf8.RuneCountInString(hostel) <= 100, "hostel", "must not be more than 100 characters")
+	if !v.Valid() {
+		return Profile{}, v
+	}
+
+	_, err = s.Users.UpdateUserProfile(ctx, db.UpdateUserProfileParams{
+		Name:        name,
+		UpiID:       data.NewNullPGText(upiID),
+		PhoneNumber: data.NewNullPGText(phone),
+		Hostel:      data.NewNullPGText(hostel),
+		ID:          int32(userID),
+		Version:     upd.Version,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			return Profile{}, ErrProfileEditConflict
+		case errors.Is(err, data.ErrDuplicateUpiID):
+			return Profile{}, ErrUpiIDTaken
+		case errors.Is(err, data.ErrDuplicatePhone):
+			return Profile{}, ErrPhoneTaken
+		}
+		s.Logger.Error("Failed to update profile", "user_id", userID, "error", err)
+		return Profile{}, err
+	}
+
+	s.Logger.Info("Profile updated", "user_id", userID)
+	return s.GetProfile(ctx, userID)
+}
+
+// UpdateAvatar stores a new profile picture and deletes the old one. The
+// image goes through the same processing as listing photos, so metadata is
+// stripped.
+func (s *ProfileService) UpdateAvatar(ctx context.Context, userID int64, file io.Reader, filename string) (Profile, error) {
+	logger := s.Logger.With("user_id", userID)
+
+	current, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	processed, err := imaging.Process(file)
+	if err != nil {
+		logger.Warn("Rejected avatar image", "filename", filename, "error", err)
+		v := validator.New()
+		v.AddError("avatar", err.Error())
+		return Profile{}, v
+	}
+	variant, ok := processed.Variant(avatarVariant)
+	if !ok {
+		return Profile{}, fmt.Errorf("missing %s variant for avatar", avatarVariant)
+	}
+
+	url, err := s.CloudSvc.UploadImage(ctx, bytes.NewReader(variant.Data), fmt.Sprintf("avatar_%d%s", userID, variant.Ext))
+	if err != nil {
+		logger.Error("Failed to upload avatar", "error", err)
+		return Profile{}, err
+	}
+
+	if _, err := s.Users.UpdateUserAvatar(ctx, int(userID), url); err != nil {
+		logger.Error("Failed to save avatar", "error", err)
+		if delErr := s.CloudSvc.DeleteImage(ctx, url); delErr != nil {
+			logger.Warn("Failed to delete unused avatar upload", "url", url, "error", delErr)
+		}
+		return Profile{}, err
+	}
+
+	if current.AvatarURL != "" {
+		if err := s.CloudSvc.DeleteImage(ctx, current.AvatarURL); err != nil {
+			logger.Warn("Failed to delete previous avatar", "url", current.AvatarURL, "error", err)
+		}
+	}
+
+	return s.GetProfile(ctx, userID)
+}
+
+// GetSellerProfile is the public view of a user, as shown to anonymous
+// visitors.
+func (s *ProfileService) GetSellerProfile(ctx context.Context, sellerID int64) (SellerSummary, error) {
+	summary, err := s.Sellers.GetSellerSummary(ctx, sellerID, 0)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return SellerSummary{}, ErrProfileNotFound
+		}
+		return SellerSummary{}, err
+	}
+	return summary, nil
+}
+
+func toProfile(u db.GetUserByIDRow) Profile {
+	return Profile{
+		ID:            int64(u.ID),
+		Name:          u.Name,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Phone:         u.PhoneNumber.String,
+		UpiID:         u.UpiID.String,
+		AvatarURL:     u.AvatarUrl.String,
+		Hostel:        u.Hostel.String,
+		RollNumber:    u.RollNumber.String,
+		Batch:         u.Batch.String,
+		Branch:        u.Branch.String,
+		UserType:      u.UserType,
+		CreatedAt:     u.CreatedAt.Time,
+		Version:       u.Version,
+	}
+}
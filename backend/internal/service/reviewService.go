@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ReviewFlagReasons = []string{"spam", "offensive", "irrelevant", "fake", "other"}
+
+var (
+	ErrReviewNotAllowed    = errors.New("you can only review items from your completed orders")
+	ErrAlreadyReviewed     = errors.New("you have already reviewed this item")
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrAlreadyFlagged      = errors.New("you have already flagged this review")
+	ErrCannotFlagOwnReview = errors.New("you cannot flag your own review")
+)
+
+// Review is the public view of a review. Moderation state is left out; hidden
+// reviews are never listed.
+type Review struct {
+	ID           int64      `json:"id"`
+	ProductID    int64      `json:"product_id"`
+	ProductName  string     `json:"product_name,omitempty"`
+	SellerID     int64      `json:"seller_id"`
+	ReviewerName string     `json:"reviewer_name"`
+	Rating       int32      `json:"rating"`
+	Body         string     `json:"body"`
+	SellerReply  *string    `json:"seller_reply"`
+	RepliedAt    *time.Time `json:"replied_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type ProductReviews struct {
+	RatingAverage float64  `json:"rating_average"`
+	RatingCount   int64    `json:"rating_count"`
+	Reviews       []Review `json:"reviews"`
+}
+
+type ReviewableItem struct {
+	OrderItemID int64     `json:"order_item_id"`
+	OrderID     int64     `json:"order_id"`
+	ProductID   int64     `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	SellerID    int64     `json:"seller_id"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type FlaggedReview struct {
+	ID        int64     `json:"id"`
+	ProductID int64     `json:"product_id"`
+	SellerID  int64     `json:"seller_id"`
+	BuyerID   int64     `json:"buyer_id"`
+	Rating    int32     `json:"rating"`
+	Body      string    `json:"body"`
+	FlagCount int32     `json:"flag_count"`
+	Reasons   []string  `json:"reasons"`
+	IsHidden  bool      `json:"is_hidden"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReviewService struct {
+	Store  data.ReviewStore
+	Pool   *pgxpool.Pool
+	Logger *slog.Logger
+}
+
+func NewReviewService(store data.ReviewStore, pool *pgxpool.Pool, logger *slog.Logger) *ReviewService {
+	return &ReviewService{
+		Store:  store,
+		Pool:   pool,
+		Logger: logger,
+	}
+}
+
+// CreateReview records a buyer's review of an item from one of their
+// completed sub-orders and adds it to the seller's cached rating.
+func (s *ReviewService) CreateReview(ctx context.Context, buyerID, orderItemID int64, rating int32, body string) (Review, error) {
+	logger := s.Logger.With("buyer_id", buyerID, "order_item_id", orderItemID)
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return Review{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	review, err := txStore.CreateReview(ctx, db.CreateReviewParams{
+		OrderItemID: orderItemID,
+		BuyerID:     buyerID,
+		Rating:      rating,
+		Body:        body,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return Review{}, ErrReviewNotAllowed
+		case errors.Is(err, data.ErrDuplicateReview):
+			return Review{}, ErrAlreadyReviewed
+		}
+		logger.Error("Failed to create review", "error", err)
+		return Review{}, err
+	}
+
+	if err := txStore.AdjustSellerRating(ctx, review.SellerID, review.Rating, 1); err != nil {
+		logger.Error("Failed to update seller rating", "seller_id", review.SellerID, "error", err)
+		return Review{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return Review{}, err
+	}
+
+	logger.Info("Review created", "review_id", review.ID, "rating", review.Rating)
+	return toReview(review), nil
+}
+
+// ListReviewableItems returns the buyer's completed order items that they
+// haven't reviewed yet.
+func (s *ReviewService) ListReviewableItems(ctx context.Context, buyerID int64) ([]ReviewableItem, error) {
+	rows, err := s.Store.ListReviewableItems(ctx, buyerID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ReviewableItem, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, ReviewableItem{
+			OrderItemID: r.OrderItemID,
+			OrderID:     r.OrderID,
+			ProductID:   r.ProductID,
+			ProductName: r.ProductName,
+			SellerID:    r.SellerID,
+			CompletedAt: r.CompletedAt.Time,
+		})
+	}
+	return items, nil
+}
+
+func (s *ReviewService) ListProductReviews(ctx context.Context, productID int64) (ProductReviews, error) {
+	rating, err := s.Store.GetProductRating(ctx, productID)
+	if err != nil {
+		return ProductReviews{}, err
+	}
+	rows, err := s.Store.ListProductReviews(ctx, productID)
+	if err != nil {
+		return ProductReviews{}, err
+	}
+
+	result := ProductReviews{
+		RatingCount: rating.RatingCount,
+		Reviews:     make([]Review, 0, len(rows)),
+	}
+	if rating.RatingCount > 0 {
+		result.RatingAverage = float64(rating.RatingTotal) / float64(rating.RatingCount)
+	}
+	for _, r := range rows {
+		review := toReview(db.Review{
+			ID:          r.ID,
+			ProductID:   r.ProductID,
+			SellerID:    r.SellerID,
+			Rating:      r.Rating,
+			Body:        r.Body,
+			SellerReply: r.SellerReply,
+			RepliedAt:   r.RepliedAt,
+			CreatedAt:   r.CreatedAt,
+		})
+		review.ReviewerName = r.ReviewerName
+		result.Reviews = append(result.Reviews, review)
+	}
+	return result, nil
+}
+
+// ListSellerReviews returns every visible review across the seller's
+// listings. The aggregate is on the seller summary.
+func (s *ReviewService) ListSellerReviews(ctx context.Context, sellerID int64) ([]Review, error) {
+	rows, err := s.Store.ListSellerReviews(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := make([]Review, 0, len(rows))
+	for _, r := range rows {
+		review := toReview(db.Review{
+			ID:          r.ID,
+			ProductID:   r.ProductID,
+			SellerID:    r.SellerID,
+			Rating:      r.Rating,
+			Body:        r.Body,
+			SellerReply: r.SellerReply,
+			RepliedAt:   r.RepliedAt,
+			CreatedAt:   r.CreatedAt,
+		})
+		review.ReviewerName = r.ReviewerName
+		review.ProductName = r.ProductName
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
+
+// Reply sets the seller's public reply to a review of one of their items,
+// replacing any earlier reply.
+func (s *ReviewService) Reply(ctx context.Context, sellerID, reviewID int64, reply string) (Review, error) {
+	review, err := s.Store.SetReviewReply(ctx, reviewID, sellerID, reply)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return Review{}, ErrReviewNotFound
+		}
+		s.Logger.Error("Failed to save review reply", "review_id", reviewID, "seller_id", sellerID, "error", err)
+		return Review{}, err
+	}
+	return toReview(review), nil
+}
+
+// Flag reports a review for moderation. Each user can flag a review once.
+func (s *ReviewService) Flag(ctx context.Context, userID, reviewID int64, reason string) error {
+	logger := s.Logger.With("review_id", reviewID, "user_id", userID)
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	review, err := txStore.GetReviewForUpdate(ctx, reviewID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return ErrReviewNotFound
+		}
+		return err
+	}
+	if review.IsHidden {
+		return ErrReviewNotFound
+	}
+	if review.BuyerID == userID {
+		return ErrCannotFlagOwnReview
+	}
+
+	if err := txStore.CreateReviewFlag(ctx, reviewID, userID, reason); err != nil {
+		if errors.Is(err, data.ErrDuplicateFlag) {
+			return ErrAlreadyFlagged
+		}
+		logger.Error("Failed to flag review", "error", err)
+		return err
+	}
+	if err := txStore.IncrementReviewFlagCount(ctx, reviewID); err != nil {
+		logger.Error("Failed to update review flag count", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return err
+	}
+
+	logger.Warn("Review flagged", "reason", reason)
+	return nil
+}
+
+func (s *ReviewService) ListFlagged(ctx context.Context) ([]FlaggedReview, error) {
+	rows, err := s.Store.ListFlaggedReviews(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := make([]FlaggedReview, 0, len(rows))
+	for _, r := range rows {
+		reviews = append(reviews, FlaggedReview{
+			ID:        r.ID,
+			ProductID: r.ProductID,
+			SellerID:  r.SellerID,
+			BuyerID:   r.BuyerID,
+			Rating:    r.Rating,
+			Body:      r.Body,
+			FlagCount: r.FlagCount,
+			Reasons:   strings.Split(r.Reasons, ","),
+			IsHidden:  r.IsHidden,
+			CreatedAt: r.CreatedAt.Time,
+		})
+	}
+	return reviews, nil
+}
+
+// SetHidden hides or restores a review. Hidden reviews don't count towards
+// the seller's rating, so it is adjusted whenever the state changes.
+func (s *ReviewService) SetHidden(ctx context.Context, reviewID int64, hidden bool) error {
+	logger := s.Logger.With("review_id", reviewID, "hidden", hidden)
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	review, err := txStore.GetReviewForUpdate(ctx, reviewID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return ErrReviewNotFound
+		}
+		return err
+	}
+	if review.IsHidden == hidden {
+		return nil
+	}
+
+	if _, err := txStore.SetReviewHidden(ctx, reviewID, hidden); err != nil {
+		logger.Error("Failed to update review", "error", err)
+		return err
+	}
+
+	totalDelta, countDelta := review.Rating, int32(1)
+	if hidden {
+		totalDelta, countDelta = -totalDelta, -countDelta
+	}
+	if err := txStore.AdjustSellerRating(ctx, review.SellerID, totalDelta, countDelta); err != nil {
+		logger.Error("Failed to update seller rating", "seller_id", review.SellerID, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return err
+	}
+
+	logger.Info("Review visibility changed")
+	return nil
+}
+
+func toReview(r db.Review) Review {
+	review := Review{
+		ID:        r.ID,
+		ProductID: r.ProductID,
+		SellerID:  r.SellerID,
+		Rating:    r.Rating,
+		Body:      r.Body,
+		CreatedAt: r.CreatedAt.Time,
+	}
+	if r.SellerReply.Valid {
+		review.SellerReply = &r.SellerReply.String
+	}
+	if r.RepliedAt.Valid {
+		review.RepliedAt = &r.RepliedAt.Time
+	}
+	return review
+}
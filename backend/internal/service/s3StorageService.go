@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/config"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var _ CloudService = (*S3StorageService)(nil)
+
+type S3StorageService struct {
+	Client    *minio.Client
+	Bucket    string
+	PublicURL string
+	Logger    *slog.Logger
+}
+
+func NewS3StorageService(cfg *config.Config, logger *slog.Logger) (*S3StorageService, error) {
+	if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+		return nil, errors.New("config error: S3_ENDPOINT and S3_BUCKET are required for s3 storage")
+	}
+
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure: cfg.S3UseSSL,
+	})
+	if err != nil {
+		logger.Error("Failed to initialize S3 client", "error", err)
+		return nil, err
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, cfg.S3Bucket)
+	if err != nil {
+		logger.Error("Failed to check S3 bucket", "bucket", cfg.S3Bucket, "error", err)
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.S3Bucket, minio.MakeBucketOptions{}); err != nil {
+			logger.Error("Failed to create S3 bucket", "bucket", cfg.S3Bucket, "error", err)
+			return nil, err
+		}
+		logger.Info("Created S3 bucket", "bucket", cfg.S3Bucket)
+	}
+
+	// Buckets are private by default, but UploadImage hands out plain object
+	// URLs. Grant anonymous read on the image prefix unless the operator has
+	// already configured a policy of their own.
+	policy, err := client.GetBucketPolicy(ctx, cfg.S3Bucket)
+	if err != nil {
+		logger.Error("Failed to read S3 bucket policy", "bucket", cfg.S3Bucket, "error", err)
+		return nil, err
+	}
+	if policy == "" {
+		if err := client.SetBucketPolicy(ctx, cfg.S3Bucket, publicReadPolicy(cfg.S3Bucket)); err != nil {
+			logger.Error("Failed to set S3 bucket policy", "bucket", cfg.S3Bucket, "error", err)
+			return nil, err
+		}
+		logger.Info("Granted public read on S3 image prefix", "bucket", cfg.S3Bucket, "prefix", storageFolder)
+	}
+
+	publicURL := cfg.S3PublicURL
+	if publicURL == "" {
+		scheme := "http"
+		if cfg.S3UseSSL {
+			scheme = "https"
+		}
+		publicURL = fmt.Sprintf("%s://%s", scheme, cfg.S3Endpoint)
+	}
+
+	logger.Info("S3 storage service initialized successfully", "endpoint", cfg.S3Endpoint, "bucket", cfg.S3Bucket)
+	return &S3StorageService{
+		Client:    client,
+		Bucket:    cfg.S3Bucket,
+		PublicURL: strings.TrimRight(publicURL, "/"),
+		Logger:    logger,
+	}, nil
+}
+
+func publicReadPolicy(bucket string) string {
+	return fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::%s/%s/*"]}]}`, bucket, storageFolder)
+}
+
+func (s *S3StorageService) UploadImage(ctx context.Context, file io.Reader, filename string) (string, error) {
+	s.Logger.Info("Uploading image to S3", "filename", filename, "bucket", s.Bucket)
+
+	key := newObjectKey(filename)
+	opts := minio.PutObjectOptions{
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+	}
+
+	if _, err := s.Client.PutObject(ctx, s.Bucket, key, file, -1, opts); err != nil {
+		s.Logger.Error("S3 upload failed", "key", key, "error", err)
+		return "", err
+	}
+
+	objectURL := fmt.Sprintf("%s/%s/%s", s.PublicURL, s.Bucket, key)
+	s.Logger.Info("Image uploaded successfully", "url", objectURL)
+	return objectURL, nil
+}
+
+func (s *S3StorageService) DeleteImage(ctx context.Context, imageURL string) error {
+	key, ok := strings.CutPrefix(imageURL, s.PublicURL+"/"+s.Bucket+"/")
+	if !ok {
+		return errors.New("not an object URL for bucket " + s.Bucket + ": " + imageURL)
+	}
+
+	s.Logger.Info("Deleting image from S3", "key", key, "bucket", s.Bucket)
+	if err := s.Client.RemoveObject(ctx, s.Bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		s.Logger.Error("S3 delete failed", "key", key, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *S3StorageService) ListImages(ctx context.Context) ([]StoredImage, error) {
+	var images []StoredImage
+
+	objects := s.Client.ListObjects(ctx, s.Bucket, minio.ListObjectsOptions{
+		Prefix:    storageFolder + "/",
+		Recursive: true,
+	})
+	for obj := range objects {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		images = append(images, StoredImage{
+			URL:       fmt.Sprintf("%s/%s/%s", s.PublicURL, s.Bucket, obj.Key),
+			CreatedAt: obj.LastModified,
+		})
+	}
+	return images, nil
+}
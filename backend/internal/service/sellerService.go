@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	"log/slog"
+	"time"
+)
+
+type SellerService struct {
+	UserStore  data.UserStore
+	OrderStore data.OrderStore
+	Logger     *slog.Logger
+}
+
+type SellerSummary struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	RatingAverage float64   `json:"rating_average"`
+	RatingCount   int32     `json:"rating_count"`
+	JoinedAt      time.Time `json:"joined_at"`
+	Verified      bool      `json:"verified"`
+	Phone         string    `json:"phone,omitempty"`
+	AvatarURL     string    `json:"avatar_url,omitempty"`
+	Hostel        string    `json:"hostel,omitempty"`
+}
+
+func NewSellerService(userStore data.UserStore, orderStore data.OrderStore, logger *slog.Logger) *SellerService {
+	return &SellerService{
+		UserStore:  userStore,
+		OrderStore: orderStore,
+		Logger:     logger,
+	}
+}
+
+// GetSellerSummary returns the public view of a seller. The phone number is
+// only filled in when viewerID is a buyer with an open order from this
+// seller; pass 0 for anonymous visitors.
+func (s *SellerService) GetSellerSummary(ctx context.Context, sellerID, viewerID int64) (SellerSummary, error) {
+	seller, err := s.UserStore.GetSellerSummary(ctx, sellerID)
+	if err != nil {
+		s.Logger.Error("Failed to get seller summary", "seller_id", sellerID, "error", err)
+		return SellerSummary{}, err
+	}
+
+	summary := SellerSummary{
+		ID:          int64(seller.ID),
+		Name:        seller.Name,
+		RatingCount: seller.RatingCount,
+		JoinedAt:    seller.CreatedAt.Time,
+		Verified:    seller.EmailVerified,
+		AvatarURL:   seller.AvatarUrl.String,
+		Hostel:      seller.Hostel.String,
+	}
+	if seller.RatingCount > 0 {
+		summary.RatingAverage = float64(seller.RatingTotal) / float64(seller.RatingCount)
+	}
+
+	if viewerID == 0 || viewerID == sellerID {
+		return summary, nil
+	}
+
+	canContact, err := s.OrderStore.HasActiveOrderWithSeller(ctx, viewerID, sellerID)
+	if err != nil {
+		s.Logger.Error("Failed to check buyer orders with seller", "seller_id", sellerID, "buyer_id", viewerID, "error", err)
+		return summary, nil
+	}
+	if !canContact {
+		return summary, nil
+	}
+
+	contact, err := s.UserStore.GetUserByID(ctx, int(sellerID))
+	if err != nil {
+		s.Logger.Error("Failed to get seller contact details", "seller_id", sellerID, "error", err)
+		return summary, nil
+	}
+	summary.Phone = contact.PhoneNumber.String
+
+	return summary, nil
+}
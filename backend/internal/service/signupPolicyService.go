@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	db "ecommerce/internal/data/gen"
+	"ecommerce/internal/validator"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	SignupRuleAllow = "allow"
+	SignupRuleDeny  = "deny"
+)
+
+var SignupRuleKinds = []string{SignupRuleAllow, SignupRuleDeny}
+
+var domainRX = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?)+$`)
+
+var (
+	ErrEmailDomainNotAllowed    = errors.New("sign up with your university email address")
+	ErrSignupRuleNotFound       = errors.New("signup rule not found")
+	ErrSignupRuleExists         = errors.New("a rule for this address or domain already exists")
+	ErrInvalidSignupRulePattern = errors.New("pattern must be an email address or a domain")
+)
+
+// AcademicDetails are parsed from the local part of a university address.
+// Any of them may be empty.
+type AcademicDetails struct {
+	RollNumber string `json:"roll_number,omitempty"`
+	Batch      string `json:"batch,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+}
+
+type SignupRule struct {
+	ID        int64     `json:"id"`
+	Pattern   string    `json:"pattern"`
+	Rule      string    `json:"rule"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SignupPolicyService decides which email addresses may sign up. Addresses
+// on the configured university domains (or their subdomains) are accepted,
+// and admins can allow or deny individual addresses and whole domains on
+// top of that, e.g. for staff and alumni. A deny always wins. With no
+// domains configured anything not denied is accepted.
+type SignupPolicyService struct {
+	Store       data.SignupRuleStore
+	Domains     []string
+	RollPattern *regexp.Regexp
+	Logger      *slog.Logger
+}
+
+func NewSignupPolicyService(store data.SignupRuleStore, domains []string, rollPattern *regexp.Regexp, logger *slog.Logger) *SignupPolicyService {
+	return &SignupPolicyService{
+		Store:       store,
+		Domains:     domains,
+		RollPattern: rollPattern,
+		Logger:      logger,
+	}
+}
+
+// Check returns ErrEmailDomainNotAllowed if the address may not sign up.
+// Otherwise it returns whatever academic details the address carries, which
+// is nothing for addresses let in by an admin exception.
+func (s *SignupPolicyService) Check(ctx context.Context, email string) (AcademicDetails, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 {
+		return AcademicDetails{}, ErrEmailDomainNotAllowed
+	}
+	local, domain := email[:at], email[at+1:]
+
+	rules, err := s.Store.MatchSignupEmailRules(ctx, email, domain)
+	if err != nil {
+		s.Logger.Error("Failed to load signup rules", "error", err)
+		return AcademicDetails{}, err
+	}
+
+	excepted := false
+	for _, r := range rules {
+		if r.Rule == SignupRuleDeny {
+			s.Logger.Warn("Signup denied by rule", "pattern", r.Pattern)
+			return AcademicDetails{}, ErrEmailDomainNotAllowed
+		}
+		excepted = true
+	}
+	if excepted {
+		return AcademicDetails{}, nil
+	}
+
+	if len(s.Domains) == 0 {
+		return AcademicDetails{}, nil
+	}
+	if !s.universityDomain(domain) {
+		return AcademicDetails{}, ErrEmailDomainNotAllowed
+	}
+	return s.parseAcademicDetails(local), nil
+}
+
+func (s *SignupPolicyService) universityDomain(domain string) bool {
+	for _, d := range s.Domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAcademicDetails applies the roll number pattern to the local part.
+// The named groups roll, batch and branch fill the matching fields; without
+// a roll group the whole match is the roll number.
+func (s *SignupPolicyService) parseAcademicDetails(local string) AcademicDetails {
+	if s.RollPattern == nil {
+		return AcademicDetails{}
+	}
+	m := s.RollPattern.FindStringSubmatch(local)
+	if m == nil {
+		return AcademicDetails{}
+	}
+
+	details := AcademicDetails{RollNumber: m[0]}
+	for i, name := range s.RollPattern.SubexpNames() {
+		switch name {
+		case "roll":
+			details.RollNumber = m[i]
+		case "batch":
+			details.Batch = m[i]
+		case "branch":
+			details.Branch = m[i]
+		}
+	}
+	return details
+}
+
+func (s *SignupPolicyService) ListRules(ctx context.Context) ([]SignupRule, error) {
+	rows, err := s.Store.ListSignupEmailRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]SignupRule, 0, len(rows))
+	for _, r := range rows {
+		rules = append(rules, toSignupRule(r))
+	}
+	return rules, nil
+}
+
+// AddRule records an exception for an address or a domain. A leading "@"
+// on a domain is dropped.
+func (s *SignupPolicyService) AddRule(ctx context.Context, adminID int64, pattern, rule, note string) (SignupRule, error) {
+	pattern = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(pattern)), "@")
+	if !validator.New().Matches(pattern, validator.EmailRX) && !domainRX.MatchString(pattern) {
+		return SignupRule{}, ErrInvalidSignupRulePattern
+	}
+
+	created, err := s.Store.CreateSignupEmailRule(ctx, pattern, rule, note, adminID)
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateSignupRule) {
+			return SignupRule{}, ErrSignupRuleExists
+		}
+		s.Logger.Error("Failed to create signup rule", "pattern", pattern, "error", err)
+		return SignupRule{}, err
+	}
+
+	s.Logger.Info("Signup rule added", "pattern", pattern, "rule", rule, "admin_id", adminID)
+	return toSignupRule(created), nil
+}
+
+func (s *SignupPolicyService) DeleteRule(ctx context.Context, id int64) error {
+	if err := s.Store.DeleteSignupEmailRule(ctx, id); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return ErrSignupRuleNotFound
+		}
+		s.Logger.Error("Failed to delete signup rule", "rule_id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+func toSignupRule(r db.SignupEmailRule) SignupRule {
+	return SignupRule{
+		ID:        r.ID,
+		Pattern:   r.Pattern,
+		Rule:      r.Rule,
+		Note:      r.Note,
+		CreatedAt: r.CreatedAt.Time,
+	}
+}
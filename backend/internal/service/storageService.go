@@ -0,0 +1,45 @@
+package service
+
+import (
+	"ecommerce/internal/config"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StorageBackendCloudinary = "cloudinary"
+	StorageBackendLocal      = "local"
+	StorageBackendS3         = "s3"
+
+	storageFolder = "ecommerce_products"
+)
+
+func NewCloudService(cfg *config.Config, logger *slog.Logger) (CloudService, error) {
+	logger.Info("Initializing image storage", "backend", cfg.StorageBackend)
+
+	switch cfg.StorageBackend {
+	case StorageBackendCloudinary:
+		return NewCloudinaryService(cfg, logger)
+	case StorageBackendLocal:
+		return NewLocalStorageService(cfg, logger)
+	case StorageBackendS3:
+		return NewS3StorageService(cfg, logger)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
+
+func newObjectKey(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch ext {
+	case ".jpg", ".jpeg", ".png", ".webp", ".gif":
+	default:
+		ext = ""
+	}
+	return path.Join(storageFolder, uuid.NewString()+ext)
+}
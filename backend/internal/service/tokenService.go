@@ -11,12 +11,15 @@ import (
 	"errors"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const (
 	AccessTokenTTL  = 15 * time.Minute
 	RefreshTokenTTL = 7 * 24 * time.Hour
+
+	PasswordResetTokenTTL = 30 * time.Minute
 )
 
 type TokenService struct {
@@ -28,6 +31,11 @@ func NewTokenService(store data.TokenStore, logger *slog.Logger) *TokenService {
 	return &TokenService{Store: store, Logger: logger}
 }
 
+// WithTx returns a TokenService whose writes go through tx.
+func (s *TokenService) WithTx(tx pgx.Tx) *TokenService {
+	return &TokenService{Store: s.Store.WithTx(tx), Logger: s.Logger}
+}
+
 func (s *TokenService) CreateNewTokens(ctx context.Context, userID int64) (*token.Token, *token.Token, error) {
 
 	s.Logger.Info("Generating new token pair", "user_id", userID)
@@ -143,6 +151,53 @@ func (s *TokenService) RefreshAndRevokeTokens(ctx context.Context, oldPlaintextT
 	return newAccessToken, newRefreshToken, nil
 }
 
+// CreatePasswordResetToken issues a reset token for the user. Any earlier
+// reset tokens stop working.
+func (s *TokenService) CreatePasswordResetToken(ctx context.Context, userID int64) (*token.Token, error) {
+	if err := s.Store.DeleteAllForUserAndScope(ctx, token.ScopePasswordReset, userID); err != nil {
+		s.Logger.Error("Failed to revoke previous reset tokens", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	resetToken, err := token.GeneratePasswordResetToken(userID, PasswordResetTokenTTL)
+	if err != nil {
+		s.Logger.Error("Failed to generate reset token", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	if err := s.InsertToken(ctx, resetToken); err != nil {
+		s.Logger.Error("Failed to insert reset token hash into DB", "user_id", userID, "error", err)
+		return nil, err
+	}
+	return resetToken, nil
+}
+
+// ConsumePasswordResetToken uses up a reset token and returns the user it
+// was issued to. Unknown, already used and expired tokens give
+// token.ErrInvalidToken.
+func (s *TokenService) ConsumePasswordResetToken(ctx context.Context, plaintext string) (int64, error) {
+	hashBytes, err := hex.DecodeString(token.GenerateTokenHash(plaintext))
+	if err != nil {
+		return 0, err
+	}
+
+	tokenRecord, err := s.Store.ConsumeToken(ctx, hashBytes, token.ScopePasswordReset)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return 0, token.ErrInvalidToken
+		}
+		s.Logger.Error("Database error during reset token lookup", "error", err)
+		return 0, err
+	}
+
+	if !tokenRecord.Expiry.Valid || time.Now().After(tokenRecord.Expiry.Time) {
+		s.Logger.Info("Password reset token expired", "user_id", tokenRecord.UserID)
+		return 0, token.ErrInvalidToken
+	}
+
+	return tokenRecord.UserID, nil
+}
+
 func (s *TokenService) RevokeAllUserTokens(ctx context.Context, scope string, userID int64) error {
 	s.Logger.Info("Attempting to revoke all tokens for user", "user_id", userID, "scope", scope)
 	err := s.Store.DeleteAllForUserAndScope(ctx, scope, userID)
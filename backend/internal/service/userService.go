@@ -9,24 +9,60 @@ import (
 	"ecommerce/internal/dto"
 	"ecommerce/internal/password"
 	"ecommerce/internal/token"
+	"ecommerce/internal/validator"
 	"ecommerce/internal/worker"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"log/slog"
+	"strings"
 	"time"
 )
 
 var ErrPwdMismatch = errors.New("invalid email or password")
 
+const (
+	// emailVerifiedTTL bounds how long a cached verification status is
+	// trusted. Changes made through this service invalidate it straight away.
+	emailVerifiedTTL = 10 * time.Minute
+
+	verificationTokenTTL = 15 * time.Minute
+	// After maxVerificationAttempts tries the user is locked out until
+	// verificationLockout has passed since the first one. The same limits
+	// apply to confirming an email change.
+	maxVerificationAttempts    = 5
+	verificationLockout        = 15 * time.Minute
+	VerificationResendCooldown = time.Minute
+)
+
+var (
+	ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
+	ErrVerificationLocked      = errors.New("too many failed attempts, try again later")
+	ErrResendCooldown          = errors.New("please wait before requesting another code")
+	ErrInvalidResetToken       = errors.New("invalid or expired reset token")
+	ErrEmailTaken              = errors.New("an account with this email already exists")
+	ErrCurrentPasswordMismatch = errors.New("current password is incorrect")
+	ErrNoPasswordSet           = errors.New("this account has no password, use password reset to set one")
+	ErrOAuthEmailUnverified    = errors.New("your Google account email is not verified")
+	ErrOAuthAccountConflict    = errors.New("this email is already linked to a different Google account")
+)
+
+// OAuthIdentity is what a sign-in provider tells us about the user.
+type OAuthIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
 type UserService struct {
 	Logger       *slog.Logger
 	Store        data.UserStore
 	WalletStore  data.WalletStore
 	Cache        cache.Cache
-	Pool         *pgxpool.Pool
+	Pool         data.TxBeginner
 	TokenService *TokenService
+	SignupPolicy *SignupPolicyService
 }
 
 type UserVerification struct {
@@ -39,8 +75,9 @@ func NewUserService(
 	store data.UserStore,
 	walletStore data.WalletStore,
 	cache cache.Cache,
-	pool *pgxpool.Pool,
+	pool data.TxBeginner,
 	tokenService *TokenService,
+	signupPolicy *SignupPolicyService,
 ) *UserService {
 	return &UserService{
 		Logger:       logger,
@@ -49,35 +86,322 @@ func NewUserService(
 		TokenService: tokenService,
 		WalletStore:  walletStore,
 		Pool:         pool,
+		SignupPolicy: signupPolicy,
+	}
+}
+
+// UpdateEmail starts an address change by sending a code to the new
+// address. The account keeps its current email until ConfirmEmailChange.
+func (s *UserService) UpdateEmail(ctx context.Context, userID int64, input dto.UserEmailUpdate) error {
+	email := strings.TrimSpace(input.UpdatedEmail)
+	logger := s.Logger.With("user_id", userID)
+
+	v := validator.New()
+	v.Check(email != "", "updated_email", "must be provided")
+	v.Check(v.Matches(email, validator.EmailRX), "updated_email", "invalid email format")
+	if !v.Valid() {
+		return v
+	}
+
+	user, err := s.Store.GetUserByID(ctx, int(userID))
+	if err != nil {
+		logger.Error("Error getting user", "error", err)
+		return err
+	}
+	if strings.EqualFold(email, user.Email) {
+		v.AddError("updated_email", "must be different from your current email")
+		return v
+	}
+
+	if _, err := s.SignupPolicy.Check(ctx, email); err != nil {
+		if errors.Is(err, ErrEmailDomainNotAllowed) {
+			v.AddError("updated_email", err.Error())
+			return v
+		}
+		return err
+	}
+
+	if _, err := s.Store.GetUserByEmail(ctx, email); err == nil {
+		return ErrEmailTaken
+	} else if !errors.Is(err, data.ErrRecordNotFound) {
+		logger.Error("Error checking email availability", "error", err)
+		return err
+	}
+
+	ok, err := s.Cache.StartVerificationCooldown(ctx, userID, VerificationResendCooldown)
+	if err != nil {
+		logger.Error("Error checking verification resend cooldown", "error", err)
+		return err
+	}
+	if !ok {
+		return ErrResendCooldown
+	}
+
+	code, err := token.GenerateVerificationToken(userID, verificationTokenTTL, token.ScopeActivation)
+	if err != nil {
+		logger.Error("Error generating token", "error", err)
+		return err
+	}
+	if err := s.Cache.SetPendingEmailChange(ctx, userID, email, code.Hash, verificationTokenTTL); err != nil {
+		logger.Error("Error saving pending email change", "error", err)
+		return err
+	}
+
+	err = s.queueEmail(ctx, email, "email_change.tmpl", map[string]any{
+		"name":           user.Name,
+		"token":          code.Plaintext,
+		"expiry_minutes": int(verificationTokenTTL.Minutes()),
+	})
+	if err != nil {
+		return err
 	}
+
+	logger.Info("Email change requested")
+	return nil
 }
 
-func (s *UserService) UpdateEmail(ctx context.Context, input dto.UserEmailUpdate) error {
+// ConfirmEmailChange swaps in the pending address once the code sent to it
+// checks out, and lets the old address know. Too many wrong codes cancel
+// the pending change and lock the user out of confirming for a while.
+func (s *UserService) ConfirmEmailChange(ctx context.Context, userID int64, code string) error {
+	logger := s.Logger.With("user_id", userID)
+
+	// Counted up front and per user rather than per code, so neither parallel
+	// requests nor asking for a fresh code buys more guesses.
+	attempts, err := s.Cache.IncrEmailChangeAttempts(ctx, userID, verificationLockout)
+	if err != nil {
+		logger.Error("Error counting email change attempt", "error", err)
+		return err
+	}
+	if attempts > maxVerificationAttempts {
+		return ErrVerificationLocked
+	}
+
+	email, tokenHash, err := s.Cache.GetPendingEmailChange(ctx, userID)
+	if err != nil {
+		logger.Error("Error getting pending email change", "error", err)
+		return err
+	}
+	if email == "" || tokenHash == "" {
+		return ErrInvalidVerificationCode
+	}
+
+	match, err := token.MatchToken(code, tokenHash)
+	if err != nil {
+		logger.Error("Error decoding token", "error", err)
+		return err
+	}
+	if !match {
+		if attempts == maxVerificationAttempts {
+			logger.Warn("Email change cancelled after repeated failures", "attempts", attempts)
+			if err := s.Cache.DeletePendingEmailChange(ctx, userID); err != nil {
+				logger.Warn("Failed to delete pending email change", "error", err)
+			}
+			return ErrVerificationLocked
+		}
+		return ErrInvalidVerificationCode
+	}
+
+	user, err := s.Store.GetUserByID(ctx, int(userID))
+	if err != nil {
+		logger.Error("Error getting user", "error", err)
+		return err
+	}
+
+	if err := s.Store.UpdateUserEmail(ctx, int(userID), email); err != nil {
+		if errors.Is(err, data.ErrDuplicateEmail) {
+			return ErrEmailTaken
+		}
+		logger.Error("Error updating email", "error", err)
+		return err
+	}
+
+	if err := s.Cache.DeletePendingEmailChange(ctx, userID); err != nil {
+		logger.Warn("Failed to delete pending email change", "error", err)
+	}
+	if err := s.Cache.ResetEmailChangeAttempts(ctx, userID); err != nil {
+		logger.Warn("Failed to reset email change attempts", "error", err)
+	}
+	if err := s.Cache.DeleteEmailVerified(ctx, userID); err != nil {
+		logger.Warn("Failed to clear cached verification status", "error", err)
+	}
+
+	err = s.queueEmail(ctx, user.Email, "email_changed.tmpl", map[string]any{
+		"name":      user.Name,
+		"new_email": email,
+	})
+	if err != nil {
+		logger.Warn("Failed to notify previous address", "error", err)
+	}
+
+	logger.Info("Email changed")
 	return nil
 }
 
+// ChangePassword replaces the password after checking the current one. Every
+// session is signed out and a fresh token pair is returned for this one.
+func (s *UserService) ChangePassword(ctx context.Context, userID int64, input dto.UserPasswordUpdate) (accessToken string, refreshToken string, err error) {
+	logger := s.Logger.With("user_id", userID)
+
+	v := validator.New()
+	v.Check(input.CurrentPassword != "", "current_password", "must be provided")
+	validatePassword(input.NewPassword, v)
+	if !v.Valid() {
+		return "", "", v
+	}
+
+	storedHash, err := s.Store.GetUserPasswordHash(ctx, int(userID))
+	if err != nil {
+		logger.Error("Error getting password hash", "error", err)
+		return "", "", err
+	}
+	if storedHash == "" {
+		return "", "", ErrNoPasswordSet
+	}
+
+	match, err := password.ComparePasswordAndHash(input.CurrentPassword, storedHash)
+	if err != nil {
+		logger.Error("Password comparison failed", "error", err)
+		return "", "", err
+	}
+	if !match {
+		logger.Warn("Password change failed: current password mismatch")
+		return "", "", ErrCurrentPasswordMismatch
+	}
+
+	passwordHash, err := password.GeneratePasswordHash(input.NewPassword)
+	if err != nil {
+		logger.Error("Failed to hash new password", "error", err)
+		return "", "", err
+	}
+	if err := s.Store.UpdateUserPassword(ctx, int(userID), passwordHash); err != nil {
+		logger.Error("Failed to update password", "error", err)
+		return "", "", err
+	}
+
+	if err := s.TokenService.RevokeAllUserTokens(ctx, token.ScopeRefresh, userID); err != nil {
+		return "", "", err
+	}
+	newAccessToken, newRefreshToken, err := s.TokenService.CreateNewTokens(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	logger.Info("Password changed")
+	return newAccessToken.Plaintext, newRefreshToken.Plaintext, nil
+}
+
+// VerifyUser checks a verification code and marks the user's email as
+// verified. Wrong codes are counted, and once there have been too many the
+// user is locked out for a while, even with the right code.
 func (s *UserService) VerifyUser(ctx context.Context, input *UserVerification) error {
-	tokenHash, err := s.Cache.GetTokenHashByUserID(ctx, int64(input.ID))
+	userID := int64(input.ID)
+	logger := s.Logger.With("user_id", input.ID)
+
+	// Count the attempt before looking at the code, so parallel guesses can't
+	// all slip in under the limit.
+	attempts, err := s.Cache.IncrVerificationAttempts(ctx, userID, verificationLockout)
 	if err != nil {
-		s.Logger.Error("Error getting token", "error", err)
+		logger.Error("Error counting verification attempt", "error", err)
 		return err
 	}
+	if attempts > maxVerificationAttempts {
+		return ErrVerificationLocked
+	}
+
+	tokenHash, err := s.Cache.GetTokenHashByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Error getting token", "error", err)
+		return err
+	}
+	if tokenHash == "" {
+		return ErrInvalidVerificationCode
+	}
+
 	match, err := token.MatchToken(input.Token, tokenHash)
 	if err != nil {
-		s.Logger.Error("Error decoding token", "error", err)
+		logger.Error("Error decoding token", "error", err)
 		return err
 	}
 
-	if match {
-		err := s.Store.VerifyUserEmail(ctx, input.ID)
-		if err != nil {
-			s.Logger.Error("Error updating email verification", "error", err, "user_id", input.ID)
-			return err
+	if !match {
+		if attempts == maxVerificationAttempts {
+			logger.Warn("Verification locked after repeated failures", "attempts", attempts)
+			return ErrVerificationLocked
 		}
+		return ErrInvalidVerificationCode
+	}
+
+	if err := s.Store.VerifyUserEmail(ctx, input.ID); err != nil {
+		logger.Error("Error updating email verification", "error", err)
+		return err
+	}
+
+	if err := s.Cache.DeleteToken(ctx, tokenHash); err != nil {
+		logger.Warn("Failed to delete used verification token", "error", err)
+	}
+	if err := s.Cache.ResetVerificationAttempts(ctx, userID); err != nil {
+		logger.Warn("Failed to reset verification attempts", "error", err)
+	}
+	if err := s.Cache.DeleteEmailVerified(ctx, userID); err != nil {
+		logger.Warn("Failed to clear cached verification status", "error", err)
+	}
+
+	logger.Info("User email verified")
+	return nil
+}
+
+// ResendVerification emails a new code to an unverified account, replacing
+// the old one. Unknown and already verified addresses are ignored so the
+// endpoint can't be used to find out who has an account.
+func (s *UserService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.Store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return nil
+		}
+		s.Logger.Error("Error looking up user for verification resend", "error", err)
+		return err
+	}
+	if user.EmailVerified {
 		return nil
-	} else {
-		return fmt.Errorf("invalid token")
 	}
+
+	// A request during the cooldown is dropped silently; reporting it would
+	// tell the caller that the address has an unverified account.
+	ok, err := s.Cache.StartVerificationCooldown(ctx, int64(user.ID), VerificationResendCooldown)
+	if err != nil {
+		s.Logger.Error("Error checking verification resend cooldown", "error", err, "user_id", user.ID)
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	s.sendToken(ctx, user.ID, user.Email, user.Name)
+	return nil
+}
+
+// IsEmailVerified reports whether the user has verified their email,
+// reading through a short-lived Valkey cache so the check is cheap enough to
+// run on every request.
+func (s *UserService) IsEmailVerified(ctx context.Context, userID int64) (bool, error) {
+	verified, ok, err := s.Cache.GetEmailVerified(ctx, userID)
+	if err != nil {
+		s.Logger.Warn("Failed to read cached verification status", "error", err, "user_id", userID)
+	} else if ok {
+		return verified, nil
+	}
+
+	u, err := s.Store.GetUserByID(ctx, int(userID))
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.Cache.SetEmailVerified(ctx, userID, u.EmailVerified, emailVerifiedTTL); err != nil {
+		s.Logger.Warn("Failed to cache verification status", "error", err, "user_id", userID)
+	}
+	return u.EmailVerified, nil
 }
 
 func (s UserService) FindUserByEmail(email string) (*domain.User, error) {
@@ -90,6 +414,16 @@ func (s UserService) Signup(ctx context.Context, input dto.UserSignup) (*domain.
 		return nil, err
 	}
 
+	academic, err := s.SignupPolicy.Check(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, ErrEmailDomainNotAllowed) {
+			v := validator.New()
+			v.AddError("email", err.Error())
+			return nil, v
+		}
+		return nil, err
+	}
+
 	password_hash, err := password.GeneratePasswordHash(input.Password)
 	if err != nil {
 		return nil, err
@@ -111,6 +445,9 @@ func (s UserService) Signup(ctx context.Context, input dto.UserSignup) (*domain.
 		PhoneNumber:  data.NewPGText(input.Phone),
 		Email:        input.Email,
 		PasswordHash: pgTextPwdHash,
+		RollNumber:   data.NewNullPGText(academic.RollNumber),
+		Batch:        data.NewNullPGText(academic.Batch),
+		Branch:       data.NewNullPGText(academic.Branch),
 	}
 	s.Logger.Info("Creating User", "email", user.Email)
 
@@ -133,10 +470,13 @@ func (s UserService) Signup(ctx context.Context, input dto.UserSignup) (*domain.
 	}
 
 	resUser := &domain.User{
-		ID:    uint64(dbUser.ID),
-		Phone: input.Phone,
-		Email: dbUser.Email,
-		Name:  dbUser.Name,
+		ID:         uint64(dbUser.ID),
+		Phone:      input.Phone,
+		Email:      dbUser.Email,
+		Name:       dbUser.Name,
+		RollNumber: dbUser.RollNumber.String,
+		Batch:      dbUser.Batch.String,
+		Branch:     dbUser.Branch.String,
 	}
 
 	s.sendToken(ctx, dbUser.ID, dbUser.Email, dbUser.Name)
@@ -146,6 +486,88 @@ func (s UserService) Signup(ctx context.Context, input dto.UserSignup) (*domain.
 	return resUser, nil
 }
 
+// ForgotPassword emails a password reset token. Unknown addresses are
+// ignored so the endpoint can't be used to find out who has an account.
+func (s *UserService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.Store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return nil
+		}
+		s.Logger.Error("Error looking up user for password reset", "error", err)
+		return err
+	}
+
+	resetToken, err := s.TokenService.CreatePasswordResetToken(ctx, int64(user.ID))
+	if err != nil {
+		return err
+	}
+
+	err = s.queueEmail(ctx, user.Email, "password_reset.tmpl", map[string]any{
+		"name":           user.Name,
+		"token":          resetToken.Plaintext,
+		"expiry_minutes": int(PasswordResetTokenTTL.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Info("Password reset requested", "user_id", user.ID)
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token, then signs the user
+// out everywhere by revoking their refresh tokens.
+func (s *UserService) ResetPassword(ctx context.Context, tokenPlaintext, newPassword string) error {
+	v := validator.New()
+	validatePassword(newPassword, v)
+	if !v.Valid() {
+		return v
+	}
+
+	passwordHash, err := password.GeneratePasswordHash(newPassword)
+	if err != nil {
+		s.Logger.Error("Failed to hash new password", "error", err)
+		return err
+	}
+
+	// The token is only used up if the password change and the session
+	// revocation both commit, so a failure leaves the link usable.
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		s.Logger.Error("Failed to begin password reset transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	txTokens := s.TokenService.WithTx(tx)
+
+	userID, err := txTokens.ConsumePasswordResetToken(ctx, tokenPlaintext)
+	if err != nil {
+		if errors.Is(err, token.ErrInvalidToken) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	if err := s.Store.WithTx(tx).UpdateUserPassword(ctx, int(userID), passwordHash); err != nil {
+		s.Logger.Error("Failed to update password", "user_id", userID, "error", err)
+		return err
+	}
+
+	if err := txTokens.RevokeAllUserTokens(ctx, token.ScopeRefresh, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.Logger.Error("Failed to commit password reset", "user_id", userID, "error", err)
+		return err
+	}
+
+	s.Logger.Info("Password reset", "user_id", userID)
+	return nil
+}
+
 func (s *UserService) Login(ctx context.Context, input dto.UserLogin) (user *domain.User, accessToken string, refreshToken string, err error) {
 	s.Logger.Info("Attempting user login", "email", input.Email)
 
@@ -184,13 +606,161 @@ func (s *UserService) Login(ctx context.Context, input dto.UserLogin) (user *dom
 	return user, newAccessToken.Plaintext, newRefreshToken.Plaintext, nil
 }
 
+// LoginWithGoogle signs in the user behind a Google identity and issues the
+// same token pair as Login. A user already linked to the Google account is
+// signed straight in. Otherwise the account with the same email is linked,
+// which requires Google to have verified the address, and failing that a new
+// user and wallet are created.
+func (s *UserService) LoginWithGoogle(ctx context.Context, identity OAuthIdentity) (user *domain.User, accessToken string, refreshToken string, err error) {
+	logger := s.Logger.With("google_id", identity.Subject)
+
+	dbUser, err := s.Store.GetUserByGoogleID(ctx, identity.Subject)
+	switch {
+	case err == nil:
+	case errors.Is(err, data.ErrRecordNotFound):
+		if !identity.EmailVerified {
+			logger.Warn("Google sign-in rejected: email not verified", "email", identity.Email)
+			return nil, "", "", ErrOAuthEmailUnverified
+		}
+		dbUser, err = s.linkOrCreateGoogleUser(ctx, identity)
+		if err != nil {
+			return nil, "", "", err
+		}
+	default:
+		logger.Error("Error looking up user by Google ID", "error", err)
+		return nil, "", "", err
+	}
+
+	userID := int64(dbUser.ID)
+	newAccessToken, newRefreshToken, err := s.TokenService.CreateNewTokens(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to generate and save tokens", "error", err, "user_id", userID)
+		return nil, "", "", errors.New("failed to generate secure tokens")
+	}
+
+	user = &domain.User{
+		ID:    uint64(dbUser.ID),
+		Name:  dbUser.Name,
+		Email: dbUser.Email,
+		Phone: dbUser.PhoneNumber.String,
+	}
+	logger.Info("User logged in with Google", "user_id", userID)
+	return user, newAccessToken.Plaintext, newRefreshToken.Plaintext, nil
+}
+
+// linkGoogleAccount attaches a Google account to an existing user. If the
+// user never verified their email, whoever registered it may not own the
+// address, so their password and sessions are dropped in the same
+// transaction and only the Google account can sign in afterwards.
+func (s *UserService) linkGoogleAccount(ctx context.Context, existing db.User, googleID string) error {
+	logger := s.Logger.With("google_id", googleID, "user_id", existing.ID)
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	txStore := s.Store.WithTx(tx)
+
+	if !existing.EmailVerified {
+		if err := txStore.ClearUserPassword(ctx, int(existing.ID)); err != nil {
+			logger.Error("Failed to clear password of unverified account", "error", err)
+			return err
+		}
+		if err := s.TokenService.WithTx(tx).RevokeAllUserTokens(ctx, token.ScopeRefresh, int64(existing.ID)); err != nil {
+			return err
+		}
+		logger.Warn("Dropped credentials of unverified account before linking Google")
+	}
+
+	if err := txStore.LinkGoogleAccount(ctx, int(existing.ID), googleID); err != nil {
+		if errors.Is(err, data.ErrEditConflict) {
+			logger.Warn("Google sign-in rejected: account linked elsewhere")
+			return ErrOAuthAccountConflict
+		}
+		logger.Error("Failed to link Google account", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit Google account link", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *UserService) linkOrCreateGoogleUser(ctx context.Context, identity OAuthIdentity) (db.User, error) {
+	logger := s.Logger.With("google_id", identity.Subject)
+
+	existing, err := s.Store.GetUserByEmail(ctx, identity.Email)
+	if err == nil {
+		if err := s.linkGoogleAccount(ctx, existing, identity.Subject); err != nil {
+			return db.User{}, err
+		}
+		if err := s.Cache.DeleteEmailVerified(ctx, int64(existing.ID)); err != nil {
+			logger.Warn("Failed to clear cached verification status", "error", err)
+		}
+		logger.Info("Linked Google account to existing user", "user_id", existing.ID)
+		return existing, nil
+	}
+	if !errors.Is(err, data.ErrRecordNotFound) {
+		logger.Error("Error looking up user by email", "error", err)
+		return db.User{}, err
+	}
+
+	academic, err := s.SignupPolicy.Check(ctx, identity.Email)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	name := strings.TrimSpace(identity.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return db.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := s.Store.WithTx(tx).CreateGoogleUser(ctx, db.CreateGoogleUserParams{
+		Name:       name,
+		Email:      identity.Email,
+		GoogleID:   data.NewPGText(identity.Subject),
+		RollNumber: data.NewNullPGText(academic.RollNumber),
+		Batch:      data.NewNullPGText(academic.Batch),
+		Branch:     data.NewNullPGText(academic.Branch),
+	})
+	if err != nil {
+		logger.Warn("Failed to create Google user, rolling back", "error", err)
+		return db.User{}, err
+	}
+
+	if _, err := s.WalletStore.WithTx(tx).CreateWallet(ctx, created.ID); err != nil {
+		logger.Warn("Failed to create wallet, rolling back", "user_id", created.ID, "error", err)
+		return db.User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return db.User{}, err
+	}
+
+	logger.Info("User and wallet created from Google sign-in", "user_id", created.ID)
+	return created, nil
+}
+
 func (s UserService) FindUserByID(ctx context.Context, id int32) (db.GetUserByIDRow, error) {
 	return s.Store.GetUserByID(ctx, int(id))
 }
 
 func (s UserService) sendToken(ctx context.Context, id int32, email string, name string) {
 
-	expiry := time.Minute * 15
+	expiry := verificationTokenTTL
 	token, err := token.GenerateVerificationToken(int64(id), expiry, token.ScopeActivation)
 	if err != nil {
 		s.Logger.Error("Error generating token", "error", err)
@@ -243,3 +813,23 @@ func (s UserService) queueVerificationEmail(ctx context.Context, userEmail, name
 
 	return nil
 }
+
+func (s UserService) queueEmail(ctx context.Context, recipient, templateFile string, templateData any) error {
+	job := worker.MailJob{
+		Recipient:    recipient,
+		TemplateFile: templateFile,
+		TemplateData: templateData,
+	}
+
+	jobJSON, err := json.Marshal(job)
+	if err != nil {
+		s.Logger.Error("Failed to serialize mail job", "error", err)
+		return fmt.Errorf("failed to serialize mail job: %w", err)
+	}
+
+	if err := s.Cache.AddEmailToQueue(ctx, recipient, string(jobJSON)); err != nil {
+		s.Logger.Error("Failed to enqueue email job", "template", templateFile, "error", err)
+		return fmt.Errorf("failed to enqueue email job to valkey: %w", err)
+	}
+	return nil
+}
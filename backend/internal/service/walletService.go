@@ -22,6 +22,7 @@ var (
 type WalletService struct {
 	BaseStore    data.WalletStore
 	Pool         *pgxpool.Pool
+	Events       *EventService
 	Logger       *slog.Logger
 	RzpClient    *razorpay.Client
 	RzpKeySecret string
@@ -38,12 +39,14 @@ func NewWalletService(
 	store data.WalletStore,
 	pool *pgxpool.Pool,
 	paymentService *WalletPaymentService,
+	events *EventService,
 	logger *slog.Logger,
 ) *WalletService {
 
 	return &WalletService{
 		BaseStore: store,
 		Pool:      pool,
+		Events:    events,
 		Logger:    logger,
 	}
 }
@@ -104,7 +107,11 @@ func (s *WalletService) Credit(ctx context.Context, userID int32, amount int64)
 		LifetimeEarned: wallet.LifetimeEarned,
 	}
 
-	return w, tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return Wallet{}, err
+	}
+	s.Events.Publish(ctx, int64(userID), EventWalletBalance, w)
+	return w, nil
 }
 
 func (s *WalletService) Debit(ctx context.Context, userID int32, amount int64) (Wallet, error) {
@@ -143,7 +150,11 @@ func (s *WalletService) Debit(ctx context.Context, userID int32, amount int64) (
 		LifetimeEarned: updatedWallet.LifetimeEarned,
 	}
 
-	return w, tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return Wallet{}, err
+	}
+	s.Events.Publish(ctx, int64(userID), EventWalletBalance, w)
+	return w, nil
 }
 
 func (s *WalletService) Transfer(ctx context.Context, txStore data.WalletStore, senderID int32, recipientID int32, amount int64) error {
@@ -177,6 +188,13 @@ func (s *WalletService) Transfer(ctx context.Context, txStore data.WalletStore,
 	return nil
 }
 
+// TransferCompleted tells both sides about a transfer once the caller has
+// committed it.
+func (s *WalletService) TransferCompleted(ctx context.Context, senderID, recipientID int32, amount int64) {
+	s.Events.Publish(ctx, int64(recipientID), EventWalletTransferIn, TransferReceived{FromUserID: senderID, Amount: amount})
+	s.Events.PublishBalance(ctx, int64(senderID), int64(recipientID))
+}
+
 func (s *WalletService) CreatePaymentOrder(ctx context.Context, userID int32, amount int64) (map[string]interface{}, error) {
 	s.Logger.Info("Creating Razorpay order", "user_id", userID, "amount", amount)
 
@@ -302,6 +320,7 @@ func (s *WalletService) VerifyPayment(ctx context.Context, rzpOrderID, rzpPaymen
 	}
 
 	s.Logger.Info("Payment verified and wallet credited", "user_id", wallet.UserID)
+	s.Events.PublishBalance(ctx, int64(wallet.UserID))
 	return wallet, nil
 }
 
@@ -354,3 +373,26 @@ func (s *WalletService) creditWalletInternal(
 
 	return wallet, nil
 }
+
+// debitWalletInTx debits a wallet inside a caller's transaction, locking it
+// first so the balance check and the debit can't interleave with another
+// payment.
+func (s *WalletService) debitWalletInTx(
+	ctx context.Context,
+	txStore data.WalletStore,
+	userID int32,
+	amount int64,
+	txType string,
+	relatedUserID *int32,
+) (db_gen.Wallet, error) {
+	wallet, err := txStore.GetWalletByUserIDForUpdate(ctx, userID)
+	if err != nil {
+		return db_gen.Wallet{}, err
+	}
+
+	if wallet.Balance < amount {
+		return db_gen.Wallet{}, ErrInsufficientFunds
+	}
+
+	return s.creditWalletInternal(ctx, txStore, userID, -amount, txType, "completed", relatedUserID)
+}
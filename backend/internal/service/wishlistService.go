@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"ecommerce/internal/data"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var ErrWishlistItemNotFound = errors.New("item not in wishlist")
+
+type WishlistItem struct {
+	ProductID  int64     `json:"product_id"`
+	Name       string    `json:"name"`
+	Price      int32     `json:"price"`
+	PriceAtAdd int32     `json:"price_at_add"`
+	PriceDrop  int32     `json:"price_drop"`
+	Stock      int32     `json:"stock"`
+	ImageUrl   string    `json:"image_url"`
+	Available  bool      `json:"available"`
+	AddedAt    time.Time `json:"added_at"`
+}
+
+type WishlistService struct {
+	Store        data.WishlistStore
+	ProductStore data.ProductStore
+	Carts        *CartService
+	Logger       *slog.Logger
+}
+
+func NewWishlistService(store data.WishlistStore, productStore data.ProductStore, carts *CartService, logger *slog.Logger) *WishlistService {
+	return &WishlistService{
+		Store:        store,
+		ProductStore: productStore,
+		Carts:        carts,
+		Logger:       logger,
+	}
+}
+
+func (s *WishlistService) AddToWishlist(ctx context.Context, userID, productID int64) error {
+	logger := s.Logger.With("user_id", userID, "product_id", productID)
+
+	product, err := s.ProductStore.GetProductByID(ctx, productID)
+	if err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			logger.Error("Failed to look up product for wishlist", "error", err)
+		}
+		return err
+	}
+	if product.SellerID == userID {
+		return ErrCannotBuyOwnItem
+	}
+
+	logger.Info("Adding item to wishlist")
+	return s.Store.AddWishlistItem(ctx, userID, productID, product.Price)
+}
+
+func (s *WishlistService) RemoveFromWishlist(ctx context.Context, userID, productID int64) error {
+	s.Logger.Info("Removing item from wishlist", "user_id", userID, "product_id", productID)
+
+	err := s.Store.DeleteWishlistItem(ctx, userID, productID)
+	if errors.Is(err, data.ErrRecordNotFound) {
+		return ErrWishlistItemNotFound
+	}
+	return err
+}
+
+func (s *WishlistService) GetWishlist(ctx context.Context, userID int64) ([]WishlistItem, error) {
+	rows, err := s.Store.GetWishlist(ctx, userID)
+	if err != nil {
+		s.Logger.Error("Failed to get wishlist", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	items := make([]WishlistItem, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, WishlistItem{
+			ProductID:  r.ProductID,
+			Name:       r.Name,
+			Price:      r.Price,
+			PriceAtAdd: r.PriceAtAdd,
+			PriceDrop:  max(r.PriceAtAdd-r.Price, 0),
+			Stock:      r.Stock,
+			ImageUrl:   r.ImageUrl.String,
+			Available:  r.IsActive && r.Stock > 0,
+			AddedAt:    r.AddedAt.Time,
+		})
+	}
+	return items, nil
+}
+
+// MoveToCart adds a wishlisted item to the user's cart through the normal
+// cart validation and drops it from the wishlist once that succeeds.
+func (s *WishlistService) MoveToCart(ctx context.Context, userID, productID int64, quantity int) error {
+	ok, err := s.Store.IsInWishlist(ctx, userID, productID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWishlistItemNotFound
+	}
+
+	if err := s.Carts.AddToCart(ctx, UserCart(userID), productID, quantity); err != nil {
+		return err
+	}
+
+	return s.RemoveFromWishlist(ctx, userID, productID)
+}
@@ -31,6 +31,9 @@ const (
 	ScopeActivation     = "activation"
 	ScopeAuthentication = "authentication"
 	ScopeRefresh        = "refresh"
+	ScopeHandover       = "handover"
+	ScopePasswordReset  = "password_reset"
+	ScopeEventStream    = "event_stream"
 )
 
 type Token struct {
@@ -91,6 +94,23 @@ func GenerateRefreshToken(userID int64, ttl time.Duration, scope string) (*Token
 	return token, nil
 }
 
+func GeneratePasswordResetToken(userID int64, ttl time.Duration) (*Token, error) {
+	result, err := generateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &Token{
+		Plaintext: result,
+		UserID:    userID,
+		Expiry:    time.Now().Add(ttl),
+		Scope:     ScopePasswordReset,
+	}
+
+	token.Hash = GenerateTokenHash(token.Plaintext)
+	return token, nil
+}
+
 func GenerateVerificationToken(userID int64, ttl time.Duration, scope string) (*Token, error) {
 	nBig, err := rand.Int(rand.Reader, big.NewInt(900000))
 	if err != nil {
@@ -109,6 +129,17 @@ func GenerateVerificationToken(userID int64, ttl time.Duration, scope string) (*
 }
 
 func VerifyAccessToken(tokenPlaintext string) (*Claims, error) {
+	return verifyJWT(tokenPlaintext, ScopeAuthentication)
+}
+
+// VerifyEventStreamToken checks a short-lived token minted for opening the
+// event stream. Access tokens are not accepted here, so they never need to
+// appear in a URL.
+func VerifyEventStreamToken(tokenPlaintext string) (*Claims, error) {
+	return verifyJWT(tokenPlaintext, ScopeEventStream)
+}
+
+func verifyJWT(tokenPlaintext string, scope string) (*Claims, error) {
 	keyFunc := func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
@@ -123,7 +154,7 @@ func VerifyAccessToken(tokenPlaintext string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
-	if claims.Scope != ScopeAuthentication {
+	if claims.Scope != scope {
 		return nil, ErrInvalidToken
 	}
 
@@ -0,0 +1,46 @@
+-- name: CreateProductAlert :exec
+INSERT INTO product_alerts (user_id, product_id, kind)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, product_id, kind) DO NOTHING;
+
+-- name: DeleteProductAlert :execrows
+DELETE FROM product_alerts
+WHERE user_id = $1 AND product_id = $2 AND kind = $3;
+
+-- name: ListProductAlertsForUser :many
+SELECT
+    a.product_id,
+    a.kind,
+    a.created_at,
+    p.name,
+    p.price,
+    p.stock
+FROM product_alerts a
+JOIN products p ON p.id = a.product_id
+WHERE a.user_id = $1
+ORDER BY a.created_at DESC;
+
+-- name: ListAlertSubscribers :many
+-- Wishlisting a product implies subscribing to every alert for it.
+SELECT u.id, u.name, u.email, u.alert_mode
+FROM users u
+WHERE u.alert_mode <> 'off'
+  AND (
+    EXISTS (
+        SELECT 1 FROM product_alerts a
+        WHERE a.user_id = u.id AND a.product_id = sqlc.arg(product_id)::bigint AND a.kind = sqlc.arg(kind)::text
+    )
+    OR EXISTS (
+        SELECT 1 FROM wishlist_items w
+        WHERE w.user_id = u.id AND w.product_id = sqlc.arg(product_id)::bigint
+    )
+  );
+
+-- name: GetAlertMode :one
+SELECT alert_mode FROM users
+WHERE id = $1;
+
+-- name: SetAlertMode :exec
+UPDATE users
+SET alert_mode = $2
+WHERE id = $1;
@@ -0,0 +1,27 @@
+-- name: AddCartItem :exec
+INSERT INTO cart_items (user_id, product_id, quantity, price_at_add)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, product_id) DO UPDATE
+SET quantity = cart_items.quantity + EXCLUDED.quantity,
+    price_at_add = EXCLUDED.price_at_add;
+
+-- name: SetCartItem :exec
+INSERT INTO cart_items (user_id, product_id, quantity, price_at_add)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, product_id) DO UPDATE
+SET quantity = EXCLUDED.quantity,
+    price_at_add = EXCLUDED.price_at_add;
+
+-- name: GetCartItems :many
+SELECT product_id, quantity, price_at_add
+FROM cart_items
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteCartItem :exec
+DELETE FROM cart_items
+WHERE user_id = $1 AND product_id = $2;
+
+-- name: ClearCart :exec
+DELETE FROM cart_items
+WHERE user_id = $1;
@@ -0,0 +1,27 @@
+-- name: ListCategoriesWithCounts :many
+SELECT
+    c.id,
+    c.parent_id,
+    c.name,
+    c.slug,
+    c.icon,
+    c.display_order,
+    COUNT(p.id) FILTER (WHERE p.is_active) AS product_count
+FROM categories c
+LEFT JOIN products p ON p.category_id = c.id
+GROUP BY c.id
+ORDER BY c.display_order ASC, c.name ASC;
+
+-- name: GetCategoryBySlugOrName :one
+SELECT * FROM categories
+WHERE slug = lower(sqlc.arg(term)::text) OR lower(name) = lower(sqlc.arg(term)::text)
+ORDER BY slug = lower(sqlc.arg(term)::text) DESC, parent_id NULLS FIRST, id ASC
+LIMIT 1;
+
+-- name: GetCategoryDescendantIDs :many
+WITH RECURSIVE tree AS (
+    SELECT c.id FROM categories c WHERE c.id = $1
+    UNION ALL
+    SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+SELECT id FROM tree;
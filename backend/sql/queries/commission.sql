@@ -0,0 +1,44 @@
+-- name: ListCategoryCommissionRates :many
+WITH RECURSIVE tree (id, commission_bps) AS (
+    SELECT c.id, c.commission_bps FROM categories c WHERE c.parent_id IS NULL
+    UNION ALL
+    SELECT c.id, COALESCE(c.commission_bps, t.commission_bps)
+    FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+SELECT id, commission_bps FROM tree;
+
+-- name: SetCategoryCommission :execrows
+UPDATE categories
+SET commission_bps = sqlc.narg(commission_bps)
+WHERE id = sqlc.arg(id);
+
+-- name: ListVerifiedClubs :many
+SELECT id FROM users
+WHERE id = ANY(sqlc.arg(ids)::int[])
+  AND user_type = 'club';
+
+-- name: SetClubVerified :execrows
+UPDATE users
+SET user_type = CASE WHEN sqlc.arg(verified)::boolean THEN 'club' ELSE 'customer' END
+WHERE id = sqlc.arg(id)
+  AND user_type IN ('customer', 'club');
+
+-- name: ListSalesBySeller :many
+SELECT
+    oi.id,
+    oi.order_id,
+    oi.sub_order_id,
+    oi.product_id,
+    p.name AS product_name,
+    oi.quantity,
+    oi.price_at_purchase,
+    oi.commission_bps,
+    oi.commission_amount,
+    oi.seller_payout,
+    so.status,
+    oi.created_at
+FROM order_items oi
+JOIN sub_orders so ON so.id = oi.sub_order_id
+JOIN products p ON p.id = oi.product_id
+WHERE oi.seller_id = $1
+ORDER BY oi.created_at DESC, oi.id DESC;
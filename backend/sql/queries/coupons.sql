@@ -0,0 +1,50 @@
+-- name: CreateCoupon :one
+INSERT INTO coupons (
+    code,
+    description,
+    discount_type,
+    discount_value,
+    max_discount,
+    min_cart_value,
+    usage_limit,
+    per_user_limit,
+    category_id,
+    seller_id,
+    starts_at,
+    ends_at,
+    created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) RETURNING *;
+
+-- name: GetCouponByCode :one
+SELECT * FROM coupons
+WHERE code = $1;
+
+-- name: GetCouponByCodeForUpdate :one
+SELECT * FROM coupons
+WHERE code = $1
+FOR UPDATE;
+
+-- name: ListCoupons :many
+SELECT * FROM coupons
+ORDER BY created_at DESC;
+
+-- name: SetCouponActive :one
+UPDATE coupons
+SET is_active = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CountCouponRedemptionsByUser :one
+SELECT COUNT(*) FROM coupon_redemptions
+WHERE coupon_id = $1 AND user_id = $2;
+
+-- name: CreateCouponRedemption :exec
+INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount)
+VALUES ($1, $2, $3, $4);
+
+-- name: IncrementCouponUsage :exec
+UPDATE coupons
+SET times_used = times_used + 1
+WHERE id = $1;
@@ -0,0 +1,63 @@
+-- name: ListCampusLocations :many
+SELECT * FROM campus_locations
+WHERE is_active
+ORDER BY display_order ASC, name ASC;
+
+-- name: CreatePickupSlot :one
+INSERT INTO pickup_slots (sub_order_id, location_id, starts_at, ends_at)
+SELECT sqlc.arg(sub_order_id), l.id, sqlc.arg(starts_at), sqlc.arg(ends_at)
+FROM campus_locations l
+WHERE l.id = sqlc.arg(location_id) AND l.is_active
+RETURNING *;
+
+-- name: ListPickupSlots :many
+SELECT
+    ps.id,
+    ps.sub_order_id,
+    ps.location_id,
+    l.name AS location,
+    ps.starts_at,
+    ps.ends_at
+FROM pickup_slots ps
+JOIN campus_locations l ON l.id = ps.location_id
+WHERE ps.sub_order_id = $1
+ORDER BY ps.starts_at ASC;
+
+-- name: DeletePickupSlots :exec
+DELETE FROM pickup_slots
+WHERE sub_order_id = $1;
+
+-- name: GetPickupSlot :one
+SELECT * FROM pickup_slots
+WHERE id = $1 AND sub_order_id = $2;
+
+-- name: GetHandover :one
+SELECT * FROM handovers
+WHERE sub_order_id = $1;
+
+-- name: UpsertHandover :one
+INSERT INTO handovers (sub_order_id, slot_id, code_hash, code_expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (sub_order_id) DO UPDATE
+SET slot_id = EXCLUDED.slot_id,
+    code_hash = EXCLUDED.code_hash,
+    code_expires_at = EXCLUDED.code_expires_at,
+    failed_attempts = 0
+WHERE handovers.handed_over_at IS NULL
+RETURNING *;
+
+-- name: ClaimHandoverAttempt :one
+UPDATE handovers
+SET failed_attempts = failed_attempts + 1
+WHERE sub_order_id = $1
+  AND failed_attempts < $2
+  AND code_hash IS NOT NULL
+  AND handed_over_at IS NULL
+RETURNING *;
+
+-- name: CompleteHandover :execrows
+UPDATE handovers
+SET handed_over_at = NOW(), code_hash = NULL, failed_attempts = failed_attempts - 1
+WHERE sub_order_id = $1
+  AND code_hash = $2
+  AND handed_over_at IS NULL;
@@ -0,0 +1,135 @@
+-- name: UpsertProductConversation :one
+INSERT INTO conversations (product_id, buyer_id, seller_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (product_id, buyer_id) WHERE product_id IS NOT NULL
+DO UPDATE SET seller_id = EXCLUDED.seller_id
+RETURNING *;
+
+-- name: UpsertSubOrderConversation :one
+INSERT INTO conversations (sub_order_id, buyer_id, seller_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (sub_order_id) WHERE sub_order_id IS NOT NULL
+DO UPDATE SET seller_id = EXCLUDED.seller_id
+RETURNING *;
+
+-- name: GetConversation :one
+SELECT * FROM conversations
+WHERE id = $1;
+
+-- name: ListConversationsForUser :many
+SELECT
+    c.id,
+    c.product_id,
+    c.sub_order_id,
+    c.buyer_id,
+    c.seller_id,
+    (CASE WHEN c.buyer_id = sqlc.arg(user_id) THEN s.name ELSE b.name END)::text AS counterpart_name,
+    COALESCE(p.name, '')::text AS product_name,
+    COALESCE((
+        SELECT m.body FROM messages m
+        WHERE m.conversation_id = c.id
+        ORDER BY m.id DESC
+        LIMIT 1
+    ), '')::text AS last_message,
+    c.last_message_at,
+    (
+        SELECT COUNT(*) FROM messages m
+        WHERE m.conversation_id = c.id
+          AND m.sender_id <> sqlc.arg(user_id)
+          AND m.id > (CASE WHEN c.buyer_id = sqlc.arg(user_id) THEN c.buyer_last_read_id ELSE c.seller_last_read_id END)
+    )::bigint AS unread_count,
+    c.created_at
+FROM conversations c
+JOIN users b ON b.id = c.buyer_id
+JOIN users s ON s.id = c.seller_id
+LEFT JOIN products p ON p.id = c.product_id
+WHERE c.buyer_id = sqlc.arg(user_id) OR c.seller_id = sqlc.arg(user_id)
+ORDER BY COALESCE(c.last_message_at, c.created_at) DESC;
+
+-- name: CountUnreadMessages :one
+SELECT COUNT(*)::bigint
+FROM messages m
+JOIN conversations c ON c.id = m.conversation_id
+WHERE (c.buyer_id = sqlc.arg(user_id) OR c.seller_id = sqlc.arg(user_id))
+  AND m.sender_id <> sqlc.arg(user_id)
+  AND m.id > (CASE WHEN c.buyer_id = sqlc.arg(user_id) THEN c.buyer_last_read_id ELSE c.seller_last_read_id END);
+
+-- name: ListMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+  AND (sqlc.narg(before_id)::bigint IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: CreateMessage :one
+INSERT INTO messages (conversation_id, sender_id, body)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetMessage :one
+SELECT * FROM messages
+WHERE id = $1;
+
+-- Sending a message also marks the conversation read up to it for the
+-- sender.
+-- name: TouchConversation :exec
+UPDATE conversations
+SET last_message_at = NOW(),
+    buyer_last_read_id = CASE WHEN buyer_id = sqlc.arg(sender_id) THEN sqlc.arg(message_id) ELSE buyer_last_read_id END,
+    seller_last_read_id = CASE WHEN seller_id = sqlc.arg(sender_id) THEN sqlc.arg(message_id) ELSE seller_last_read_id END
+WHERE id = sqlc.arg(id);
+
+-- name: MarkConversationRead :exec
+UPDATE conversations c
+SET buyer_last_read_id = CASE WHEN c.buyer_id = sqlc.arg(user_id) THEN latest.id ELSE c.buyer_last_read_id END,
+    seller_last_read_id = CASE WHEN c.seller_id = sqlc.arg(user_id) THEN latest.id ELSE c.seller_last_read_id END
+FROM (
+    SELECT COALESCE(MAX(m.id), 0)::bigint AS id
+    FROM messages m
+    WHERE m.conversation_id = sqlc.arg(id)
+) latest
+WHERE c.id = sqlc.arg(id);
+
+-- name: BlockUser :exec
+INSERT INTO user_blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM user_blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: ListBlockedUsers :many
+SELECT ub.blocked_id, u.name, ub.created_at
+FROM user_blocks ub
+JOIN users u ON u.id = ub.blocked_id
+WHERE ub.blocker_id = $1
+ORDER BY ub.created_at DESC;
+
+-- name: IsBlockedBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = sqlc.arg(user_a) AND blocked_id = sqlc.arg(user_b))
+       OR (blocker_id = sqlc.arg(user_b) AND blocked_id = sqlc.arg(user_a))
+);
+
+-- name: CreateMessageReport :one
+INSERT INTO message_reports (message_id, reporter_id, reason, details)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListMessageReports :many
+SELECT
+    r.id,
+    r.message_id,
+    r.reason,
+    r.details,
+    r.created_at,
+    r.reporter_id,
+    m.sender_id,
+    m.conversation_id,
+    m.body,
+    m.created_at AS sent_at
+FROM message_reports r
+JOIN messages m ON m.id = r.message_id
+ORDER BY r.created_at DESC;
@@ -0,0 +1,61 @@
+-- name: CreateListingReport :one
+INSERT INTO listing_reports (product_id, reporter_id, reason, details)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: CountOpenListingReports :one
+SELECT COUNT(*) FROM listing_reports
+WHERE product_id = $1 AND status = 'open';
+
+-- name: ListListingReports :many
+SELECT r.*, u.name AS reporter_name
+FROM listing_reports r
+JOIN users u ON u.id = r.reporter_id
+WHERE r.product_id = $1
+ORDER BY r.created_at DESC;
+
+-- name: ResolveListingReports :exec
+UPDATE listing_reports
+SET status = sqlc.arg(status),
+    resolved_by = sqlc.arg(resolved_by),
+    resolved_at = NOW()
+WHERE product_id = sqlc.arg(product_id) AND status = 'open';
+
+-- name: SetProductModeration :one
+UPDATE products
+SET moderation_status = sqlc.arg(moderation_status),
+    moderation_reason = sqlc.narg(moderation_reason),
+    is_active = sqlc.arg(is_active),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ListModerationQueue :many
+SELECT
+    p.id,
+    p.seller_id,
+    p.name,
+    p.moderation_status,
+    p.moderation_reason,
+    COUNT(r.id) FILTER (WHERE r.status = 'open') AS open_reports,
+    COALESCE(string_agg(DISTINCT r.reason, ',') FILTER (WHERE r.status = 'open'), '')::text AS reasons,
+    MAX(r.created_at)::timestamptz AS last_reported_at
+FROM products p
+LEFT JOIN listing_reports r ON r.product_id = p.id
+WHERE p.moderation_status = 'held'
+   OR EXISTS (SELECT 1 FROM listing_reports o WHERE o.product_id = p.id AND o.status = 'open')
+GROUP BY p.id
+ORDER BY (p.moderation_status = 'held') DESC, open_reports DESC, last_reported_at DESC;
+
+-- name: ListBannedTerms :many
+SELECT * FROM banned_terms
+ORDER BY pattern;
+
+-- name: CreateBannedTerm :one
+INSERT INTO banned_terms (pattern, is_regex, created_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: DeleteBannedTerm :execrows
+DELETE FROM banned_terms
+WHERE id = $1;
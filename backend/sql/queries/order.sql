@@ -2,20 +2,26 @@
 INSERT INTO orders (
     user_id,
     total_amount,
-    status
+    status,
+    coupon_id,
+    discount_amount
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5
 ) RETURNING *;
 
 -- name: CreateOrderItem :exec
 INSERT INTO order_items (
     order_id,
+    sub_order_id,
     product_id,
     seller_id,
     quantity,
-    price_at_purchase
+    price_at_purchase,
+    commission_bps,
+    commission_amount,
+    seller_payout
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 );
 
 -- name: GetOrderByID :one
@@ -30,3 +36,64 @@ WHERE order_id = $1;
 SELECT * FROM orders
 WHERE user_id = $1
 ORDER BY created_at DESC;
+
+-- name: HasActiveOrderWithSeller :one
+SELECT EXISTS (
+    SELECT 1
+    FROM orders o
+    JOIN sub_orders so ON so.order_id = o.id
+    WHERE o.user_id = sqlc.arg(buyer_id)
+      AND so.seller_id = sqlc.arg(seller_id)
+      AND so.status = 'pending'
+);
+
+-- name: CreateSubOrder :one
+INSERT INTO sub_orders (
+    order_id,
+    seller_id,
+    gross,
+    discount,
+    commission,
+    payout
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetSubOrder :one
+SELECT so.*, o.user_id AS buyer_id
+FROM sub_orders so
+JOIN orders o ON o.id = so.order_id
+WHERE so.id = $1;
+
+-- name: GetSubOrderForUpdate :one
+SELECT so.*, o.user_id AS buyer_id
+FROM sub_orders so
+JOIN orders o ON o.id = so.order_id
+WHERE so.id = $1
+FOR UPDATE OF so;
+
+-- name: GetSubOrdersByOrderID :many
+SELECT * FROM sub_orders
+WHERE order_id = $1
+ORDER BY id;
+
+-- name: CompleteSubOrder :one
+UPDATE sub_orders
+SET status = 'completed', completed_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: CancelSubOrder :one
+UPDATE sub_orders
+SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = sqlc.arg(cancelled_by)
+WHERE id = sqlc.arg(id) AND status = 'pending'
+RETURNING *;
+
+-- name: RefreshOrderStatus :exec
+UPDATE orders o
+SET status = CASE
+    WHEN EXISTS (SELECT 1 FROM sub_orders so WHERE so.order_id = o.id AND so.status = 'pending') THEN 'pending'
+    WHEN EXISTS (SELECT 1 FROM sub_orders so WHERE so.order_id = o.id AND so.status = 'completed') THEN 'completed'
+    ELSE 'cancelled'
+END
+WHERE o.id = $1;
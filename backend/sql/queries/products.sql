@@ -1,17 +1,16 @@
 -- name: CreateProduct :one
 INSERT INTO products (
     seller_id, 
-	seller_name,
-	seller_phone,
     name, 
     description, 
 	condition,
 	category,
     price, 
     stock, 
-    image_url
+    image_url,
+    category_id
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 ) RETURNING *;
 
 -- name: GetProductsByPriceRange :many
@@ -32,11 +31,23 @@ ORDER BY created_at DESC;
 INSERT INTO product_images (
     product_id, 
     image_url, 
-    display_order
+    display_order,
+    thumbnail_url,
+    medium_url,
+    phash,
+    duplicate_of_product_id
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, $6, $7
 );
 
+-- name: FindSimilarProductImage :one
+SELECT product_id FROM product_images
+WHERE phash IS NOT NULL
+  AND product_id <> sqlc.arg(exclude_product_id)
+  AND bit_count((phash # sqlc.arg(phash)::bigint)::bit(64)) <= sqlc.arg(max_distance)::int
+ORDER BY bit_count((phash # sqlc.arg(phash)::bigint)::bit(64)) ASC, created_at ASC
+LIMIT 1;
+
 -- name: GetProductByID :one
 SELECT * FROM products
 WHERE id = $1 AND is_active = TRUE;
@@ -47,9 +58,25 @@ WHERE product_id = $1
 ORDER BY display_order ASC;
 
 -- name: GetAllProducts :many
-SELECT * FROM products
-WHERE is_active = TRUE
-ORDER BY created_at DESC;
+SELECT
+    p.id,
+    p.seller_id,
+    u.name AS seller_name,
+    p.name,
+    p.description,
+    p.condition,
+    p.price,
+    p.stock,
+    p.category,
+    p.category_id,
+    p.image_url,
+    p.is_active,
+    p.created_at,
+    p.updated_at
+FROM products p
+JOIN users u ON u.id = p.seller_id
+WHERE p.is_active = TRUE
+ORDER BY p.created_at DESC;
 
 
 -- name: GetProductsBySeller :many
@@ -60,3 +87,28 @@ ORDER BY created_at DESC;
 -- name: GetProductsByIDs :many
 SELECT * FROM products
 WHERE id = ANY($1::bigint[]);
+
+-- name: ListReferencedImageURLs :many
+SELECT image_url AS url FROM product_images
+UNION
+SELECT thumbnail_url FROM product_images WHERE thumbnail_url IS NOT NULL
+UNION
+SELECT medium_url FROM product_images WHERE medium_url IS NOT NULL
+UNION
+SELECT image_url FROM products WHERE image_url IS NOT NULL
+UNION
+SELECT avatar_url FROM users WHERE avatar_url IS NOT NULL;
+
+-- name: GetProductForUpdate :one
+SELECT * FROM products
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateProductListing :one
+UPDATE products
+SET price = COALESCE(sqlc.narg('price'), price),
+    stock = COALESCE(sqlc.narg('stock'), stock),
+    is_active = COALESCE(sqlc.narg('is_active'), is_active),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
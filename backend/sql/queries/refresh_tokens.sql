@@ -6,6 +6,11 @@ VALUES ($1, $2, $3, $4);
 DELETE FROM tokens
 WHERE scope = $1 AND user_id = $2;
 
+-- name: ConsumeToken :one
+DELETE FROM tokens
+WHERE hash = $1 AND scope = $2
+RETURNING *;
+
 -- name: GetTokenByHash :one
 SELECT *
 FROM tokens
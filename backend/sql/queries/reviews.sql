@@ -0,0 +1,82 @@
+-- name: CreateReview :one
+INSERT INTO reviews (order_item_id, product_id, seller_id, buyer_id, rating, body)
+SELECT oi.id, oi.product_id, oi.seller_id, o.user_id, sqlc.arg(rating), sqlc.arg(body)
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN sub_orders so ON so.id = oi.sub_order_id
+WHERE oi.id = sqlc.arg(order_item_id)
+  AND o.user_id = sqlc.arg(buyer_id)
+  AND so.status = 'completed'
+RETURNING *;
+
+-- name: GetReviewForUpdate :one
+SELECT * FROM reviews
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListProductReviews :many
+SELECT r.*, u.name AS reviewer_name
+FROM reviews r
+JOIN users u ON u.id = r.buyer_id
+WHERE r.product_id = $1 AND NOT r.is_hidden
+ORDER BY r.created_at DESC;
+
+-- name: ListSellerReviews :many
+SELECT r.*, u.name AS reviewer_name, p.name AS product_name
+FROM reviews r
+JOIN users u ON u.id = r.buyer_id
+JOIN products p ON p.id = r.product_id
+WHERE r.seller_id = $1 AND NOT r.is_hidden
+ORDER BY r.created_at DESC;
+
+-- name: ListReviewableItems :many
+SELECT oi.id AS order_item_id, oi.order_id, oi.product_id, p.name AS product_name, oi.seller_id, so.completed_at
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN sub_orders so ON so.id = oi.sub_order_id
+JOIN products p ON p.id = oi.product_id
+LEFT JOIN reviews r ON r.order_item_id = oi.id
+WHERE o.user_id = $1
+  AND so.status = 'completed'
+  AND r.id IS NULL
+ORDER BY so.completed_at DESC;
+
+-- name: GetProductRating :one
+SELECT COALESCE(SUM(rating), 0)::bigint AS rating_total, COUNT(*)::bigint AS rating_count
+FROM reviews
+WHERE product_id = $1 AND NOT is_hidden;
+
+-- name: SetReviewReply :one
+UPDATE reviews
+SET seller_reply = sqlc.arg(reply), replied_at = NOW()
+WHERE id = sqlc.arg(id) AND seller_id = sqlc.arg(seller_id) AND NOT is_hidden
+RETURNING *;
+
+-- name: CreateReviewFlag :exec
+INSERT INTO review_flags (review_id, user_id, reason)
+VALUES ($1, $2, $3);
+
+-- name: IncrementReviewFlagCount :exec
+UPDATE reviews
+SET flag_count = flag_count + 1
+WHERE id = $1;
+
+-- name: ListFlaggedReviews :many
+SELECT
+    r.*,
+    COALESCE((SELECT string_agg(DISTINCT f.reason, ',') FROM review_flags f WHERE f.review_id = r.id), '')::text AS reasons
+FROM reviews r
+WHERE r.flag_count > 0
+ORDER BY r.is_hidden ASC, r.flag_count DESC, r.created_at DESC;
+
+-- name: SetReviewHidden :one
+UPDATE reviews
+SET is_hidden = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: AdjustSellerRating :exec
+UPDATE users
+SET rating_total = rating_total + sqlc.arg(total_delta),
+    rating_count = rating_count + sqlc.arg(count_delta)
+WHERE id = sqlc.arg(seller_id);
@@ -0,0 +1,16 @@
+-- name: ListSignupEmailRules :many
+SELECT * FROM signup_email_rules
+ORDER BY rule, pattern;
+
+-- name: MatchSignupEmailRules :many
+SELECT * FROM signup_email_rules
+WHERE pattern = sqlc.arg(email)::text OR pattern = sqlc.arg(domain)::text;
+
+-- name: CreateSignupEmailRule :one
+INSERT INTO signup_email_rules (pattern, rule, note, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: DeleteSignupEmailRule :execrows
+DELETE FROM signup_email_rules
+WHERE id = $1;
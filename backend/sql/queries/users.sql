@@ -1,18 +1,39 @@
 -- name: CreateUser :one
 INSERT INTO users (
-  name, email, phone_number, password_hash
+  name, email, phone_number, password_hash, roll_number, batch, branch
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6, $7
 )
 RETURNING *;
 
+-- name: CreateGoogleUser :one
+INSERT INTO users (
+  name, email, google_id, email_verified, roll_number, batch, branch
+) VALUES (
+  $1, $2, $3, TRUE, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetUserByGoogleID :one
+SELECT * FROM users
+WHERE google_id = $1;
+
+-- name: LinkGoogleAccount :execrows
+UPDATE users
+SET
+  google_id = $1,
+  email_verified = TRUE,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = $2 AND google_id IS NULL;
+
 -- name: GetUserAuthByEmail :one
 SELECT id, name, password_hash, phone_number
 FROM users 
 WHERE email = $1;
 
 -- name: GetUserByID :one
-SELECT id, name, email, upi_id, phone_number, email_verified, created_at, version
+SELECT id, name, email, upi_id, phone_number, email_verified, user_type, created_at, version,
+  avatar_url, hostel, roll_number, batch, branch
 FROM users
 WHERE id = $1;
 
@@ -27,19 +48,57 @@ SET
   updated_at = CURRENT_TIMESTAMP
 WHERE id = $1;
 
+-- name: GetUserPasswordHash :one
+SELECT password_hash FROM users
+WHERE id = $1;
+
 -- name: UpdateUserEmail :exec
 UPDATE users
 SET
     email = $1,
-    updated_at = CURRENT_TIMESTAMP
+    email_verified = TRUE,
+    updated_at = CURRENT_TIMESTAMP,
+    version = version + 1
 where id = $2;
 
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET
+  password_hash = $1,
+  updated_at = CURRENT_TIMESTAMP,
+  version = version + 1
+WHERE id = $2;
+
+-- name: ClearUserPassword :exec
+UPDATE users
+SET
+  password_hash = NULL,
+  updated_at = CURRENT_TIMESTAMP,
+  version = version + 1
+WHERE id = $1;
+
 -- name: UpdateUserProfile :one
 UPDATE users
 SET 
   name = $1, 
   upi_id = $2,
+  phone_number = $3,
+  hostel = $4,
   updated_at = CURRENT_TIMESTAMP,
   version = version + 1
-WHERE id = $3 AND version = $4
+WHERE id = $5 AND version = $6
 RETURNING *;
+
+-- name: UpdateUserAvatar :one
+UPDATE users
+SET
+  avatar_url = $1,
+  updated_at = CURRENT_TIMESTAMP,
+  version = version + 1
+WHERE id = $2
+RETURNING *;
+
+-- name: GetSellerSummary :one
+SELECT id, name, created_at, email_verified, rating_total, rating_count, avatar_url, hostel
+FROM users
+WHERE id = $1;
@@ -37,3 +37,7 @@ SET
   lifetime_spent = lifetime_spent + $1
 WHERE user_id = $2
 RETURNING *;
+
+-- name: GetPlatformAccount :one
+SELECT user_id FROM platform_accounts
+WHERE name = $1;
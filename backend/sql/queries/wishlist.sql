@@ -0,0 +1,30 @@
+-- name: AddWishlistItem :exec
+INSERT INTO wishlist_items (user_id, product_id, price_at_add)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, product_id) DO NOTHING;
+
+-- name: DeleteWishlistItem :execrows
+DELETE FROM wishlist_items
+WHERE user_id = $1 AND product_id = $2;
+
+-- name: GetWishlist :many
+SELECT
+    w.product_id,
+    w.price_at_add,
+    w.created_at AS added_at,
+    p.name,
+    p.price,
+    p.stock,
+    p.image_url,
+    p.is_active
+FROM wishlist_items w
+JOIN products p ON p.id = w.product_id
+WHERE w.user_id = $1
+ORDER BY w.created_at DESC;
+
+-- name: IsInWishlist :one
+SELECT EXISTS (
+    SELECT 1 FROM wishlist_items
+    WHERE user_id = $1 AND product_id = $2
+);
+
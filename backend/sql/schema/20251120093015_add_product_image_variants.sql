@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE product_images
+    ADD COLUMN IF NOT EXISTS thumbnail_url TEXT,
+    ADD COLUMN IF NOT EXISTS medium_url TEXT,
+    ADD COLUMN IF NOT EXISTS phash BIGINT,
+    ADD COLUMN IF NOT EXISTS duplicate_of_product_id BIGINT REFERENCES products (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS product_images_duplicate_of_idx ON product_images (duplicate_of_product_id)
+    WHERE duplicate_of_product_id IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS product_images_duplicate_of_idx;
+ALTER TABLE product_images
+    DROP COLUMN IF EXISTS duplicate_of_product_id,
+    DROP COLUMN IF EXISTS phash,
+    DROP COLUMN IF EXISTS medium_url,
+    DROP COLUMN IF EXISTS thumbnail_url;
+-- +goose StatementEnd
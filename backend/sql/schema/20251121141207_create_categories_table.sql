@@ -0,0 +1,100 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS categories (
+    id BIGSERIAL PRIMARY KEY,
+    parent_id BIGINT REFERENCES categories (id) ON DELETE RESTRICT,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    icon TEXT,
+    display_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
+
+INSERT INTO categories (name, slug, icon, display_order) VALUES
+    ('Books', 'books', 'book-open', 1),
+    ('Electronics', 'electronics', 'laptop', 2),
+    ('Stationery', 'stationery', 'pencil', 3),
+    ('Lab Equipment', 'lab-equipment', 'flask-conical', 4),
+    ('Hostel Essentials', 'hostel-essentials', 'bed', 5),
+    ('Clothing', 'clothing', 'shirt', 6),
+    ('Sports & Fitness', 'sports', 'dumbbell', 7),
+    ('Bicycles', 'bicycles', 'bike', 8),
+    ('Other', 'other', 'package', 99)
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO categories (parent_id, name, slug, icon, display_order)
+SELECT p.id, c.name, c.slug, c.icon, c.display_order
+FROM (VALUES
+    ('books', 'Engineering', 'books-engineering', 'book', 1),
+    ('books', 'Competitive Exams', 'books-competitive-exams', 'book', 2),
+    ('books', 'Fiction & Novels', 'books-fiction', 'book', 3),
+    ('electronics', 'Laptops', 'electronics-laptops', 'laptop', 1),
+    ('electronics', 'Calculators', 'electronics-calculators', 'calculator', 2),
+    ('electronics', 'Mobile Phones', 'electronics-phones', 'smartphone', 3),
+    ('electronics', 'Accessories', 'electronics-accessories', 'headphones', 4),
+    ('lab-equipment', 'Lab Coats', 'lab-equipment-coats', 'shirt', 1),
+    ('lab-equipment', 'Drafters & Instruments', 'lab-equipment-drafters', 'ruler', 2)
+) AS c (parent_slug, name, slug, icon, display_order)
+JOIN categories p ON p.slug = c.parent_slug
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO categories (parent_id, name, slug, icon, display_order)
+SELECT p.id, c.name, c.slug, c.icon, c.display_order
+FROM (VALUES
+    ('books-engineering', 'CSE', 'books-engineering-cse', 'book', 1),
+    ('books-engineering', 'ECE', 'books-engineering-ece', 'book', 2),
+    ('books-engineering', 'Electrical', 'books-engineering-ee', 'book', 3),
+    ('books-engineering', 'Mechanical', 'books-engineering-me', 'book', 4),
+    ('books-engineering', 'Civil', 'books-engineering-ce', 'book', 5)
+) AS c (parent_slug, name, slug, icon, display_order)
+JOIN categories p ON p.slug = c.parent_slug
+ON CONFLICT (slug) DO NOTHING;
+
+-- Map the old free-text values onto the taxonomy by slug or name; anything
+-- unrecognised lands in "other".
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id BIGINT REFERENCES categories (id) ON DELETE RESTRICT;
+
+UPDATE products p
+SET category_id = c.id
+FROM categories c
+WHERE lower(trim(p.category)) IN (c.slug, lower(c.name));
+
+UPDATE products
+SET category_id = (SELECT id FROM categories WHERE slug = 'other')
+WHERE category_id IS NULL;
+
+UPDATE products p
+SET category = c.slug
+FROM categories c
+WHERE c.id = p.category_id;
+
+ALTER TABLE products ALTER COLUMN category_id SET NOT NULL;
+ALTER TABLE products ALTER COLUMN category SET DEFAULT 'other';
+
+CREATE INDEX IF NOT EXISTS products_category_id_idx ON products (category_id);
+
+UPDATE products
+SET condition = CASE
+    WHEN lower(trim(condition)) IN ('new', 'brand new', 'sealed') THEN 'new'
+    WHEN lower(trim(condition)) IN ('like_new', 'like new', 'like-new', 'mint') THEN 'like_new'
+    WHEN lower(trim(condition)) IN ('fair', 'ok', 'okay') THEN 'fair'
+    WHEN lower(trim(condition)) IN ('poor', 'damaged', 'for parts') THEN 'poor'
+    ELSE 'good'
+END;
+
+ALTER TABLE products ADD CONSTRAINT products_condition_check
+    CHECK (condition IN ('new', 'like_new', 'good', 'fair', 'poor'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_condition_check;
+DROP INDEX IF EXISTS products_category_id_idx;
+ALTER TABLE products ALTER COLUMN category SET DEFAULT 'general';
+ALTER TABLE products DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS categories;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS seller_name,
+    DROP COLUMN IF EXISTS seller_phone;
+
+CREATE INDEX IF NOT EXISTS products_seller_id_idx ON products (seller_id);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS rating_total INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN IF EXISTS rating_count,
+    DROP COLUMN IF EXISTS rating_total;
+
+DROP INDEX IF EXISTS products_seller_id_idx;
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS seller_name TEXT,
+    ADD COLUMN IF NOT EXISTS seller_phone TEXT;
+
+UPDATE products p
+SET seller_name = u.name,
+    seller_phone = COALESCE(u.phone_number, '')
+FROM users u
+WHERE u.id = p.seller_id;
+
+ALTER TABLE products
+    ALTER COLUMN seller_name SET NOT NULL,
+    ALTER COLUMN seller_phone SET NOT NULL;
+-- +goose StatementEnd
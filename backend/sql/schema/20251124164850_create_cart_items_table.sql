@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    price_at_add INT NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, product_id)
+);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON cart_items
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS set_timestamp ON cart_items;
+DROP TABLE IF EXISTS cart_items;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS product_alerts (
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('price_drop', 'back_in_stock')),
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, product_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS product_alerts_product_id_idx ON product_alerts (product_id, kind);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS alert_mode TEXT NOT NULL DEFAULT 'instant'
+    CHECK (alert_mode IN ('instant', 'digest', 'off'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS alert_mode;
+DROP TABLE IF EXISTS product_alerts;
+-- +goose StatementEnd
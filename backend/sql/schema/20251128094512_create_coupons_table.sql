@@ -0,0 +1,67 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS platform_accounts (
+    name TEXT PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id)
+);
+
+INSERT INTO users (name, email, user_type, email_verified)
+VALUES ('Unimart Promotions', 'promotions@unimart.internal', 'platform', TRUE)
+ON CONFLICT (email) DO NOTHING;
+
+INSERT INTO wallets (user_id)
+SELECT id FROM users WHERE email = 'promotions@unimart.internal'
+ON CONFLICT (user_id) DO NOTHING;
+
+INSERT INTO platform_accounts (name, user_id)
+SELECT 'promo', id FROM users WHERE email = 'promotions@unimart.internal'
+ON CONFLICT (name) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id BIGSERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE CHECK (code = UPPER(code)),
+    description TEXT NOT NULL DEFAULT '',
+    discount_type TEXT NOT NULL CHECK (discount_type IN ('percent', 'flat')),
+    discount_value INT NOT NULL CHECK (discount_value > 0),
+    max_discount INT,
+    min_cart_value INT NOT NULL DEFAULT 0,
+    usage_limit INT,
+    per_user_limit INT NOT NULL DEFAULT 1,
+    times_used INT NOT NULL DEFAULT 0,
+    category_id BIGINT REFERENCES categories (id),
+    seller_id BIGINT REFERENCES users (id),
+    starts_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP(0) WITH TIME ZONE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (discount_type <> 'percent' OR discount_value <= 100)
+);
+
+ALTER TABLE orders
+    ADD COLUMN coupon_id BIGINT REFERENCES coupons (id),
+    ADD COLUMN discount_amount BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id BIGSERIAL PRIMARY KEY,
+    coupon_id BIGINT NOT NULL REFERENCES coupons (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    discount_amount BIGINT NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS coupon_redemptions_coupon_user_idx ON coupon_redemptions (coupon_id, user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS coupon_redemptions;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS coupon_id;
+DROP TABLE IF EXISTS coupons;
+DROP TABLE IF EXISTS platform_accounts;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Commission is in basis points. A category without its own rate inherits
+-- its parent's; top-level categories without one use the configured default.
+ALTER TABLE categories ADD COLUMN commission_bps INT CHECK (commission_bps BETWEEN 0 AND 10000);
+
+ALTER TABLE order_items
+    ADD COLUMN commission_bps INT NOT NULL DEFAULT 0,
+    ADD COLUMN commission_amount BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN seller_payout BIGINT NOT NULL DEFAULT 0;
+
+UPDATE order_items SET seller_payout = price_at_purchase::BIGINT * quantity * 100;
+
+INSERT INTO users (name, email, user_type, email_verified)
+VALUES ('Unimart Revenue', 'revenue@unimart.internal', 'platform', TRUE)
+ON CONFLICT (email) DO NOTHING;
+
+INSERT INTO wallets (user_id)
+SELECT id FROM users WHERE email = 'revenue@unimart.internal'
+ON CONFLICT (user_id) DO NOTHING;
+
+INSERT INTO platform_accounts (name, user_id)
+SELECT 'revenue', id FROM users WHERE email = 'revenue@unimart.internal'
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM platform_accounts WHERE name = 'revenue';
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS seller_payout,
+    DROP COLUMN IF EXISTS commission_amount,
+    DROP COLUMN IF EXISTS commission_bps;
+ALTER TABLE categories DROP COLUMN IF EXISTS commission_bps;
+-- +goose StatementEnd
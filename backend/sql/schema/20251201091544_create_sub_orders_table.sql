@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+
+INSERT INTO users (name, email, user_type, email_verified)
+VALUES ('Unimart Escrow', 'escrow@unimart.internal', 'platform', TRUE)
+ON CONFLICT (email) DO NOTHING;
+
+INSERT INTO wallets (user_id)
+SELECT id FROM users WHERE email = 'escrow@unimart.internal'
+ON CONFLICT (user_id) DO NOTHING;
+
+INSERT INTO platform_accounts (name, user_id)
+SELECT 'escrow', id FROM users WHERE email = 'escrow@unimart.internal'
+ON CONFLICT (name) DO NOTHING;
+
+-- One shipment per seller in an order. Amounts are in paise: gross is what
+-- the items cost, discount is this shipment's share of any coupon, and
+-- commission + payout = gross once it is released to the seller.
+CREATE TABLE IF NOT EXISTS sub_orders (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    seller_id BIGINT NOT NULL REFERENCES users (id),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'cancelled')),
+    gross BIGINT NOT NULL,
+    discount BIGINT NOT NULL DEFAULT 0,
+    commission BIGINT NOT NULL DEFAULT 0,
+    payout BIGINT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP(0) WITH TIME ZONE,
+    cancelled_at TIMESTAMP(0) WITH TIME ZONE,
+    cancelled_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (order_id, seller_id)
+);
+
+CREATE INDEX IF NOT EXISTS sub_orders_seller_id_idx ON sub_orders (seller_id);
+
+ALTER TABLE order_items ADD COLUMN sub_order_id BIGINT REFERENCES sub_orders (id) ON DELETE CASCADE;
+
+-- Orders placed before the split were paid out at checkout.
+INSERT INTO sub_orders (order_id, seller_id, status, gross, commission, payout, completed_at, created_at)
+SELECT
+    oi.order_id,
+    oi.seller_id,
+    'completed',
+    SUM(oi.price_at_purchase::BIGINT * oi.quantity * 100),
+    SUM(oi.commission_amount),
+    SUM(oi.seller_payout),
+    MIN(oi.created_at),
+    MIN(oi.created_at)
+FROM order_items oi
+GROUP BY oi.order_id, oi.seller_id
+ON CONFLICT (order_id, seller_id) DO NOTHING;
+
+UPDATE order_items oi
+SET sub_order_id = so.id
+FROM sub_orders so
+WHERE so.order_id = oi.order_id AND so.seller_id = oi.seller_id;
+
+CREATE INDEX IF NOT EXISTS order_items_sub_order_id_idx ON order_items (sub_order_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_items DROP COLUMN IF EXISTS sub_order_id;
+DROP TABLE IF EXISTS sub_orders;
+DELETE FROM platform_accounts WHERE name = 'escrow';
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS campus_locations (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    display_order INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO campus_locations (name, display_order) VALUES
+    ('Main Gate', 1),
+    ('Central Library', 2),
+    ('Student Activity Centre', 3),
+    ('Main Canteen', 4),
+    ('Academic Block A Lobby', 5),
+    ('Hostel Office', 6)
+ON CONFLICT (name) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS pickup_slots (
+    id BIGSERIAL PRIMARY KEY,
+    sub_order_id BIGINT NOT NULL REFERENCES sub_orders (id) ON DELETE CASCADE,
+    location_id BIGINT NOT NULL REFERENCES campus_locations (id),
+    starts_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS pickup_slots_sub_order_id_idx ON pickup_slots (sub_order_id);
+
+-- The buyer's chosen slot and the hash of their handover code. The code is
+-- cleared once the seller has entered it.
+CREATE TABLE IF NOT EXISTS handovers (
+    sub_order_id BIGINT PRIMARY KEY REFERENCES sub_orders (id) ON DELETE CASCADE,
+    slot_id BIGINT NOT NULL REFERENCES pickup_slots (id) ON DELETE CASCADE,
+    code_hash TEXT,
+    code_expires_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    handed_over_at TIMESTAMP(0) WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS handovers;
+DROP TABLE IF EXISTS pickup_slots;
+DROP TABLE IF EXISTS campus_locations;
+-- +goose StatementEnd
@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A conversation is between a buyer and a seller about either a product
+-- (before buying) or a sub-order (after). Each side keeps the ID of the last
+-- message it has read, which is what unread counts are worked out from.
+CREATE TABLE IF NOT EXISTS conversations (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT REFERENCES products (id) ON DELETE CASCADE,
+    sub_order_id BIGINT REFERENCES sub_orders (id) ON DELETE CASCADE,
+    buyer_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    seller_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    buyer_last_read_id BIGINT NOT NULL DEFAULT 0,
+    seller_last_read_id BIGINT NOT NULL DEFAULT 0,
+    last_message_at TIMESTAMP(0) WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (num_nonnulls(product_id, sub_order_id) = 1),
+    CHECK (buyer_id <> seller_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS conversations_product_buyer_idx
+    ON conversations (product_id, buyer_id) WHERE product_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS conversations_sub_order_idx
+    ON conversations (sub_order_id) WHERE sub_order_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS conversations_buyer_id_idx ON conversations (buyer_id);
+CREATE INDEX IF NOT EXISTS conversations_seller_id_idx ON conversations (seller_id);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id BIGSERIAL PRIMARY KEY,
+    conversation_id BIGINT NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    sender_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    body TEXT NOT NULL CHECK (char_length(body) BETWEEN 1 AND 2000),
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS messages_conversation_id_idx ON messages (conversation_id, id DESC);
+
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    blocked_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE TABLE IF NOT EXISTS message_reports (
+    id BIGSERIAL PRIMARY KEY,
+    message_id BIGINT NOT NULL REFERENCES messages (id) ON DELETE CASCADE,
+    reporter_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('spam', 'harassment', 'scam', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (message_id, reporter_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS message_reports;
+DROP TABLE IF EXISTS user_blocks;
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS conversations;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- One review per order item, left by its buyer once the sub-order is
+-- completed. Hidden reviews stay in the table but are left out of listings
+-- and of the seller's cached rating on users.
+CREATE TABLE IF NOT EXISTS reviews (
+    id BIGSERIAL PRIMARY KEY,
+    order_item_id BIGINT NOT NULL UNIQUE REFERENCES order_items (id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    seller_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    buyer_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT NOT NULL DEFAULT '',
+    seller_reply TEXT,
+    replied_at TIMESTAMP(0) WITH TIME ZONE,
+    flag_count INT NOT NULL DEFAULT 0,
+    is_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS reviews_product_id_idx ON reviews (product_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS reviews_seller_id_idx ON reviews (seller_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS reviews_flagged_idx ON reviews (flag_count DESC) WHERE flag_count > 0;
+
+CREATE TABLE IF NOT EXISTS review_flags (
+    review_id BIGINT NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('spam', 'offensive', 'irrelevant', 'fake', 'other')),
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (review_id, user_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS review_flags;
+DROP TABLE IF EXISTS reviews;
+-- +goose StatementEnd
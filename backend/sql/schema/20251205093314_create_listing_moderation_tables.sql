@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Held and removed listings are also made inactive, so every query that
+-- already filters on is_active keeps them out of sight.
+ALTER TABLE products
+    ADD COLUMN moderation_status TEXT NOT NULL DEFAULT 'active'
+        CHECK (moderation_status IN ('active', 'held', 'removed')),
+    ADD COLUMN moderation_reason TEXT;
+
+CREATE TABLE IF NOT EXISTS listing_reports (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    reporter_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('prohibited', 'counterfeit', 'misleading', 'offensive', 'spam', 'scam', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'actioned')),
+    resolved_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP(0) WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (product_id, reporter_id)
+);
+
+CREATE INDEX IF NOT EXISTS listing_reports_open_idx ON listing_reports (product_id) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS banned_terms (
+    id BIGSERIAL PRIMARY KEY,
+    pattern TEXT NOT NULL UNIQUE,
+    is_regex BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS banned_terms;
+DROP TABLE IF EXISTS listing_reports;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS moderation_reason,
+    DROP COLUMN IF EXISTS moderation_status;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Parsed from the university email at signup when ROLL_NUMBER_PATTERN is set.
+ALTER TABLE users
+    ADD COLUMN roll_number TEXT,
+    ADD COLUMN batch TEXT,
+    ADD COLUMN branch TEXT;
+
+-- Exceptions to the allowed signup domains. A pattern is either a full
+-- address or a bare domain, always stored lower-case.
+CREATE TABLE IF NOT EXISTS signup_email_rules (
+    id BIGSERIAL PRIMARY KEY,
+    pattern TEXT NOT NULL UNIQUE,
+    rule TEXT NOT NULL CHECK (rule IN ('allow', 'deny')),
+    note TEXT NOT NULL DEFAULT '',
+    created_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS signup_email_rules;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS branch,
+    DROP COLUMN IF EXISTS batch,
+    DROP COLUMN IF EXISTS roll_number;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN avatar_url TEXT,
+    ADD COLUMN hostel TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN IF EXISTS hostel,
+    DROP COLUMN IF EXISTS avatar_url;
+-- +goose StatementEnd
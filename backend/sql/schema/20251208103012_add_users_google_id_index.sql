@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS users_google_id_idx ON users (google_id) WHERE google_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_google_id_idx;
+-- +goose StatementEnd
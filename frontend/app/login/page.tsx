@@ -35,6 +35,7 @@ export default function LoginPage() {
 		try {
 			const response = await fetch("http://localhost:8088/login", {
 				method: "POST",
+				credentials: "include",
 				headers: { "Content-Type": "application/json" },
 				body: JSON.stringify(formData),
 			})
@@ -17,12 +17,21 @@ interface Product {
 	Stock: number;
 	Description: string;
 	ImageUrl: string;
-	SellerName?: string;
-	SellerPhone?: string;
 	SellerID: number;
+	seller?: SellerSummary;
 	images?: ProductImage[];
 }
 
+interface SellerSummary {
+	id: number;
+	name: string;
+	rating_average: number;
+	rating_count: number;
+	joined_at: string;
+	verified: boolean;
+	phone?: string;
+}
+
 export default function ProductDetailsPage() {
 	const { id } = useParams();
 	const [product, setProduct] = useState<Product | null>(null);
@@ -74,14 +83,12 @@ export default function ProductDetailsPage() {
 
 		try {
 			const token = localStorage.getItem("access_token");
-			if (!token) {
-				throw new Error("Please log in to add items to your cart");
-			}
 
 			const res = await fetch("http://localhost:8088/cart/add", {
 				method: "POST",
+				credentials: "include",
 				headers: {
-					Authorization: `Bearer ${token}`,
+					...(token ? { Authorization: `Bearer ${token}` } : {}),
 					"Content-Type": "application/json",
 				},
 				body: JSON.stringify({
@@ -92,7 +99,7 @@ export default function ProductDetailsPage() {
 
 			if (!res.ok) {
 				const errorData = await res.json();
-				throw new Error(errorData.error || "Failed to add to cart");
+				throw new Error(errorData.message || errorData.error || "Failed to add to cart");
 			}
 
 			
@@ -180,10 +187,17 @@ export default function ProductDetailsPage() {
 					<h2 className="text-xl font-semibold mt-6 mb-2">Seller Details</h2>
 					<div className="bg-gray-100 p-4 rounded-lg">
 						<p>
-							<strong>Name:</strong> {product.SellerName || "Unknown Seller"}
+							<strong>Name:</strong> {product.seller?.name || "Unknown Seller"}
+							{product.seller?.verified && <span className="ml-2 text-green-600">✓ Verified</span>}
+						</p>
+						<p>
+							<strong>Rating:</strong>{" "}
+							{product.seller?.rating_count
+								? `${product.seller.rating_average.toFixed(1)} (${product.seller.rating_count})`
+								: "No ratings yet"}
 						</p>
 						<p>
-							<strong>Phone:</strong> {product.SellerPhone || "N/A"}
+							<strong>Phone:</strong> {product.seller?.phone || "Shared after you place an order"}
 						</p>
 						<p>
 							<strong>Seller ID:</strong> {product.SellerID}
@@ -12,6 +12,8 @@ interface CartItem {
 	condition?: string;
 	price?: number;
 	quantity?: number;
+	available?: boolean;
+	warnings?: { code: string; message: string }[];
 	[key: string]: any;
 }
 
@@ -22,6 +24,9 @@ export default function CartPage() {
 	const [updating, setUpdating] = useState<number | null>(null);
 	const [checkingOut, setCheckingOut] = useState(false);
 	const [error, setError] = useState<string | null>(null);
+	const [couponCode, setCouponCode] = useState("");
+	const [appliedCoupon, setAppliedCoupon] = useState<{ code: string; discount: number } | null>(null);
+	const [couponError, setCouponError] = useState<string | null>(null);
 
 	useEffect(() => {
 		fetchCart();
@@ -91,7 +96,7 @@ export default function CartPage() {
 				await fetchCart();
 			} else {
 				const errorData = await res.json();
-				throw new Error(errorData.error || "Failed to update quantity");
+				throw new Error(errorData.message || errorData.error || "Failed to update quantity");
 			}
 		} catch (err: any) {
 			setError(err.message);
@@ -160,6 +165,35 @@ export default function CartPage() {
 		}
 	};
 
+	const applyCoupon = async () => {
+		setCouponError(null);
+
+		try {
+			const token = localStorage.getItem("access_token");
+			if (!token) throw new Error("Please log in to use a coupon");
+
+			const res = await fetch("http://localhost:8088/coupons/preview", {
+				method: "POST",
+				headers: {
+					Authorization: `Bearer ${token}`,
+					"Content-Type": "application/json",
+				},
+				body: JSON.stringify({ code: couponCode }),
+			});
+
+			const data = await res.json();
+			if (!res.ok) {
+				throw new Error(data.message || data.error || "Could not apply coupon");
+			}
+
+			// Quotes are in paise.
+			setAppliedCoupon({ code: data.code, discount: data.discount / 100 });
+		} catch (err: any) {
+			setAppliedCoupon(null);
+			setCouponError(err.message);
+		}
+	};
+
 	const proceedToCheckout = async () => {
 		setCheckingOut(true);
 		setError(null);
@@ -174,14 +208,17 @@ export default function CartPage() {
 					Authorization: `Bearer ${token}`,
 					"Content-Type": "application/json",
 				},
+				body: JSON.stringify({ coupon_code: appliedCoupon?.code || "" }),
 			});
 
 			if (res.ok) {
 				alert("Order created successfully!");
 				setItems([]);
+				setAppliedCoupon(null);
+				setCouponCode("");
 			} else {
 				const errorData = await res.json();
-				throw new Error(errorData.error || "Failed to create order");
+				throw new Error(errorData.message || errorData.error || "Failed to create order");
 			}
 		} catch (err: any) {
 			setError(err.message);
@@ -192,7 +229,8 @@ export default function CartPage() {
 	};
 
 	const total = items.reduce(
-		(sum, item) => sum + (item.price || 0) * (item.quantity || 0),
+		(sum, item) =>
+			item.available === false ? sum : sum + (item.price || 0) * (item.quantity || 0),
 		0
 	);
 
@@ -263,6 +301,12 @@ export default function CartPage() {
 										<p className="text-sm font-medium text-blue-600 mt-1">
 											₹{(item.price || 0).toLocaleString()} each
 										</p>
+
+										{item.warnings?.map((w) => (
+											<p key={w.code} className="text-xs text-amber-600 mt-1">
+												{w.message}
+											</p>
+										))}
 									</div>
 								</div>
 
@@ -331,13 +375,39 @@ export default function CartPage() {
 					</div>
 
 					<div className="bg-white rounded-lg shadow p-4 md:p-6">
+						<div className="flex gap-2 mb-2">
+							<input
+								type="text"
+								value={couponCode}
+								onChange={(e) => {
+									setCouponCode(e.target.value);
+									setAppliedCoupon(null);
+								}}
+								placeholder="Coupon code"
+								className="flex-1 border rounded-lg px-3 py-2 text-sm uppercase"
+							/>
+							<button
+								onClick={applyCoupon}
+								disabled={!couponCode.trim()}
+								className="bg-gray-800 text-white px-4 py-2 rounded-lg text-sm hover:bg-gray-900 disabled:opacity-50"
+							>
+								Apply
+							</button>
+						</div>
+						{couponError && <p className="text-xs text-red-600 mb-2">{couponError}</p>}
+						{appliedCoupon && (
+							<p className="text-xs text-green-600 mb-2">
+								{appliedCoupon.code} applied: −₹{appliedCoupon.discount.toLocaleString()}
+							</p>
+						)}
+
 						<div className="border-t border-gray-200 pt-4">
 							<div className="flex items-center justify-between mb-4">
 								<span className="text-base md:text-lg font-semibold text-gray-900">
 									Total:
 								</span>
 								<span className="text-xl md:text-2xl font-bold text-blue-600">
-									₹{total.toLocaleString()}
+									₹{(total - (appliedCoupon?.discount || 0)).toLocaleString()}
 								</span>
 							</div>
 
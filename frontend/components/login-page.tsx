@@ -30,6 +30,7 @@ export default function LoginPage({ onLoginSuccess, onSwitchToSignup }: LoginPag
 		try {
 			const response = await fetch("http://localhost:8088/login", {
 				method: "POST",
+				credentials: "include",
 				headers: {
 					"Content-Type": "application/json",
 				},
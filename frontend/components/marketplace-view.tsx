@@ -20,7 +20,6 @@ interface Product {
     Condition?: string;
     SellerID: number;
     SellerName?: string;
-    SellerPhone?: string;
     ImageUrl?: string;
     Images?: string[];
 }
@@ -84,7 +83,7 @@ export default function MarketplaceView({
         price: Number(product.Price),
         condition: product.Condition || "Good",
         seller: product.SellerName || `Seller ${product.SellerID}`,
-        phone: product.SellerPhone || "Contact seller",
+        phone: "Contact seller",
         image: product.ImageUrl || "/placeholder.jpg",
         images: product.Images || [],
         description: product.Description,
@@ -14,6 +14,26 @@ interface PostedItem {
     UpdatedAt: string;
 }
 
+// Amounts are in paise.
+interface SalesSummary {
+    sales: {
+        order_item_id: number;
+        order_id: number;
+        product_name: string;
+        quantity: number;
+        gross: number;
+        commission_bps: number;
+        commission: number;
+        payout: number;
+        sold_at: string;
+    }[];
+    gross: number;
+    commission: number;
+    payout: number;
+}
+
+const rupees = (paise: number) => `₹${(paise / 100).toLocaleString()}`;
+
 interface ProfilePageProps {
     userData: {
         username: string;
@@ -28,11 +48,35 @@ export default function ProfilePage({ userData }: ProfilePageProps) {
     const [loading, setLoading] = useState(true);
     const [username, setUsername] = useState(userData.username);
     const [phone, setPhone] = useState(userData.phone);
+    const [sales, setSales] = useState<SalesSummary | null>(null);
 
     useEffect(() => {
         fetchUserPostedItems();
+        fetchSales();
     }, []);
 
+    const fetchSales = async () => {
+        try {
+            const token = localStorage.getItem("access_token");
+            if (!token) return;
+
+            const response = await fetch("http://localhost:8088/orders/sales", {
+                headers: {
+                    Authorization: `Bearer ${token}`,
+                },
+            });
+
+            if (!response.ok) {
+                console.error("Failed to fetch sales");
+                return;
+            }
+
+            setSales(await response.json());
+        } catch (error) {
+            console.error("Error fetching sales:", error);
+        }
+    };
+
     const fetchUserPostedItems = async () => {
         try {
             const token = localStorage.getItem("access_token");
@@ -166,6 +210,57 @@ export default function ProfilePage({ userData }: ProfilePageProps) {
                     ))}
                 </div>
             )}
+
+            {sales && sales.sales.length > 0 && (
+                <>
+                    <h2 className="text-xl font-semibold mt-8 mb-4">Your Sales</h2>
+
+                    <div className="grid grid-cols-3 gap-6 mb-4">
+                        <div className="bg-white rounded-lg shadow p-4">
+                            <p className="text-sm text-gray-500">Gross</p>
+                            <p className="mt-1 font-semibold">{rupees(sales.gross)}</p>
+                        </div>
+                        <div className="bg-white rounded-lg shadow p-4">
+                            <p className="text-sm text-gray-500">Platform Fees</p>
+                            <p className="mt-1 font-semibold text-red-600">−{rupees(sales.commission)}</p>
+                        </div>
+                        <div className="bg-white rounded-lg shadow p-4">
+                            <p className="text-sm text-gray-500">Paid to You</p>
+                            <p className="mt-1 font-semibold text-green-600">{rupees(sales.payout)}</p>
+                        </div>
+                    </div>
+
+                    <table className="w-full bg-white rounded-lg shadow text-sm">
+                        <thead>
+                            <tr className="text-left text-gray-500 border-b">
+                                <th className="p-3">Item</th>
+                                <th className="p-3">Qty</th>
+                                <th className="p-3 text-right">Gross</th>
+                                <th className="p-3 text-right">Fee</th>
+                                <th className="p-3 text-right">Payout</th>
+                                <th className="p-3">Date</th>
+                            </tr>
+                        </thead>
+                        <tbody>
+                            {sales.sales.map((sale) => (
+                                <tr key={sale.order_item_id} className="border-b last:border-0">
+                                    <td className="p-3">{sale.product_name}</td>
+                                    <td className="p-3">{sale.quantity}</td>
+                                    <td className="p-3 text-right">{rupees(sale.gross)}</td>
+                                    <td className="p-3 text-right">
+                                        {rupees(sale.commission)}
+                                        {sale.commission_bps > 0 && (
+                                            <span className="text-gray-400"> ({sale.commission_bps / 100}%)</span>
+                                        )}
+                                    </td>
+                                    <td className="p-3 text-right">{rupees(sale.payout)}</td>
+                                    <td className="p-3">{new Date(sale.sold_at).toLocaleDateString()}</td>
+                                </tr>
+                            ))}
+                        </tbody>
+                    </table>
+                </>
+            )}
         </div>
     );
 }
@@ -15,10 +15,10 @@ interface SellItemFormProps {
 export default function SellItemForm({ onSubmit, userData }: SellItemFormProps) {
 	const [formData, setFormData] = useState({
 		name: "",
-		category: "Books",
+		category: "books",
 		description: "",
 		price: "",
-		condition: "Good",
+		condition: "good",
 		stock: "1",
 	})
 
@@ -136,10 +136,10 @@ export default function SellItemForm({ onSubmit, userData }: SellItemFormProps)
 
 			setFormData({
 				name: "",
-				category: "Books",
+				category: "books",
 				description: "",
 				price: "",
-				condition: "Good",
+				condition: "good",
 				stock: "1",
 			})
 			setImages([])
@@ -215,11 +215,15 @@ export default function SellItemForm({ onSubmit, userData }: SellItemFormProps)
 								onChange={handleChange}
 								className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none text-sm"
 							>
-								<option>Books</option>
-								<option>Electronics</option>
-								<option>Furniture</option>
-								<option>Clothing</option>
-								<option>Others</option>
+								<option value="books">Books</option>
+								<option value="electronics">Electronics</option>
+								<option value="stationery">Stationery</option>
+								<option value="lab-equipment">Lab Equipment</option>
+								<option value="hostel-essentials">Hostel Essentials</option>
+								<option value="clothing">Clothing</option>
+								<option value="sports">Sports &amp; Fitness</option>
+								<option value="bicycles">Bicycles</option>
+								<option value="other">Other</option>
 							</select>
 						</div>
 
@@ -231,10 +235,11 @@ export default function SellItemForm({ onSubmit, userData }: SellItemFormProps)
 								onChange={handleChange}
 								className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none text-sm"
 							>
-								<option>New</option>
-								<option>Like New</option>
-								<option>Good</option>
-								<option>Used</option>
+								<option value="new">New</option>
+								<option value="like_new">Like New</option>
+								<option value="good">Good</option>
+								<option value="fair">Fair</option>
+								<option value="poor">Poor</option>
 							</select>
 						</div>
 					</div>